@@ -7,6 +7,7 @@
 package main
 
 import (
+	"context"
 	"os"
 
 	"github.com/whiteblock/genesis/pkg/config"
@@ -22,34 +23,90 @@ import (
 	queue "github.com/whiteblock/amqp"
 )
 
-func getRestServer() (controller.RestController, error) {
+func getExecutionRepository(conf config.Config) repository.ExecutionRepository {
+	if conf.Execution.StateStore == "memory" {
+		return repository.NewMemoryExecutionRepository()
+	}
+	return repository.NewFileExecutionRepository(conf.Execution.StateDir, conf.GetLogger())
+}
+
+func getDockerService(conf config.Config) service.DockerService {
+	return service.NewDockerService(
+		repository.NewDockerRepository(conf.GetLogger()),
+		conf.Docker,
+		file.NewRemoteSources(
+			conf,
+			conf.GetLogger()),
+		conf.GetLogger())
+}
+
+func getScheduleService(conf config.Config, events service.EventService) service.ScheduleService {
+	return service.NewScheduleService(
+		getDockerService(conf),
+		events,
+		file.NewRemoteSources(
+			conf,
+			conf.GetLogger()),
+		conf.GetLogger())
+}
+
+func getChaosService(conf config.Config, events service.EventService) service.ChaosService {
+	return service.NewChaosService(
+		getDockerService(conf),
+		events,
+		conf.GetLogger())
+}
+
+func getStatsService(conf config.Config) service.StatsService {
+	return service.NewStatsService(
+		getDockerService(conf),
+		repository.NewFileStatsRepository(conf.Stats.Dir, conf.GetLogger()),
+		file.NewRemoteSources(
+			conf,
+			conf.GetLogger()),
+		conf.Stats,
+		conf.GetLogger())
+}
+
+func getRestServer(reaper service.ReaperService, execs repository.ExecutionRepository,
+	events service.EventService, schedules service.ScheduleService,
+	chaos service.ChaosService, stats service.StatsService) (controller.RestController, error) {
 	conf, err := config.NewConfig()
 	if err != nil {
 		return nil, err
 	}
 	config.SanityCheck(conf)
 
+	dockerUseCase := usecase.NewDockerUseCase(
+		getDockerService(conf),
+		schedules,
+		chaos,
+		stats,
+		conf.GetLogger())
+
 	return controller.NewRestController(
 		conf.GetRestConfig(),
 		handler.NewRestHandler(
 			handAux.NewExecutor(
 				conf.Execution,
-				usecase.NewDockerUseCase(
-					service.NewDockerService(
-						repository.NewDockerRepository(conf.GetLogger()),
-						conf.Docker,
-						file.NewRemoteSources(
-							conf,
-							conf.GetLogger()),
-						conf.GetLogger()),
-					conf.GetLogger()),
+				dockerUseCase,
+				reaper,
+				conf.GetLogger()),
+			dockerUseCase,
+			execs,
+			events,
+			reaper,
+			stats,
+			file.NewRemoteSources(
+				conf,
 				conf.GetLogger()),
 			conf.GetLogger()),
 		mux.NewRouter(),
 		conf.GetLogger()), nil
 }
 
-func getCommandController() (controller.CommandController, error) {
+func getCommandController(reaper service.ReaperService, schedules service.ScheduleService,
+	chaos service.ChaosService, stats service.StatsService) (controller.CommandController, error) {
 	conf, err := config.NewConfig()
 	if err != nil {
 		return nil, err
@@ -111,14 +168,12 @@ func getCommandController() (controller.CommandController, error) {
 			handAux.NewExecutor(
 				conf.Execution,
 				usecase.NewDockerUseCase(
-					service.NewDockerService(
-						repository.NewDockerRepository(conf.GetLogger()),
-						conf.Docker,
-						file.NewRemoteSources(
-							conf,
-							conf.GetLogger()),
-						conf.GetLogger()),
+					getDockerService(conf),
+					schedules,
+					chaos,
+					stats,
 					conf.GetLogger()),
+				reaper,
 				conf.GetLogger()),
 			conf,
 			conf.MaxMessageRetries,
@@ -138,18 +193,27 @@ func main() {
 		os.Exit(0)
 	}
 
-	restServer, err := getRestServer()
+	conf, err := config.NewConfig()
 	if err != nil {
 		panic(err)
 	}
 
-	conf, err := config.NewConfig()
+	execs := getExecutionRepository(conf)
+	reaper := service.NewReaperService(getDockerService(conf), execs, conf.Reaper, conf.GetLogger())
+	go reaper.Start(context.Background())
+
+	events := service.NewEventService(conf.GetLogger())
+	schedules := getScheduleService(conf, events)
+	chaos := getChaosService(conf, events)
+	stats := getStatsService(conf)
+
+	restServer, err := getRestServer(reaper, execs, events, schedules, chaos, stats)
 	if err != nil {
 		panic(err)
 	}
 
 	if !conf.LocalMode {
-		cmdCntl, err := getCommandController()
+		cmdCntl, err := getCommandController(reaper, schedules, chaos, stats)
 		if err != nil {
 			panic(err)
 		}
@@ -40,6 +40,8 @@ type Config struct {
 	Execution   Execution   `mapstructure:"-"`
 	Docker      Docker      `mapstructure:"-"`
 	FileHandler FileHandler `mapstructure:"-"`
+	Reaper      Reaper      `mapstructure:"-"`
+	Stats       Stats       `mapstructure:"-"`
 }
 
 // GetLogger gets a logger according to the config
@@ -115,6 +117,8 @@ func setViperEnvBindings() {
 	setExecutionBindings(viper.GetViper())
 	setDockerBindings(viper.GetViper())
 	setFileHandlerBindings(viper.GetViper())
+	setReaperBindings(viper.GetViper())
+	setStatsBindings(viper.GetViper())
 }
 
 func setViperDefaults() {
@@ -132,6 +136,8 @@ func setViperDefaults() {
 	setExecutionDefaults(viper.GetViper())
 	setDockerDefaults(viper.GetViper())
 	setFileHandlerDefaults(viper.GetViper())
+	setReaperDefaults(viper.GetViper())
+	setStatsDefaults(viper.GetViper())
 }
 
 func init() {
@@ -163,6 +169,16 @@ func NewConfig() (conf Config, err error) {
 		return
 	}
 
+	conf.Reaper, err = NewReaper(viper.GetViper())
+	if err != nil {
+		return
+	}
+
+	conf.Stats, err = NewStats(viper.GetViper())
+	if err != nil {
+		return
+	}
+
 	conf.Docker, err = NewDocker(viper.GetViper())
 	return
 }
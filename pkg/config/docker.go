@@ -7,6 +7,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
@@ -30,6 +32,13 @@ type Docker struct {
 	GlusterDriver string `mapstructure:"dockerGlusterDriver"`
 
 	GlusterMaxNanoCPU int64 `mapstructure:"dockerGlusterMaxNanoCPU"`
+
+	// ExecTimeout is the longest a command executed inside of a container may run for
+	ExecTimeout time.Duration `mapstructure:"dockerExecTimeout"`
+
+	// PartitionImage is the image of the sidecars which partition the network and check whether containers
+	// are ready, it must have iptables, ip6tables, nc and curl
+	PartitionImage string `mapstructure:"dockerPartitionImage"`
 }
 
 // NewDocker creates a new docker configuration from viper
@@ -73,6 +82,16 @@ func setDockerBindings(v *viper.Viper) error {
 		return err
 	}
 
+	err = v.BindEnv("dockerExecTimeout", "DOCKER_EXEC_TIMEOUT")
+	if err != nil {
+		return err
+	}
+
+	err = v.BindEnv("dockerPartitionImage", "DOCKER_PARTITION_IMAGE")
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -84,4 +103,6 @@ func setDockerDefaults(v *viper.Viper) {
 	v.SetDefault("dockerGlusterImage", "gcr.io/whiteblock/gluster:latest")
 	v.SetDefault("dockerGlusterDriver", "glusterfs")
 	v.SetDefault("dockerGlusterMaxNanoCPU", 2000000000)
+	v.SetDefault("dockerExecTimeout", 5*time.Minute)
+	v.SetDefault("dockerPartitionImage", "nicolaka/netshoot:latest")
 }
@@ -22,6 +22,11 @@ type Execution struct {
 	// not signal completion
 	DebugMode         bool          `mapstructure:"debugMode"`
 	DMCompletionDelay time.Duration `mapstructure:"dmCompletionDelay"`
+	// StateStore is the type of store used to keep track of executions in local mode,
+	// either "file" or "memory"
+	StateStore string `mapstructure:"stateStore"`
+	// StateDir is the directory in which the file state store keeps its data
+	StateDir string `mapstructure:"stateDir"`
 }
 
 // NewExecution creates a new Execution config from the given viper
@@ -50,6 +55,14 @@ func setExecutionBindings(v *viper.Viper) error {
 	if err != nil {
 		return err
 	}
+	err = v.BindEnv("stateStore", "STATE_STORE")
+	if err != nil {
+		return err
+	}
+	err = v.BindEnv("stateDir", "STATE_DIR")
+	if err != nil {
+		return err
+	}
 	return v.BindEnv("executionConnectionRetries", "EXECUTION_CONNECTION_RETRIES")
 }
 
@@ -60,4 +73,6 @@ func setExecutionDefaults(v *viper.Viper) {
 	v.SetDefault("executionTimeLimit", 10*time.Minute)
 	v.SetDefault("debugMode", false)
 	v.SetDefault("dmCompletionDelay", 2*time.Hour)
+	v.SetDefault("stateStore", "file")
+	v.SetDefault("stateDir", "/var/lib/genesis/state")
 }
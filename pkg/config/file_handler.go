@@ -15,6 +15,8 @@ import (
 type FileHandler struct {
 	APIEndpoint string        `mapstructure:"apiEndpoint"`
 	APITimeout  time.Duration `mapstructure:"apiTimeout"`
+	// ArtifactsDir is where the files produced by tests are kept in local mode
+	ArtifactsDir string `mapstructure:"artifactsDir"`
 }
 
 //NewFileHandler creates a new FileHandler config from the given viper
@@ -27,10 +29,15 @@ func setFileHandlerBindings(v *viper.Viper) error {
 	if err != nil {
 		return err
 	}
+	err = v.BindEnv("artifactsDir", "ARTIFACTS_DIR")
+	if err != nil {
+		return err
+	}
 	return v.BindEnv("apiEndpoint", "API_ENDPOINT")
 }
 
 func setFileHandlerDefaults(v *viper.Viper) {
 	v.SetDefault("apiEndpoint", "https://www.infra.whiteblock.io")
 	v.SetDefault("apiTimeout", 10*time.Second)
+	v.SetDefault("artifactsDir", "/var/lib/genesis/artifacts")
 }
@@ -0,0 +1,66 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Reaper is the configuration for the removal of resources left behind by tests
+type Reaper struct {
+	// LocalMode causes the local docker daemon to always be scanned
+	LocalMode bool `mapstructure:"localMode"`
+	// Interval is how often to look for orphaned resources, zero disables the periodic scan
+	Interval time.Duration `mapstructure:"reaperInterval"`
+	// TTL is the maximum age of a test's resources, regardless of whether the test is
+	// still running. Zero means there is no limit.
+	TTL time.Duration `mapstructure:"reaperTTL"`
+	// GracePeriod is how long the resources of a test which is not being run are left
+	// alone, to give it a chance to be resumed or torn down normally
+	GracePeriod time.Duration `mapstructure:"reaperGracePeriod"`
+	// DryRun causes the reaper to only report what it would remove
+	DryRun bool `mapstructure:"reaperDryRun"`
+	// Untracked allows the resources of tests which this instance knows nothing about to be reaped.
+	// It is off by default, since those tests may be run by another instance, or by this one
+	// before it was restarted.
+	Untracked bool `mapstructure:"reaperUntracked"`
+}
+
+// NewReaper creates a new Reaper config from the given viper
+func NewReaper(v *viper.Viper) (out Reaper, err error) {
+	return out, v.Unmarshal(&out)
+}
+
+func setReaperBindings(v *viper.Viper) error {
+	err := v.BindEnv("reaperInterval", "REAPER_INTERVAL")
+	if err != nil {
+		return err
+	}
+	err = v.BindEnv("reaperTTL", "REAPER_TTL")
+	if err != nil {
+		return err
+	}
+	err = v.BindEnv("reaperGracePeriod", "REAPER_GRACE_PERIOD")
+	if err != nil {
+		return err
+	}
+	err = v.BindEnv("reaperDryRun", "REAPER_DRY_RUN")
+	if err != nil {
+		return err
+	}
+	return v.BindEnv("reaperUntracked", "REAPER_UNTRACKED")
+}
+
+func setReaperDefaults(v *viper.Viper) {
+	v.SetDefault("reaperInterval", 10*time.Minute)
+	v.SetDefault("reaperTTL", 0)
+	v.SetDefault("reaperGracePeriod", 30*time.Minute)
+	v.SetDefault("reaperDryRun", true)
+	v.SetDefault("reaperUntracked", false)
+}
@@ -0,0 +1,39 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Stats is the configuration for the collection of the resource usage of the containers of tests
+type Stats struct {
+	// Dir is where the samples are kept until the test is torn down
+	Dir string `mapstructure:"statsDir"`
+	// Interval is how often the containers are sampled, when the order does not say
+	Interval time.Duration `mapstructure:"statsInterval"`
+}
+
+// NewStats creates a new Stats config from the given viper
+func NewStats(v *viper.Viper) (out Stats, err error) {
+	return out, v.Unmarshal(&out)
+}
+
+func setStatsBindings(v *viper.Viper) error {
+	err := v.BindEnv("statsDir", "STATS_DIR")
+	if err != nil {
+		return err
+	}
+	return v.BindEnv("statsInterval", "STATS_INTERVAL")
+}
+
+func setStatsDefaults(v *viper.Viper) {
+	v.SetDefault("statsDir", "/var/lib/genesis/stats")
+	v.SetDefault("statsInterval", 10*time.Second)
+}
@@ -43,8 +43,20 @@ func NewRestController(
 
 // Start starts the rest server, blocking the calling thread from returning
 func (rc restController) Start() {
+	rc.hand.ResumeExecutions()
 
 	rc.mux.HandleFunc("/command", rc.hand.AddCommands).Methods("POST")
+	rc.mux.HandleFunc("/executions", rc.hand.GetExecutions).Methods("GET")
+	rc.mux.HandleFunc("/executions/{id}", rc.hand.GetExecution).Methods("GET")
+	rc.mux.HandleFunc("/executions/{id}", rc.hand.CancelExecution).Methods("DELETE")
+	rc.mux.HandleFunc("/executions/{id}/events", rc.hand.StreamExecutionEvents).Methods("GET")
+	rc.mux.HandleFunc("/events", rc.hand.StreamEvents).Methods("GET")
+	rc.mux.HandleFunc("/artifacts/{test}", rc.hand.GetArtifacts).Methods("GET")
+	rc.mux.HandleFunc("/artifacts/{test}/{name}", rc.hand.GetArtifact).Methods("GET")
+	rc.mux.HandleFunc("/emulation/{container}", rc.hand.GetEmulation).Methods("GET")
+	rc.mux.HandleFunc("/stats/{test}", rc.hand.GetStats).Methods("GET")
+	rc.mux.HandleFunc("/reaper", rc.hand.PreviewReap).Methods("GET")
+	rc.mux.HandleFunc("/reaper", rc.hand.Reap).Methods("POST")
 	rc.mux.HandleFunc("/health", rc.hand.HealthCheck).Methods("GET")
 
 	rc.log.WithFields(logrus.Fields{"socket": rc.conf.Listen}).Info("listening for requests")
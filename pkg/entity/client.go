@@ -10,6 +10,7 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
@@ -49,24 +50,54 @@ type Client interface {
 	// ContainerInspect returns the container information.
 	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
 
+	// ContainerKill terminates the container process but does not remove the container from the docker host.
+	ContainerKill(ctx context.Context, containerID, signal string) error
+
 	// ContainerList returns the list of containers in the docker host.
 	ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error)
 
+	// ContainerLogs returns the logs generated by a container in an io.ReadCloser. Unless the
+	// container has a TTY, stdout and stderr are multiplexed in the stream.
 	ContainerLogs(ctx context.Context, container string, options types.ContainerLogsOptions) (io.ReadCloser, error)
 
+	// ContainerPause pauses the main process of a given container without terminating it.
+	ContainerPause(ctx context.Context, containerID string) error
+
 	// ContainerRemove kills and removes a container from the docker host.
 	ContainerRemove(ctx context.Context, containerID string, options types.ContainerRemoveOptions) error
 
+	// ContainerRestart stops and starts a container again. It makes the daemon to wait for the container
+	// to be up again for a specific amount of time, given the timeout.
+	ContainerRestart(ctx context.Context, containerID string, timeout *time.Duration) error
+
 	// ContainerStart sends a request to the docker daemon to start a container.
 	ContainerStart(ctx context.Context, containerID string, options types.ContainerStartOptions) error
 
+	// ContainerStats returns near realtime stats for a given container. When stream is set, a
+	// sample is written to the body every second until it is closed.
+	ContainerStats(ctx context.Context, containerID string, stream bool) (types.ContainerStats, error)
+
 	// ContainerStatPath returns Stat information about a path inside the container filesystem.
 	ContainerStatPath(ctx context.Context, containerID, path string) (types.ContainerPathStat, error)
 
+	// ContainerStop stops a container. In case the container fails to stop gracefully within a time frame
+	// specified by the timeout argument, it is forcefully terminated (killed).
+	ContainerStop(ctx context.Context, containerID string, timeout *time.Duration) error
+
+	// ContainerUnpause resumes the process execution within the container
+	ContainerUnpause(ctx context.Context, containerID string) error
+
+	// ContainerUpdate updates the resources of a container
+	ContainerUpdate(ctx context.Context, containerID string,
+		updateConfig container.UpdateConfig) (container.ContainerUpdateOKBody, error)
+
 	// ContainerWait waits until the specified container is in a certain state indicated by the given condition, either "not-running" (default), "next-exit", or "removed".
 	ContainerWait(ctx context.Context, containerID string,
 		condition container.WaitCondition) (<-chan container.ContainerWaitOKBody, <-chan error)
 
+	// CopyFromContainer gets the content from the container and returns it as a Reader for a TAR archive
+	CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, types.ContainerPathStat, error)
+
 	// CopyToContainer copies content into the container filesystem. Note that `content` must be a Reader for a TAR archive
 	CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader,
 		options types.CopyToContainerOptions) error
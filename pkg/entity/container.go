@@ -0,0 +1,185 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-units"
+	"github.com/whiteblock/definition/command"
+	"github.com/whiteblock/utility/utils"
+)
+
+// Ulimit is a limit of a resource of the processes of a container
+type Ulimit struct {
+	// Soft is the limit which is enforced
+	Soft int64 `json:"soft"`
+	// Hard is the ceiling which the soft limit may be raised to
+	Hard int64 `json:"hard"`
+}
+
+// Healthcheck is a docker HEALTHCHECK, which replaces that of the image
+type Healthcheck struct {
+	// Test is the check to run, such as ["CMD", "curl", "-f", "localhost"] or
+	// ["CMD-SHELL", "curl -f localhost || exit 1"]. ["NONE"] disables the check of the image.
+	Test []string `json:"test"`
+	// Interval is the time between checks
+	Interval command.Duration `json:"interval,omitempty"`
+	// Timeout is how long a check may run before it is considered to have failed
+	Timeout command.Duration `json:"timeout,omitempty"`
+	// StartPeriod is how long the container has to start before failing checks count
+	StartPeriod command.Duration `json:"startPeriod,omitempty"`
+	// Retries is how many checks in a row need to fail for the container to be unhealthy
+	Retries int `json:"retries,omitempty"`
+}
+
+// NetworkAttachment is a network which a container is attached to when it is created
+type NetworkAttachment struct {
+	// Name is the name of the network
+	Name string `json:"name"`
+	// IP is the static IP address of the container on the network, one is assigned when it is empty
+	IP string `json:"ip,omitempty"`
+	// IPv6 is the static IPv6 address of the container on the network
+	IPv6 string `json:"ipv6,omitempty"`
+	// Aliases are other names which the container can be reached by on the network
+	Aliases []string `json:"aliases,omitempty"`
+	// MacAddress is the MAC address of the container on the network, a random one is used when it is empty
+	MacAddress string `json:"macAddress,omitempty"`
+}
+
+// Container is the payload of a create container order. It extends the container of the
+// definition with more of the options of docker.
+type Container struct {
+	command.Container
+	// Workdir is the working directory of the command of the container
+	Workdir string `json:"workdir,omitempty"`
+	// Ulimits are the limits of the resources of the processes, by name such as nofile
+	Ulimits map[string]Ulimit `json:"ulimits,omitempty"`
+	// Sysctls are the kernel parameters to set in the namespaces of the container
+	Sysctls map[string]string `json:"sysctls,omitempty"`
+	// CapAdd are the kernel capabilities to add, such as NET_ADMIN
+	CapAdd []string `json:"capAdd,omitempty"`
+	// CapDrop are the kernel capabilities to drop
+	CapDrop []string `json:"capDrop,omitempty"`
+	// Privileged gives the container access to all of the devices of the host
+	Privileged bool `json:"privileged,omitempty"`
+	// Tmpfs are the tmpfs mounts, from the path to mount them on to their options such as "size=64m"
+	Tmpfs map[string]string `json:"tmpfs,omitempty"`
+	// ShmSize is the size of /dev/shm, such as "256MB", in MiB when there is no unit
+	ShmSize string `json:"shmSize,omitempty"`
+	// ExtraHosts are added to /etc/hosts, each as host:ip
+	ExtraHosts []string `json:"extraHosts,omitempty"`
+	// DNS are the addresses of the DNS servers of the container
+	DNS []string `json:"dns,omitempty"`
+	// RestartPolicy is when docker restarts the container, which is no, always, unless-stopped or
+	// on-failure with an optional maximum number of retries, such as on-failure:5
+	RestartPolicy string `json:"restartPolicy,omitempty"`
+	// Healthcheck replaces the HEALTHCHECK of the image
+	Healthcheck *Healthcheck `json:"healthcheck,omitempty"`
+	// Networks are the networks to attach the container to, along with its primary network. An
+	// attachment to the primary network sets its aliases and MAC address.
+	Networks []NetworkAttachment `json:"networks,omitempty"`
+	// IPv6 is the static IPv6 address of the container on its primary network
+	IPv6 string `json:"ipv6,omitempty"`
+}
+
+// GetNetworks gets all of the networks to attach the container to, the first of which is the
+// network which it is created on
+func (c Container) GetNetworks() []NetworkAttachment {
+	out := []NetworkAttachment{}
+	if len(c.Network) > 0 {
+		primary := NetworkAttachment{Name: c.Network, IP: c.IP, IPv6: c.IPv6}
+		for _, attachment := range c.Networks {
+			if attachment.Name == c.Network {
+				primary = attachment
+				if len(primary.IP) == 0 {
+					primary.IP = c.IP
+				}
+				if len(primary.IPv6) == 0 {
+					primary.IPv6 = c.IPv6
+				}
+			}
+		}
+		out = append(out, primary)
+	}
+	for _, attachment := range c.Networks {
+		if attachment.Name != c.Network {
+			out = append(out, attachment)
+		}
+	}
+	return out
+}
+
+// GetCmd gets the command of the container when it has no entrypoint, so that the args are
+// passed to the entrypoint of the image. Otherwise, the args are a part of the entrypoint.
+func (c Container) GetCmd() []string {
+	if len(c.EntryPoint) > 0 || len(c.Args) == 0 {
+		return nil
+	}
+	return c.Args
+}
+
+// GetUlimits gets the ulimits in the format which is expected by docker
+func (c Container) GetUlimits() []*units.Ulimit {
+	if len(c.Ulimits) == 0 {
+		return nil
+	}
+	out := []*units.Ulimit{}
+	for name, limit := range c.Ulimits {
+		out = append(out, &units.Ulimit{Name: name, Soft: limit.Soft, Hard: limit.Hard})
+	}
+	return out
+}
+
+// GetShmSize gets the size of /dev/shm in bytes, 0 leaves it to the default of docker
+func (c Container) GetShmSize() (int64, error) {
+	if len(c.ShmSize) == 0 {
+		return 0, nil
+	}
+	return utils.Memconv(c.ShmSize, utils.Mibi)
+}
+
+// GetRestartPolicy gets the restart policy in the format which is expected by docker
+func (c Container) GetRestartPolicy() (container.RestartPolicy, error) {
+	parts := strings.SplitN(c.RestartPolicy, ":", 2)
+	policy := container.RestartPolicy{Name: parts[0]}
+	switch policy.Name {
+	case "", "no", "always", "unless-stopped":
+		if len(parts) > 1 {
+			return policy, errors.New("only the on-failure restart policy takes a maximum number of retries")
+		}
+	case "on-failure":
+		if len(parts) > 1 {
+			retries, err := strconv.Atoi(parts[1])
+			if err != nil || retries < 0 {
+				return policy, fmt.Errorf(`invalid maximum number of retries "%s"`, parts[1])
+			}
+			policy.MaximumRetryCount = retries
+		}
+	default:
+		return policy, fmt.Errorf(`unknown restart policy "%s"`, policy.Name)
+	}
+	return policy, nil
+}
+
+// GetHealthcheck gets the healthcheck in the format which is expected by docker, nil keeps that of the image
+func (c Container) GetHealthcheck() *container.HealthConfig {
+	if c.Healthcheck == nil {
+		return nil
+	}
+	return &container.HealthConfig{
+		Test:        c.Healthcheck.Test,
+		Interval:    c.Healthcheck.Interval.Duration,
+		Timeout:     c.Healthcheck.Timeout.Duration,
+		StartPeriod: c.Healthcheck.StartPeriod.Duration,
+		Retries:     c.Healthcheck.Retries,
+	}
+}
@@ -6,9 +6,23 @@
 
 package entity
 
+// SidecarLabel is the label which marks a container as a sidecar created by Genesis,
+// its value is the kind of sidecar
+const SidecarLabel = "genesis.sidecar"
+
 // DockerCli is a wrapper around Client to provide extras such as labels
 type DockerCli struct {
 	Client
 	Labels map[string]string
 	TestID string
 }
+
+// SidecarLabels returns a copy of the labels, marked as belonging to a sidecar of the given kind
+func (cli DockerCli) SidecarLabels(kind string) map[string]string {
+	out := map[string]string{}
+	for key, value := range cli.Labels {
+		out[key] = value
+	}
+	out[SidecarLabel] = kind
+	return out
+}
@@ -0,0 +1,64 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package entity
+
+import (
+	"time"
+
+	"github.com/whiteblock/definition/command"
+)
+
+// EventType is the type of an event
+type EventType string
+
+const (
+	// CommandStartEvent is emitted when a command is about to be executed
+	CommandStartEvent = EventType("commandStart")
+	// ResultEvent is emitted when the execution of a step produces a result
+	ResultEvent = EventType("result")
+	// RetryEvent is emitted when a step is going to be retried
+	RetryEvent = EventType("retry")
+	// StepEvent is emitted when an execution moves on to its next step
+	StepEvent = EventType("step")
+	// FinishedEvent is emitted when an execution reaches a final state
+	FinishedEvent = EventType("finished")
+	// ScheduleEvent is emitted when an emulation schedule applies an entry, finishes or is cancelled
+	ScheduleEvent = EventType("schedule")
+	// ChaosEvent is emitted when a chaos policy injects or recovers from a fault, finishes or is cancelled
+	ChaosEvent = EventType("chaos")
+)
+
+// Event is a notification of something happening during the execution of a test
+type Event struct {
+	// Type is the type of the event
+	Type EventType `json:"type"`
+	// Execution is the id of the execution this event belongs to, if there is one
+	Execution string `json:"execution,omitempty"`
+	// Test is the id of the test this event belongs to
+	Test string `json:"test"`
+	// Step is the step of the execution which the event occurred in
+	Step int `json:"step"`
+	// Time is when the event occurred
+	Time time.Time `json:"time"`
+	// Command is the command this event relates to, if any
+	Command *command.Command `json:"command,omitempty"`
+	// Result is the result this event relates to, if any
+	Result *Result `json:"result,omitempty"`
+	// Data is any additional information about the event
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// NewEvent creates a new event of the given type, which occurred now
+func NewEvent(eventType EventType, test string) Event {
+	return Event{Type: eventType, Test: test, Time: time.Now()}
+}
+
+// Matches returns true if the event belongs to the given execution or test.
+// An empty id matches every event.
+func (ev Event) Matches(id string) bool {
+	return len(id) == 0 || ev.Execution == id || ev.Test == id
+}
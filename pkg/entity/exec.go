@@ -14,4 +14,16 @@ type Exec struct {
 	Privileged bool
 	Retries    int
 	Delay      time.Duration
+	Env        []string
+	User       string
+	WorkingDir string
+	// Timeout is the longest the command may run for, no limit when zero
+	Timeout time.Duration
+}
+
+// ExecOutput is what a command which was executed in a container produced
+type ExecOutput struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
 }
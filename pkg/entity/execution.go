@@ -0,0 +1,103 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package entity
+
+import (
+	"time"
+
+	"github.com/whiteblock/definition/command"
+)
+
+// ExecutionState is the state which an execution is currently in
+type ExecutionState string
+
+const (
+	// ExecutionRunning indicates that the execution has not yet finished
+	ExecutionRunning = ExecutionState("running")
+	// ExecutionFinished indicates that the execution completed successfully
+	ExecutionFinished = ExecutionState("finished")
+	// ExecutionFailed indicates that the execution stopped due to an error
+	ExecutionFailed = ExecutionState("failed")
+	// ExecutionCancelled indicates that the execution was stopped by request
+	ExecutionCancelled = ExecutionState("cancelled")
+)
+
+// Execution is the record of a set of instructions being run by Genesis
+type Execution struct {
+	// ID is the unique identifier of this execution
+	ID string `json:"id"`
+	// Instructions are the instructions which have yet to be completed
+	Instructions command.Instructions `json:"instructions"`
+	// Teardown are the instructions which clean up after the execution if it fails or is cancelled
+	Teardown command.Instructions `json:"teardown"`
+	// Step is the number of steps which have been completed so far
+	Step int `json:"step"`
+	// Retries is the number of times the current step has been retried
+	Retries int `json:"retries"`
+	// LastResult is the result of the last attempt at executing a step
+	LastResult *Result `json:"lastResult,omitempty"`
+	// State is the current state of the execution
+	State ExecutionState `json:"state"`
+	// Created is when this execution was first received
+	Created time.Time `json:"created"`
+	// Updated is when this execution was last updated
+	Updated time.Time `json:"updated"`
+}
+
+// NewExecution creates a new running execution for the given instructions
+func NewExecution(id string, inst command.Instructions) Execution {
+	now := time.Now()
+	return Execution{
+		ID:           id,
+		Instructions: inst,
+		State:        ExecutionRunning,
+		Created:      now,
+		Updated:      now,
+	}
+}
+
+// IsRunning returns true if the execution has not yet reached a final state
+func (exec Execution) IsRunning() bool {
+	return exec.State == ExecutionRunning
+}
+
+// ExecutionSummary is the externally visible information about an execution
+type ExecutionSummary struct {
+	ID             string         `json:"id"`
+	Test           string         `json:"test"`
+	State          ExecutionState `json:"state"`
+	Phase          string         `json:"phase,omitempty"`
+	Step           int            `json:"step"`
+	RemainingSteps int            `json:"remainingSteps"`
+	Retries        int            `json:"retries"`
+	LastResult     *Result        `json:"lastResult,omitempty"`
+	Created        time.Time      `json:"created"`
+	Updated        time.Time      `json:"updated"`
+	// Remaining are the steps which have yet to be executed, only given when requested
+	Remaining [][]command.Command `json:"remaining,omitempty"`
+}
+
+// Summary gets the summary of this execution
+func (exec Execution) Summary() ExecutionSummary {
+	phase, _ := exec.Instructions.Phase()
+	remaining := len(exec.Instructions.Commands)
+	if !exec.IsRunning() {
+		remaining = 0
+	}
+	return ExecutionSummary{
+		ID:             exec.ID,
+		Test:           exec.Instructions.ID,
+		State:          exec.State,
+		Phase:          phase,
+		Step:           exec.Step,
+		RemainingSteps: remaining,
+		Retries:        exec.Retries,
+		LastResult:     exec.LastResult,
+		Created:        exec.Created,
+		Updated:        exec.Updated,
+	}
+}
@@ -0,0 +1,125 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package entity
+
+import (
+	"net"
+
+	"github.com/docker/docker/api/types/network"
+	"github.com/whiteblock/definition/command"
+)
+
+// The drivers which a network can be created with
+const (
+	// BridgeDriver is a network on a bridge of the host
+	BridgeDriver = "bridge"
+	// OverlayDriver is a network which spans the hosts of the swarm
+	OverlayDriver = "overlay"
+	// MacvlanDriver gives each container its own MAC address on an interface of the host
+	MacvlanDriver = "macvlan"
+	// IpvlanDriver gives each container its own IP address on an interface of the host
+	IpvlanDriver = "ipvlan"
+	// NoneDriver means that no network is created
+	NoneDriver = "none"
+)
+
+// Subnet is an address pool of a network
+type Subnet struct {
+	// Subnet is the CIDR of the pool, such as 10.0.0.0/24 or fd00:1::/64
+	Subnet string `json:"subnet"`
+	// Gateway is the address of the gateway in the pool, one is assigned when it is empty
+	Gateway string `json:"gateway,omitempty"`
+	// IPRange is the part of the subnet, as a CIDR, which the addresses of the containers are assigned from
+	IPRange string `json:"ipRange,omitempty"`
+}
+
+// IsIPv6 checks whether the subnet is an IPv6 pool
+func (s Subnet) IsIPv6() bool {
+	ip, _, err := net.ParseCIDR(s.Subnet)
+	return err == nil && ip.To4() == nil
+}
+
+// Network is the payload of a create network order. It extends the network of the definition
+// with IPv6 and more address pools, so that the network can be dual-stack, and with the choice
+// of driver and its options.
+type Network struct {
+	command.Network
+	// EnableIPv6 gives the network IPv6, which it also has when any of its subnets are IPv6
+	EnableIPv6 bool `json:"enableIPv6,omitempty"`
+	// Subnets are the address pools of the network, along with the subnet of the definition
+	Subnets []Subnet `json:"subnets,omitempty"`
+	// IPRange is the part of the subnet of the definition which the addresses of the containers
+	// are assigned from
+	IPRange string `json:"ipRange,omitempty"`
+	// Driver is the driver of the network, which is bridge, overlay, macvlan, ipvlan or none. By
+	// default, it is overlay for a global network when there is a swarm, and bridge otherwise.
+	Driver string `json:"driver,omitempty"`
+	// MTU is the MTU of the interfaces on the network, 0 leaves it to the default of the driver
+	MTU int `json:"mtu,omitempty"`
+	// Internal cuts the network off from the outside, so the containers can only reach each other
+	Internal bool `json:"internal,omitempty"`
+	// Encrypted encrypts the traffic of an overlay network between the hosts
+	Encrypted bool `json:"encrypted,omitempty"`
+	// Options are the options of the driver, such as the parent interface of a macvlan network
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// GetDriver gets the driver of the network, swarm is whether there is a swarm to put a global
+// network on
+func (n Network) GetDriver(swarm bool) string {
+	if len(n.Driver) > 0 {
+		return n.Driver
+	}
+	if swarm && n.Global {
+		return OverlayDriver
+	}
+	return BridgeDriver
+}
+
+// GetSubnets gets all of the address pools of the network, the first of which is the subnet of
+// the definition when it has one
+func (n Network) GetSubnets() []Subnet {
+	out := []Subnet{}
+	if len(n.Subnet) > 0 || len(n.Gateway) > 0 || len(n.IPRange) > 0 || len(n.Subnets) == 0 {
+		out = append(out, Subnet{Subnet: n.Subnet, Gateway: n.Gateway, IPRange: n.IPRange})
+	}
+	return append(out, n.Subnets...)
+}
+
+// IsIPv6 checks whether the network has IPv6
+func (n Network) IsIPv6() bool {
+	if n.EnableIPv6 {
+		return true
+	}
+	for _, subnet := range n.GetSubnets() {
+		if subnet.IsIPv6() {
+			return true
+		}
+	}
+	return false
+}
+
+// GetIPAMConfig gets the address pools in the format which is expected by docker
+func (n Network) GetIPAMConfig() []network.IPAMConfig {
+	out := []network.IPAMConfig{}
+	for _, subnet := range n.GetSubnets() {
+		out = append(out, network.IPAMConfig{
+			Subnet:  subnet.Subnet,
+			Gateway: subnet.Gateway,
+			IPRange: subnet.IPRange,
+		})
+	}
+	return out
+}
+
+// ContainerNetwork is the payload of an attach network order. It extends that of the definition
+// with an IPv6 address.
+type ContainerNetwork struct {
+	command.ContainerNetwork
+	// IPv6 is the static IPv6 address of the container on the network
+	IPv6 string `json:"ipv6,omitempty"`
+}
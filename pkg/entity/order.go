@@ -0,0 +1,447 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package entity
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/whiteblock/genesis/pkg/netem"
+
+	"github.com/whiteblock/definition/command"
+	"github.com/whiteblock/utility/utils"
+)
+
+// The order types which are handled by Genesis in addition to those in the definition
+const (
+	// TeardownOrder removes everything which was created for a test
+	TeardownOrder = command.OrderType("teardown")
+	// DestroyTestnetOrder is an alias of TeardownOrder
+	DestroyTestnetOrder = command.OrderType("destroytestnet")
+	// CollectLogsOrder archives the logs of the containers of a test
+	CollectLogsOrder = command.OrderType("collectlogs")
+	// ExecInContainerOrder runs a command in a container and captures what it outputs
+	ExecInContainerOrder = command.OrderType("execincontainer")
+	// WaitForReadyOrder waits for a container to become ready
+	WaitForReadyOrder = command.OrderType("waitforready")
+	// UpdateEmulationOrder changes the network emulation of a container
+	UpdateEmulationOrder = command.OrderType("updateemulation")
+	// ClearEmulationOrder removes the network emulation of a container
+	ClearEmulationOrder = command.OrderType("clearemulation")
+	// LinkEmulationOrder applies network emulation to the traffic between pairs of containers
+	LinkEmulationOrder = command.OrderType("linkemulation")
+	// PartitionNetworkOrder stops groups of containers from reaching each other
+	PartitionNetworkOrder = command.OrderType("partitionnetwork")
+	// HealNetworkOrder removes partitions
+	HealNetworkOrder = command.OrderType("healnetwork")
+	// EmulationScheduleOrder changes the network emulation of a container over time
+	EmulationScheduleOrder = command.OrderType("emulationschedule")
+	// GetEmulationOrder reports the network emulation which is applied to a container
+	GetEmulationOrder = command.OrderType("getemulation")
+	// StopContainerOrder stops a container, killing it if it does not stop in time
+	StopContainerOrder = command.OrderType("stopcontainer")
+	// RestartContainerOrder stops a container and then starts it again
+	RestartContainerOrder = command.OrderType("restartcontainer")
+	// KillContainerOrder sends a signal to the main process of a container
+	KillContainerOrder = command.OrderType("killcontainer")
+	// PauseContainerOrder freezes the processes of a container
+	PauseContainerOrder = command.OrderType("pausecontainer")
+	// UnpauseContainerOrder resumes the processes of a paused container
+	UnpauseContainerOrder = command.OrderType("unpausecontainer")
+	// UpdateContainerResourcesOrder changes the resource limits of a running container
+	UpdateContainerResourcesOrder = command.OrderType("updatecontainerresources")
+	// ChaosOrder injects faults into random containers of a test, until the test is torn down
+	ChaosOrder = command.OrderType("chaos")
+	// CollectStatsOrder samples the resource usage of the containers of a test, until the test is torn down
+	CollectStatsOrder = command.OrderType("collectstats")
+	// CopyFromContainerOrder copies a file or directory out of containers and stores it as artifacts
+	CopyFromContainerOrder = command.OrderType("copyfromcontainer")
+)
+
+// Teardown is the payload of a teardown order
+type Teardown struct {
+	// TestID is the test to tear down, defaults to the test of the command
+	TestID string `json:"testID,omitempty"`
+	// Hosts are the docker hosts to tear the test down on, defaults to the target of the command
+	Hosts []string `json:"hosts,omitempty"`
+}
+
+// CollectLogs is the payload of a collect logs order
+type CollectLogs struct {
+	// Containers are the containers to collect the logs of, defaults to all of the
+	// containers of the test
+	Containers []string `json:"containers,omitempty"`
+	// Since only collects the logs after this point, either a timestamp or a duration
+	// relative to now, such as "10m"
+	Since string `json:"since,omitempty"`
+	// Tail only collects this many lines from the end of the logs of each container
+	Tail string `json:"tail,omitempty"`
+	// Name is the name of the archive, defaults to logs-<time>.tar.gz
+	Name string `json:"name,omitempty"`
+}
+
+// CopyFromContainer is the payload of a copy from container order
+type CopyFromContainer struct {
+	// Containers are the containers to copy the path out of, defaults to all of the
+	// containers of the test
+	Containers []string `json:"containers,omitempty"`
+	// Path is the absolute path of the file or directory to copy
+	Path string `json:"path"`
+	// Name is the prefix of the archives, each of which is named <name>-<container>.tar,
+	// defaults to copy-<time>
+	Name string `json:"name,omitempty"`
+}
+
+// ExecInContainer is the payload of an exec in container order
+type ExecInContainer struct {
+	// Container is the name of the container to run the command in
+	Container string `json:"container"`
+	// Cmd is the command to run, along with its arguments
+	Cmd []string `json:"cmd"`
+	// Env are additional environment variables for the command
+	Env map[string]string `json:"env,omitempty"`
+	// User is the user to run the command as, defaults to the user of the container
+	User string `json:"user,omitempty"`
+	// WorkingDir is the directory to run the command in, defaults to that of the container
+	WorkingDir string `json:"workdir,omitempty"`
+	// Privileged gives the command extended privileges
+	Privileged bool `json:"privileged,omitempty"`
+	// ExpectedExitCode is the exit code the command must exit with for the order to succeed
+	ExpectedExitCode int `json:"expectedExitCode,omitempty"`
+	// IgnoreExitCode makes the order succeed regardless of the exit code of the command
+	IgnoreExitCode bool `json:"ignoreExitCode,omitempty"`
+}
+
+// StopContainer is the payload of a stop container order
+type StopContainer struct {
+	// Name is the name of the container
+	Name string `json:"name"`
+	// Timeout is how long to wait for the container to stop before killing it, defaults to
+	// that of docker
+	Timeout command.Duration `json:"timeout,omitempty"`
+}
+
+// RestartContainer is the payload of a restart container order
+type RestartContainer struct {
+	// Name is the name of the container
+	Name string `json:"name"`
+	// Timeout is how long to wait for the container to stop before killing it, defaults to
+	// that of docker
+	Timeout command.Duration `json:"timeout,omitempty"`
+}
+
+// KillContainer is the payload of a kill container order
+type KillContainer struct {
+	// Name is the name of the container
+	Name string `json:"name"`
+	// Signal is the signal to send, such as SIGTERM or 9, defaults to SIGKILL
+	Signal string `json:"signal,omitempty"`
+}
+
+// UpdateContainerResources is the payload of an update container resources order. Only the limits
+// which are given are changed.
+type UpdateContainerResources struct {
+	// Name is the name of the container
+	Name string `json:"name"`
+	// Cpus is the CPU quota, in CPUs such as "0.5"
+	Cpus string `json:"cpus,omitempty"`
+	// BoundCPUs are the CPUs which the container may run on
+	BoundCPUs []int `json:"boundCPUs,omitempty"`
+	// Memory is the memory limit, such as "512MB", in MiB when there is no unit
+	Memory string `json:"memory,omitempty"`
+	// MemorySwap is the limit of the memory and swap together, in the same format as Memory,
+	// or -1 for unlimited swap
+	MemorySwap string `json:"memorySwap,omitempty"`
+	// PidsLimit is the maximum number of processes, or -1 for unlimited
+	PidsLimit *int64 `json:"pidsLimit,omitempty"`
+}
+
+// GetNanoCPUs gets the CPU quota in billionths of a CPU, 0 when it is not changed
+func (ucr UpdateContainerResources) GetNanoCPUs() (int64, error) {
+	if len(ucr.Cpus) == 0 {
+		return 0, nil
+	}
+	cpus, err := strconv.ParseFloat(ucr.Cpus, 64)
+	if err != nil {
+		return 0, err
+	}
+	if cpus <= 0 || math.IsInf(cpus, 0) || math.IsNaN(cpus) {
+		return 0, fmt.Errorf("the cpus must be a positive number")
+	}
+	return int64(1000000000 * cpus), nil
+}
+
+// GetCPUSet gets the CPUs which the container may run on, in the format which is expected by docker
+func (ucr UpdateContainerResources) GetCPUSet() string {
+	cpus := make([]int, len(ucr.BoundCPUs))
+	copy(cpus, ucr.BoundCPUs)
+	sort.Ints(cpus)
+	out := make([]string, len(cpus))
+	for i, cpu := range cpus {
+		out[i] = strconv.Itoa(cpu)
+	}
+	return strings.Join(out, ",")
+}
+
+// GetMemory gets the memory limit in bytes, 0 when it is not changed
+func (ucr UpdateContainerResources) GetMemory() (int64, error) {
+	if len(ucr.Memory) == 0 {
+		return 0, nil
+	}
+	return utils.Memconv(ucr.Memory, utils.Mibi)
+}
+
+// GetMemorySwap gets the limit of the memory and swap together in bytes, 0 when it is not
+// changed and -1 when it is unlimited
+func (ucr UpdateContainerResources) GetMemorySwap() (int64, error) {
+	if len(ucr.MemorySwap) == 0 {
+		return 0, nil
+	}
+	if strings.TrimSpace(ucr.MemorySwap) == "-1" {
+		return -1, nil
+	}
+	return utils.Memconv(ucr.MemorySwap, utils.Mibi)
+}
+
+// The ways of checking whether a container is ready
+const (
+	// ReadyCheckHealth waits for the docker HEALTHCHECK of the container to report healthy
+	ReadyCheckHealth = "health"
+	// ReadyCheckTCP waits for a TCP port of the container to accept connections
+	ReadyCheckTCP = "tcp"
+	// ReadyCheckHTTP waits for a HTTP GET on a port of the container to return a 2xx status
+	ReadyCheckHTTP = "http"
+	// ReadyCheckLog waits for the logs of the container to match a regular expression
+	ReadyCheckLog = "log"
+)
+
+// WaitForReady is the payload of a wait for ready order
+type WaitForReady struct {
+	// Container is the name of the container to wait on
+	Container string `json:"container"`
+	// Check is how to tell that the container is ready, one of health, tcp, http or log
+	Check string `json:"check"`
+	// Host is the address to connect to for the tcp and http checks, from the network namespace
+	// of the container, defaults to the IP address of the container
+	Host string `json:"host,omitempty"`
+	// Port is the port to connect to for the tcp and http checks
+	Port int `json:"port,omitempty"`
+	// Path is the path to request for the http check
+	Path string `json:"path,omitempty"`
+	// Pattern is the regular expression to look for in the logs for the log check
+	Pattern string `json:"pattern,omitempty"`
+	// Timeout is how long to wait for the container to become ready
+	Timeout command.Duration `json:"timeout,omitempty"`
+	// Interval is how long to wait between checks
+	Interval command.Duration `json:"interval,omitempty"`
+}
+
+// Emulation is the payload of an emulation order. It accepts everything that command.Netconf does,
+// along with the rest of the parameters of netem.
+type Emulation struct {
+	// Container is the target container
+	Container string `json:"container"`
+	// Network is the target network
+	Network string `json:"network"`
+	netem.Netem
+	// Ingress is the emulation of the traffic which the container receives
+	Ingress *netem.Ingress `json:"ingress,omitempty"`
+}
+
+// Validate checks the emulation of the traffic in both directions
+func (emu Emulation) Validate() error {
+	err := emu.Netem.Validate()
+	if err != nil {
+		return err
+	}
+	if emu.Ingress != nil {
+		err = emu.Ingress.Validate()
+		if err != nil {
+			return fmt.Errorf("ingress: %v", err)
+		}
+	}
+	return nil
+}
+
+// CheckNetconf checks the emulation like Validate does, except that the problems with the parameters
+// shared with command.Netconf are only warnings, since emulation orders with them have always been run
+func (emu Emulation) CheckNetconf() (warnings []string, err error) {
+	warnings, err = emu.Netem.CheckNetconf()
+	if err != nil {
+		return
+	}
+	if emu.Ingress != nil {
+		err = emu.Ingress.Validate()
+		if err != nil {
+			err = fmt.Errorf("ingress: %v", err)
+		}
+	}
+	return
+}
+
+// HasEgress returns true if the traffic which the container sends is to be emulated, which is
+// the case unless only the ingress is given
+func (emu Emulation) HasEgress() bool {
+	return emu.Ingress == nil || emu.Netem != (netem.Netem{})
+}
+
+// ClearEmulation is the payload of a clear emulation order
+type ClearEmulation struct {
+	// Container is the target container
+	Container string `json:"container"`
+	// Network is the target network
+	Network string `json:"network"`
+}
+
+// GetEmulation is the payload of a get emulation order
+type GetEmulation struct {
+	// Container is the target container
+	Container string `json:"container"`
+	// Network is the target network
+	Network string `json:"network"`
+}
+
+// Link is the emulation of the traffic which goes from one container to another
+type Link struct {
+	// Source is the container which sends the traffic
+	Source string `json:"source"`
+	// Destination is the container which receives the traffic
+	Destination string `json:"destination"`
+	// Bidirectional applies the same emulation to the traffic going the other way
+	Bidirectional bool `json:"bidirectional,omitempty"`
+	netem.Netem
+}
+
+// LinkEmulation is the payload of a link emulation order. The traffic of each source is
+// only shaped for the destinations it has links to, the rest of it is left alone.
+type LinkEmulation struct {
+	// Network is the network which the containers communicate over
+	Network string `json:"network"`
+	// Links are the emulations of the traffic between the pairs of containers
+	Links []Link `json:"links"`
+}
+
+// PartitionNetwork is the payload of a partition network order
+type PartitionNetwork struct {
+	// Name identifies the partition, so that it can be healed on its own. Partitioning again with
+	// the same name replaces the partition.
+	Name string `json:"name,omitempty"`
+	// Network is the network which the containers communicate over
+	Network string `json:"network"`
+	// Groups are the containers on each side of the partition
+	Groups [][]string `json:"groups"`
+	// OneWay only drops the traffic from each group to the groups after it
+	OneWay bool `json:"oneWay,omitempty"`
+}
+
+// HealNetwork is the payload of a heal network order
+type HealNetwork struct {
+	// Name is the partition to heal, every partition is healed when it is empty
+	Name string `json:"name,omitempty"`
+	// Containers are the containers to heal, defaults to all of the containers of the test
+	Containers []string `json:"containers,omitempty"`
+}
+
+// ScheduleEntry is the emulation which a schedule switches to once it has been running for Offset
+type ScheduleEntry struct {
+	// Offset is how long after the start of the schedule the emulation is applied
+	Offset command.Duration `json:"offset"`
+	netem.Netem
+}
+
+// EmulationSchedule is the payload of an emulation schedule order. The entries are either given
+// directly, or come from a trace file.
+type EmulationSchedule struct {
+	// Name identifies the schedule, starting another schedule with the same name replaces it.
+	// Defaults to the container and network.
+	Name string `json:"name,omitempty"`
+	// Container is the target container
+	Container string `json:"container"`
+	// Network is the target network
+	Network string `json:"network"`
+	// Entries are the emulations to apply, and when to apply them
+	Entries []ScheduleEntry `json:"entries,omitempty"`
+	// TraceFile is the id of a file of the definition which holds the entries as CSV, with a
+	// header naming the columns, such as "offset,delay,loss,rate"
+	TraceFile string `json:"traceFile,omitempty"`
+}
+
+// ScheduleName gets the name of the schedule, or the default one if it has none
+func (es EmulationSchedule) ScheduleName() string {
+	if len(es.Name) > 0 {
+		return es.Name
+	}
+	return es.Container + "/" + es.Network
+}
+
+// The faults which a chaos policy can inject
+const (
+	// KillFault kills the containers, and starts them again once the fault is over
+	KillFault = "kill"
+	// PauseFault pauses the containers, and unpauses them once the fault is over
+	PauseFault = "pause"
+	// RestartFault restarts the containers
+	RestartFault = "restart"
+	// PartitionFault cuts the containers off from the rest of the network, until the fault is over
+	PartitionFault = "partition"
+)
+
+// Chaos is the payload of a chaos order. Every interval, one of the faults is chosen at random
+// and injected into a random fraction of the running containers which match the selector.
+type Chaos struct {
+	// Name identifies the policy, starting another policy with the same name replaces it.
+	// Defaults to chaos.
+	Name string `json:"name,omitempty"`
+	// Selector are the labels which a container must have to be chosen, every container of
+	// the test may be chosen when it is empty
+	Selector map[string]string `json:"selector,omitempty"`
+	// Faults are the faults to choose from
+	Faults []string `json:"faults"`
+	// Fraction is the fraction of the matching containers to inject each fault into, at least
+	// one container is always chosen
+	Fraction float64 `json:"fraction"`
+	// Interval is how often a fault is injected
+	Interval command.Duration `json:"interval"`
+	// Duration is how long a fault lasts before it is recovered from, defaults to half of the interval
+	Duration command.Duration `json:"duration,omitempty"`
+	// Network is the network to partition, which is required for partition faults
+	Network string `json:"network,omitempty"`
+	// Seed seeds the choices, so that a run can be reproduced. A random seed is used when it is zero.
+	Seed int64 `json:"seed,omitempty"`
+	// Rounds is how many faults to inject, the policy runs until the test is torn down when it is zero
+	Rounds int `json:"rounds,omitempty"`
+}
+
+// PolicyName gets the name of the policy, or the default one if it has none
+func (c Chaos) PolicyName() string {
+	if len(c.Name) > 0 {
+		return c.Name
+	}
+	return "chaos"
+}
+
+// FaultDuration gets how long each fault lasts
+func (c Chaos) FaultDuration() time.Duration {
+	if c.Duration.Duration > 0 {
+		return c.Duration.Duration
+	}
+	return c.Interval.Duration / 2
+}
+
+// CollectStats is the payload of a collect stats order. The resource usage of each of the running
+// containers of the test which match the selector is sampled at the interval.
+type CollectStats struct {
+	// Interval is how often the containers are sampled, defaults to the interval of the config
+	Interval command.Duration `json:"interval,omitempty"`
+	// Selector are the labels which a container must have to be sampled, every container of the
+	// test is sampled when it is empty
+	Selector map[string]string `json:"selector,omitempty"`
+}
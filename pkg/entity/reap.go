@@ -0,0 +1,45 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package entity
+
+import (
+	"time"
+)
+
+// The reasons for the resources of a test being reaped
+const (
+	// ReapExpired means the resources are older than the maximum allowed age
+	ReapExpired = "expired"
+	// ReapUntracked means the test is not known to be running, such as after a restart
+	ReapUntracked = "untracked"
+	// ReapFinished means the test has ended, but its resources were never removed
+	ReapFinished = "finished"
+)
+
+// ReapCandidate is the set of resources a test has left behind on a docker host
+type ReapCandidate struct {
+	Host       string    `json:"host"`
+	Test       string    `json:"test"`
+	Reason     string    `json:"reason"`
+	Created    time.Time `json:"created"`
+	Containers []string  `json:"containers,omitempty"`
+	Networks   []string  `json:"networks,omitempty"`
+	Volumes    []string  `json:"volumes,omitempty"`
+	// Removed is true once all of the resources have been removed
+	Removed bool `json:"removed"`
+	// Failed are the resources which could not be removed, and why
+	Failed map[string]string `json:"failed,omitempty"`
+}
+
+// ReapReport is the outcome of looking for and removing orphaned resources
+type ReapReport struct {
+	DryRun     bool            `json:"dryRun"`
+	Time       time.Time       `json:"time"`
+	Candidates []ReapCandidate `json:"candidates"`
+	// Errors are the hosts which could not be scanned, and why
+	Errors map[string]string `json:"errors,omitempty"`
+}
@@ -8,12 +8,12 @@ package entity
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"runtime"
 	"time"
 
 	"github.com/getlantern/deepcopy"
-	"github.com/imdario/mergo"
 )
 
 // ResultType is the type of the result
@@ -103,10 +103,18 @@ func (res Result) IsDelayed() bool {
 	return res.Type == DelayType && res.Delay > 0
 }
 
-// InjectMeta allows for chaining on New...Result for the return statement
+// InjectMeta allows for chaining on New...Result for the return statement. Keys which are
+// already in the meta are kept, while zero values such as false or 0 are added like any other.
 func (res Result) InjectMeta(meta map[string]interface{}) (out Result) {
 	res.CopyTo(&out)
-	mergo.Map(&out.Meta, meta)
+	if out.Meta == nil {
+		out.Meta = map[string]interface{}{}
+	}
+	for key, value := range meta {
+		if _, exists := out.Meta[key]; !exists {
+			out.Meta[key] = value
+		}
+	}
 	return out
 }
 
@@ -147,6 +155,44 @@ func (res Result) MarshalJSON() ([]byte, error) {
 	return json.Marshal(jRes)
 }
 
+// UnmarshalJSON is the inverse of MarshalJSON, allowing a stored result to be recovered.
+// The error, if there is one, is recovered as a plain error with the original message
+func (res *Result) UnmarshalJSON(data []byte) error {
+	var jRes struct {
+		Type   string                 `json:"type"`
+		Meta   map[string]interface{} `json:"meta"`
+		Caller string                 `json:"caller"`
+		Error  *string                `json:"error"`
+	}
+	err := json.Unmarshal(data, &jRes)
+	if err != nil {
+		return err
+	}
+	*res = Result{Meta: jRes.Meta, Caller: jRes.Caller}
+	switch jRes.Type {
+	case "Success":
+		res.Type = SuccessType
+	case "AllDone":
+		res.Type = AllDoneType
+	case "TooSoon":
+		res.Type = TooSoonType
+	case "Fatal":
+		res.Type = FatalType
+	case "Error":
+		res.Type = ErrorType
+	case "Requeue":
+		res.Type = RequeueType
+	case "Trap":
+		res.Type = TrapType
+	case "Delay":
+		res.Type = DelayType
+	}
+	if jRes.Error != nil {
+		res.Error = errors.New(*jRes.Error)
+	}
+	return nil
+}
+
 const (
 	//SuccessType is the type of a successful result
 	SuccessType ResultType = iota + 1
@@ -7,11 +7,13 @@
 package entity
 
 import (
+	"encoding/json"
 	"errors"
 	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestResult_IsSuccess(t *testing.T) {
@@ -161,3 +163,41 @@ func TestNewErrorResult(t *testing.T) {
 func TestNewAllDoneResult(t *testing.T) {
 	assert.True(t, NewAllDoneResult().IsAllDone())
 }
+
+func TestResult_UnmarshalJSON(t *testing.T) {
+	var tests = []Result{
+		NewSuccessResult(),
+		NewFatalResult("fatal test"),
+		NewErrorResult("error test").InjectMeta(map[string]interface{}{"foo": "bar"}),
+		NewAllDoneResult(),
+	}
+
+	for i, tt := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			data, err := json.Marshal(tt)
+			require.NoError(t, err)
+
+			var res Result
+			require.NoError(t, json.Unmarshal(data, &res))
+			assert.Equal(t, tt.Type, res.Type)
+			assert.Equal(t, tt.Caller, res.Caller)
+			assert.Equal(t, tt.Meta, res.Meta)
+			if tt.Error == nil {
+				assert.NoError(t, res.Error)
+			} else {
+				assert.EqualError(t, res.Error, tt.Error.Error())
+			}
+		})
+	}
+}
+
+func TestResult_InjectMeta(t *testing.T) {
+	res := NewSuccessResult().InjectMeta(map[string]interface{}{"exitCode": 0, "cleared": false})
+	assert.Equal(t, map[string]interface{}{"exitCode": 0, "cleared": false}, res.Meta)
+
+	res = res.InjectMeta(map[string]interface{}{"exitCode": 1, "foo": "bar"})
+	assert.EqualValues(t, 0, res.Meta["exitCode"])
+	assert.Equal(t, "bar", res.Meta["foo"])
+
+	assert.Equal(t, "bar", Result{}.InjectMeta(map[string]interface{}{"foo": "bar"}).Meta["foo"])
+}
@@ -0,0 +1,115 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package entity
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+// StatsCSVHeader names the columns of the CSV records of the stats samples
+var StatsCSVHeader = []string{
+	"time", "container", "cpuPercent", "memoryUsage", "memoryLimit", "memoryPercent",
+	"blockRead", "blockWrite", "networkRx", "networkTx", "pids",
+}
+
+// StatsSample is the resource usage of a container at a point in time
+type StatsSample struct {
+	// Time is when the sample was taken
+	Time time.Time `json:"time"`
+	// Container is the name of the container
+	Container string `json:"container"`
+	// CPUPercent is the share of the CPUs of the host which were used since the previous
+	// sample, where 100 is all of a single CPU
+	CPUPercent float64 `json:"cpuPercent"`
+	// MemoryUsage is the memory used in bytes, without the page cache
+	MemoryUsage uint64 `json:"memoryUsage"`
+	// MemoryLimit is the memory which the container may use in bytes
+	MemoryLimit uint64 `json:"memoryLimit"`
+	// MemoryPercent is the memory used as a percentage of the limit
+	MemoryPercent float64 `json:"memoryPercent"`
+	// BlockRead is the total bytes read from block devices
+	BlockRead uint64 `json:"blockRead"`
+	// BlockWrite is the total bytes written to block devices
+	BlockWrite uint64 `json:"blockWrite"`
+	// NetworkRx is the total bytes received on all of the interfaces
+	NetworkRx uint64 `json:"networkRx"`
+	// NetworkTx is the total bytes sent on all of the interfaces
+	NetworkTx uint64 `json:"networkTx"`
+	// Pids is the number of processes and threads
+	Pids uint64 `json:"pids"`
+}
+
+// NewStatsSample creates a sample from the stats which docker reports for a container, in the
+// same way as docker stats
+func NewStatsSample(container string, stats types.StatsJSON) StatsSample {
+	out := StatsSample{
+		Time:        stats.Read,
+		Container:   container,
+		MemoryLimit: stats.MemoryStats.Limit,
+		Pids:        stats.PidsStats.Current,
+	}
+
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	cpus := float64(stats.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		out.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+
+	// the page cache can be reclaimed, so it does not count as being used. It is reported as
+	// total_inactive_file by cgroup v1 and inactive_file by cgroup v2.
+	out.MemoryUsage = stats.MemoryStats.Usage
+	for _, key := range []string{"total_inactive_file", "inactive_file"} {
+		if cache, ok := stats.MemoryStats.Stats[key]; ok {
+			if cache < out.MemoryUsage {
+				out.MemoryUsage -= cache
+			}
+			break
+		}
+	}
+	if out.MemoryLimit > 0 {
+		out.MemoryPercent = float64(out.MemoryUsage) / float64(out.MemoryLimit) * 100
+	}
+
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			out.BlockRead += entry.Value
+		case "write":
+			out.BlockWrite += entry.Value
+		}
+	}
+	for _, iface := range stats.Networks {
+		out.NetworkRx += iface.RxBytes
+		out.NetworkTx += iface.TxBytes
+	}
+	return out
+}
+
+// CSVRecord gets the sample as a CSV record, with the columns of StatsCSVHeader
+func (s StatsSample) CSVRecord() []string {
+	return []string{
+		s.Time.UTC().Format(time.RFC3339Nano),
+		s.Container,
+		strconv.FormatFloat(s.CPUPercent, 'f', 3, 64),
+		strconv.FormatUint(s.MemoryUsage, 10),
+		strconv.FormatUint(s.MemoryLimit, 10),
+		strconv.FormatFloat(s.MemoryPercent, 'f', 3, 64),
+		strconv.FormatUint(s.BlockRead, 10),
+		strconv.FormatUint(s.BlockWrite, 10),
+		strconv.FormatUint(s.NetworkRx, 10),
+		strconv.FormatUint(s.NetworkTx, 10),
+		strconv.FormatUint(s.Pids, 10),
+	}
+}
@@ -0,0 +1,59 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStatsSample(t *testing.T) {
+	var stats types.StatsJSON
+	stats.Read = time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	stats.CPUStats.CPUUsage.TotalUsage = 200
+	stats.CPUStats.SystemUsage = 2000
+	stats.CPUStats.OnlineCPUs = 2
+	stats.PreCPUStats.CPUUsage.TotalUsage = 100
+	stats.PreCPUStats.SystemUsage = 1000
+	stats.MemoryStats.Usage = 1000
+	stats.MemoryStats.Limit = 1600
+	stats.MemoryStats.Stats = map[string]uint64{"inactive_file": 200}
+	stats.BlkioStats.IoServiceBytesRecursive = []types.BlkioStatEntry{
+		{Op: "Read", Value: 10}, {Op: "Write", Value: 20}, {Op: "read", Value: 1}, {Op: "Total", Value: 31},
+	}
+	stats.Networks = map[string]types.NetworkStats{
+		"eth0": {RxBytes: 5, TxBytes: 6},
+		"eth1": {RxBytes: 1, TxBytes: 1},
+	}
+	stats.PidsStats.Current = 3
+
+	sample := NewStatsSample("node0", stats)
+	assert.Equal(t, StatsSample{
+		Time:          stats.Read,
+		Container:     "node0",
+		CPUPercent:    20,
+		MemoryUsage:   800,
+		MemoryLimit:   1600,
+		MemoryPercent: 50,
+		BlockRead:     11,
+		BlockWrite:    20,
+		NetworkRx:     6,
+		NetworkTx:     7,
+		Pids:          3,
+	}, sample)
+	assert.Equal(t, []string{"2019-10-01T12:00:00Z", "node0", "20.000", "800", "1600", "50.000",
+		"11", "20", "6", "7", "3"}, sample.CSVRecord())
+	assert.Len(t, sample.CSVRecord(), len(StatsCSVHeader))
+
+	// the first sample of a stream has no previous CPU usage to compare against
+	stats.PreCPUStats = types.CPUStats{}
+	stats.CPUStats.SystemUsage = 0
+	assert.Zero(t, NewStatsSample("node0", stats).CPUPercent)
+}
@@ -0,0 +1,161 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidArtifactName is returned when the name of an artifact would escape its directory
+var ErrInvalidArtifactName = errors.New("invalid artifact name")
+
+func (rf remoteSources) artifactPath(testID, name string) (string, error) {
+	for _, part := range []string{testID, name} {
+		if len(part) == 0 || part == "." || part == ".." || strings.ContainsAny(part, "/\\") {
+			return "", ErrInvalidArtifactName
+		}
+	}
+	return filepath.Join(rf.conf.FileHandler.ArtifactsDir, testID, name), nil
+}
+
+func (rf remoteSources) artifactURL(testID, name string) string {
+	out := fmt.Sprintf("%s/api/v1/files/tests/%s", rf.conf.FileHandler.APIEndpoint, url.PathEscape(testID))
+	if len(name) > 0 {
+		out += "/" + url.PathEscape(name)
+	}
+	return out
+}
+
+func (rf remoteSources) checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	res, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	return fmt.Errorf("file api returned %d: %s", resp.StatusCode, string(res))
+}
+
+// PutArtifact stores a file produced by a test, in the artifacts directory when in local
+// mode and with the file api otherwise
+func (rf remoteSources) PutArtifact(testID, name string, rdr io.Reader) (string, error) {
+	if rf.conf.LocalMode {
+		path, err := rf.artifactPath(testID, name)
+		if err != nil {
+			return "", err
+		}
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return "", err
+		}
+		tmp := path + ".tmp"
+		f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return "", err
+		}
+		n, err := io.Copy(f, rdr)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(tmp)
+			return "", err
+		}
+		rf.log.WithFields(logrus.Fields{"path": path, "bytes": n}).Info("stored an artifact")
+		return path, os.Rename(tmp, path)
+	}
+
+	ctx, cancel := rf.getContext()
+	defer cancel()
+	dest := rf.artifactURL(testID, name)
+	req, err := http.NewRequestWithContext(ctx, "PUT", dest, rdr)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := rf.getClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	err = rf.checkResponse(resp)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	rf.log.WithField("url", dest).Info("uploaded an artifact")
+	return dest, nil
+}
+
+// GetArtifact opens a file which was produced by a test
+func (rf remoteSources) GetArtifact(testID, name string) (io.ReadCloser, error) {
+	if rf.conf.LocalMode {
+		path, err := rf.artifactPath(testID, name)
+		if err != nil {
+			return nil, err
+		}
+		return os.Open(path)
+	}
+	resp, err := rf.getClient().Get(rf.artifactURL(testID, name))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, os.ErrNotExist
+	}
+	err = rf.checkResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ListArtifacts lists the names of the files which were produced by a test
+func (rf remoteSources) ListArtifacts(testID string) ([]string, error) {
+	out := []string{}
+	if rf.conf.LocalMode {
+		path, err := rf.artifactPath(testID, "list")
+		if err != nil {
+			return nil, err
+		}
+		infos, err := ioutil.ReadDir(filepath.Dir(path))
+		if os.IsNotExist(err) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			if !info.IsDir() && !strings.HasSuffix(info.Name(), ".tmp") {
+				out = append(out, info.Name())
+			}
+		}
+		sort.Strings(out)
+		return out, nil
+	}
+	resp, err := rf.getClient().Get(rf.artifactURL(testID, ""))
+	if err != nil {
+		return nil, err
+	}
+	err = rf.checkResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return out, json.NewDecoder(resp.Body).Decode(&out)
+}
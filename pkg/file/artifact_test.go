@@ -0,0 +1,76 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package file
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/whiteblock/genesis/pkg/config"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteSources_Artifacts_Local(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artifacts")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rs := NewRemoteSources(config.Config{
+		LocalMode:   true,
+		FileHandler: config.FileHandler{ArtifactsDir: dir},
+	}, logrus.New())
+
+	names, err := rs.ListArtifacts("test")
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+
+	_, err = rs.PutArtifact("test", "logs.tar.gz", strings.NewReader("data"))
+	assert.NoError(t, err)
+
+	names, err = rs.ListArtifacts("test")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"logs.tar.gz"}, names)
+
+	rdr, err := rs.GetArtifact("test", "logs.tar.gz")
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(rdr)
+	rdr.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+
+	_, err = rs.GetArtifact("test", "../../etc/passwd")
+	assert.Equal(t, ErrInvalidArtifactName, err)
+	_, err = rs.PutArtifact("..", "logs.tar.gz", strings.NewReader("data"))
+	assert.Equal(t, ErrInvalidArtifactName, err)
+}
+
+func TestRemoteSources_PutArtifact_Remote(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+		assert.Equal(t, "/api/v1/files/tests/test/logs.tar.gz", r.URL.Path)
+		data, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "data", string(data))
+		w.WriteHeader(201)
+	}))
+	defer server.Close()
+
+	rs := NewRemoteSources(config.Config{
+		FileHandler: config.FileHandler{APIEndpoint: server.URL},
+	}, logrus.New())
+
+	location, err := rs.PutArtifact("test", "logs.tar.gz", strings.NewReader("data"))
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/api/v1/files/tests/test/logs.tar.gz", location)
+}
@@ -27,6 +27,18 @@ import (
 //RemoteSources represents a remote file source
 type RemoteSources interface {
 	GetTarReader(testnetID string, file command.File) (io.Reader, error)
+
+	//GetFile opens a file of the definition
+	GetFile(testnetID string, file command.File) (io.ReadCloser, error)
+
+	//PutArtifact stores a file produced by a test, returning where it can be found
+	PutArtifact(testID, name string, rdr io.Reader) (string, error)
+
+	//GetArtifact opens a file which was produced by a test
+	GetArtifact(testID, name string) (io.ReadCloser, error)
+
+	//ListArtifacts lists the names of the files which were produced by a test
+	ListArtifacts(testID string) ([]string, error)
 }
 
 type remoteSources struct {
@@ -109,6 +121,11 @@ func (rf remoteSources) getReader(testnetID string, file command.File) (io.ReadC
 
 }
 
+// GetFile fetches the file from the file handler service, or opens it locally in local mode
+func (rf remoteSources) GetFile(testnetID string, file command.File) (io.ReadCloser, error) {
+	return rf.getReader(testnetID, file)
+}
+
 // GetTarReader fetches the file from the file handler service and converts it to a tar reader
 func (rf remoteSources) GetTarReader(testnetID string, file command.File) (io.Reader, error) {
 	fileReader, err := rf.getReader(testnetID, file)
@@ -17,6 +17,7 @@ import (
 
 	"github.com/whiteblock/genesis/pkg/config"
 	"github.com/whiteblock/genesis/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/service"
 	"github.com/whiteblock/genesis/pkg/usecase"
 
 	"github.com/innodv/errors/await"
@@ -27,12 +28,17 @@ import (
 
 // Executor handles the  processing of mutliple commands
 type Executor interface {
-	ExecuteCommands(cmds []command.Command) entity.Result
+	// ExecuteCommands executes the given commands concurrently, stopping early if ctx is canceled
+	ExecuteCommands(ctx context.Context, cmds []command.Command) entity.Result
 	Prepare(inst *command.Instructions) error
+	// Finish cleans up after a test which has run to completion, stopping its background
+	// jobs and making its resources eligible to be reaped
+	Finish(testID string)
 }
 
 type executor struct {
 	usecase usecase.DockerUseCase
+	reaper  service.ReaperService
 	conf    config.Execution
 	log     logrus.Ext1FieldLogger
 }
@@ -45,8 +51,9 @@ var ErrDockerConnFailed = entity.NewFatalResult("could not connect to docker")
 func NewExecutor(
 	conf config.Execution,
 	usecase usecase.DockerUseCase,
+	reaper service.ReaperService,
 	log logrus.Ext1FieldLogger) Executor {
-	return &executor{usecase: usecase, conf: conf, log: log}
+	return &executor{usecase: usecase, reaper: reaper, conf: conf, log: log}
 }
 
 func (exec executor) Prepare(inst *command.Instructions) error {
@@ -78,12 +85,32 @@ func (exec executor) Prepare(inst *command.Instructions) error {
 	return await.AwaitErrors(errChan, 3)
 }
 
-func (exec executor) ExecuteCommands(cmds []command.Command) entity.Result {
+// tearsDown checks whether the command tears down its test, which does not make the test
+// active again, so that whatever the teardown leaves behind can still be reaped
+func tearsDown(cmd command.Command) bool {
+	switch command.OrderType(strings.ToLower(string(cmd.Order.Type))) {
+	case entity.TeardownOrder, entity.DestroyTestnetOrder:
+		return true
+	}
+	return false
+}
+
+// Finish cleans up after a test which has run to completion
+func (exec executor) Finish(testID string) {
+	res := exec.usecase.Finish(testID)
+	exec.log.WithFields(logrus.Fields{"test": testID, "result": res}).Debug("finished a test")
+	exec.reaper.Finish(testID)
+}
+
+func (exec executor) ExecuteCommands(ctx context.Context, cmds []command.Command) entity.Result {
 	resultChan := make(chan entity.Result, len(cmds))
 	sem := semaphore.NewWeighted(exec.conf.LimitPerTest)
-	ctx, cancelFn := context.WithTimeout(context.Background(), exec.conf.TimeLimit)
+	ctx, cancelFn := context.WithTimeout(ctx, exec.conf.TimeLimit)
 	defer cancelFn()
 	for _, cmd := range cmds {
+		if !tearsDown(cmd) {
+			exec.reaper.Track(cmd.Target.IP, cmd.TestID())
+		}
 		go func(cmd command.Command) {
 			for i := 0; i < exec.conf.ConnectionRetries; i++ {
 				err := sem.Acquire(ctx, 1)
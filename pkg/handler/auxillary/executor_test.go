@@ -0,0 +1,86 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package auxillary
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	entityMock "github.com/whiteblock/genesis/mocks/pkg/entity"
+	serviceMock "github.com/whiteblock/genesis/mocks/pkg/service"
+	usecaseMock "github.com/whiteblock/genesis/mocks/pkg/usecase"
+	"github.com/whiteblock/genesis/pkg/config"
+	"github.com/whiteblock/genesis/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/service"
+
+	"github.com/docker/docker/api/types"
+	dockerVolume "github.com/docker/docker/api/types/volume"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/whiteblock/definition/command"
+)
+
+func testInstructions(t *testing.T, orderType command.OrderType) command.Instructions {
+	data, err := json.Marshal(command.Instructions{
+		ID: "test1",
+		Commands: [][]command.Command{{{
+			ID:     "1",
+			Target: command.Target{IP: "127.0.0.1"},
+			Order:  command.Order{Type: orderType, Payload: map[string]interface{}{}},
+		}}},
+	})
+	require.NoError(t, err)
+	var out command.Instructions
+	require.NoError(t, json.Unmarshal(data, &out))
+	return out
+}
+
+func TestExecutor_Finish_Teardown_Reap(t *testing.T) {
+	cli := new(entityMock.Client)
+	cli.On("Close").Return(nil)
+	cli.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{
+		{ID: "1", Names: []string{"/node0"}, Created: time.Now().Unix(),
+			Labels: map[string]string{command.TestIDKey: "test1"}},
+	}, nil)
+	cli.On("NetworkList", mock.Anything, mock.Anything).Return([]types.NetworkResource{}, nil)
+	cli.On("VolumeList", mock.Anything, mock.Anything).Return(dockerVolume.VolumeListOKBody{}, nil)
+
+	ds := new(serviceMock.DockerService)
+	ds.On("CreateClient2", "local", "test1").Return(cli, nil).Once()
+	ds.On("Teardown", mock.Anything, mock.Anything, entity.Teardown{TestID: "test1"}).Return(
+		entity.NewSuccessResult()).Once()
+	reaper := service.NewReaperService(ds, nil, config.Reaper{LocalMode: true}, logrus.New())
+
+	usecase := new(usecaseMock.DockerUseCase)
+	usecase.On("Run", mock.Anything, mock.Anything).Return(entity.NewSuccessResult()).Twice()
+	usecase.On("Finish", "test1").Return(entity.NewSuccessResult()).Once()
+
+	exec := NewExecutor(config.Execution{LimitPerTest: 1, ConnectionRetries: 1, TimeLimit: time.Minute},
+		usecase, reaper, logrus.New())
+
+	res := exec.ExecuteCommands(context.Background(),
+		testInstructions(t, command.Createcontainer).Commands[0])
+	require.NoError(t, res.Error)
+	exec.Finish("test1")
+	// the teardown leaves the container behind, which must still get reaped
+	res = exec.ExecuteCommands(context.Background(), testInstructions(t, entity.TeardownOrder).Commands[0])
+	require.NoError(t, res.Error)
+
+	report := reaper.Reap(context.Background(), false)
+	assert.Empty(t, report.Errors)
+	if assert.Len(t, report.Candidates, 1) {
+		assert.Equal(t, "test1", report.Candidates[0].Test)
+		assert.Equal(t, entity.ReapFinished, report.Candidates[0].Reason)
+		assert.True(t, report.Candidates[0].Removed)
+	}
+	ds.AssertExpectations(t)
+	usecase.AssertExpectations(t)
+}
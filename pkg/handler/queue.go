@@ -7,6 +7,7 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"time"
@@ -102,7 +103,7 @@ func (dh deliveryHandler) process(msg amqp.Delivery,
 	if err != nil {
 		return dh.destructMsg(inst), entity.NewFatalResult(err)
 	}
-	result = dh.aux.ExecuteCommands(cmds)
+	result = dh.aux.ExecuteCommands(context.Background(), cmds)
 	if result.IsDelayed() {
 		inst.Next()
 		out, err = queue.GetNextMessage(msg, inst)
@@ -189,6 +190,9 @@ func (dh deliveryHandler) Process(msg amqp.Delivery) (out amqp.Publishing,
 		result = result.Trap()
 		out.Headers["x-delay"] = int32(dh.conf.Execution.DMCompletionDelay.Milliseconds())
 	}
+	if result.IsFatal() || result.IsAllDone() {
+		dh.aux.Finish(inst.ID)
+	}
 
 	if result.IsAllDone() || result.IsTrap() || result.IsFatal() || result.IsIgnore() {
 		stat.Finished = true
@@ -28,7 +28,9 @@ func TestNewDeliveryHandler(t *testing.T) {
 
 func TestDeliveryHandler_Process_Successful(t *testing.T) {
 	aux := new(auxMocks.Executor)
-	aux.On("ExecuteCommands", mock.Anything).Return(entity.NewSuccessResult()).Once()
+	aux.On("Prepare", mock.Anything).Return(nil)
+	aux.On("ExecuteCommands", mock.Anything, mock.Anything).Return(entity.NewSuccessResult()).Once()
+	aux.On("Finish", "").Once()
 
 	dh := NewDeliveryHandler(aux, config.Config{}, 1, logrus.New())
 
@@ -79,7 +81,8 @@ func TestDeliveryHandler_Process_NoCmds_Failures(t *testing.T) {
 
 func TestDeliveryHandler_Process_Multiple_Commands_Successful(t *testing.T) {
 	aux := new(auxMocks.Executor)
-	aux.On("ExecuteCommands", mock.Anything).Return(entity.NewSuccessResult()).Once()
+	aux.On("Prepare", mock.Anything).Return(nil)
+	aux.On("ExecuteCommands", mock.Anything, mock.Anything).Return(entity.NewSuccessResult()).Once()
 
 	dh := NewDeliveryHandler(aux, config.Config{}, 1, logrus.New())
 
@@ -120,7 +123,8 @@ func TestDeliveryHandler_Process_Multiple_Commands_Successful(t *testing.T) {
 
 func TestDeliveryHandler_Process_Execute_Nonfatal_Failure(t *testing.T) {
 	aux := new(auxMocks.Executor)
-	aux.On("ExecuteCommands", mock.Anything).Return(entity.NewErrorResult("err")).Once()
+	aux.On("Prepare", mock.Anything).Return(nil)
+	aux.On("ExecuteCommands", mock.Anything, mock.Anything).Return(entity.NewErrorResult("err")).Once()
 	dh := NewDeliveryHandler(aux, config.Config{}, 1, logrus.New())
 
 	cmd := command.Instructions{Commands: [][]command.Command{
@@ -149,7 +153,9 @@ func TestDeliveryHandler_Process_Execute_Nonfatal_Failure(t *testing.T) {
 
 func TestDeliveryHandler_Process_Execute_Fatal_Failure(t *testing.T) {
 	aux := new(auxMocks.Executor)
-	aux.On("ExecuteCommands", mock.Anything).Return(entity.NewFatalResult("err")).Once()
+	aux.On("Prepare", mock.Anything).Return(nil)
+	aux.On("ExecuteCommands", mock.Anything, mock.Anything).Return(entity.NewFatalResult("err")).Once()
+	aux.On("Finish", "").Once()
 	dh := NewDeliveryHandler(aux, config.Config{}, 1, logrus.New())
 
 	cmd := command.Instructions{Commands: [][]command.Command{
@@ -7,44 +7,123 @@
 package handler
 
 import (
+	"context"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/whiteblock/definition/command"
 	"github.com/whiteblock/genesis/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/file"
 	"github.com/whiteblock/genesis/pkg/handler/auxillary"
+	"github.com/whiteblock/genesis/pkg/repository"
+	"github.com/whiteblock/genesis/pkg/service"
+	"github.com/whiteblock/genesis/pkg/usecase"
 	util "github.com/whiteblock/utility/utils"
 
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 )
 
 const maxRetries = 5
 
+// keepAliveInterval is how often a comment is sent on an idle event stream to keep the connection open
+const keepAliveInterval = 15 * time.Second
+
 //RestHandler handles the REST api calls
 type RestHandler interface {
 	//AddCommands handles the addition of new commands
 	AddCommands(w http.ResponseWriter, r *http.Request)
+	//GetExecutions handles the listing of all of the known executions
+	GetExecutions(w http.ResponseWriter, r *http.Request)
+	//GetExecution handles the inspection of a single execution
+	GetExecution(w http.ResponseWriter, r *http.Request)
+	//CancelExecution handles stopping a running execution and tearing down what it created
+	CancelExecution(w http.ResponseWriter, r *http.Request)
+	//GetArtifacts handles listing the files produced by a test, such as collected logs
+	GetArtifacts(w http.ResponseWriter, r *http.Request)
+	//GetArtifact handles downloading a file produced by a test
+	GetArtifact(w http.ResponseWriter, r *http.Request)
+	//GetEmulation handles reporting the network emulation which is applied to a container
+	GetEmulation(w http.ResponseWriter, r *http.Request)
+	//GetStats handles exporting the resource usage of the containers of a test, as JSON or CSV
+	GetStats(w http.ResponseWriter, r *http.Request)
+	//PreviewReap handles reporting the orphaned resources which would be removed by a reap
+	PreviewReap(w http.ResponseWriter, r *http.Request)
+	//Reap handles removing the orphaned resources, only reporting them if the dryRun query parameter is set
+	Reap(w http.ResponseWriter, r *http.Request)
+	//StreamEvents handles streaming the events of all executions, or of the test given by the id query parameter
+	StreamEvents(w http.ResponseWriter, r *http.Request)
+	//StreamExecutionEvents handles streaming the events of a single execution until it finishes
+	StreamExecutionEvents(w http.ResponseWriter, r *http.Request)
 	//HealthCheck handles the reporting of the current health of this service
 	HealthCheck(w http.ResponseWriter, r *http.Request)
+	//ResumeExecutions restarts the executions which were still running when Genesis last stopped
+	ResumeExecutions()
 }
 
 type restHandler struct {
-	aux auxillary.Executor
-	log logrus.Ext1FieldLogger
+	aux     auxillary.Executor
+	usecase usecase.DockerUseCase
+	execs   repository.ExecutionRepository
+	events  service.EventService
+	reaper  service.ReaperService
+	stats   service.StatsService
+	remote  file.RemoteSources
+	log     logrus.Ext1FieldLogger
+	mu      *sync.Mutex
+	cancels map[string]context.CancelFunc
 }
 
 //NewRestHandler creates a new rest handler
-func NewRestHandler(aux auxillary.Executor, log logrus.Ext1FieldLogger) RestHandler {
+func NewRestHandler(
+	aux auxillary.Executor,
+	usecase usecase.DockerUseCase,
+	execs repository.ExecutionRepository,
+	events service.EventService,
+	reaper service.ReaperService,
+	stats service.StatsService,
+	remote file.RemoteSources,
+	log logrus.Ext1FieldLogger) RestHandler {
 	log.Debug("creating a new rest handler")
 	out := &restHandler{
-		aux: aux,
-		log: log,
+		aux:     aux,
+		usecase: usecase,
+		execs:   execs,
+		events:  events,
+		reaper:  reaper,
+		stats:   stats,
+		remote:  remote,
+		log:     log,
+		mu:      &sync.Mutex{},
+		cancels: map[string]context.CancelFunc{},
 	}
 	return out
 }
 
+func (rh *restHandler) writeJSON(w http.ResponseWriter, code int, obj interface{}) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, err = w.Write(data)
+	if err != nil {
+		rh.log.Error(err)
+	}
+}
+
 //AddCommands handles the addition of new commands
 func (rh *restHandler) AddCommands(w http.ResponseWriter, r *http.Request) {
 	var cmds command.Instructions
@@ -60,11 +139,366 @@ func (rh *restHandler) AddCommands(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 400)
 		return
 	}
-	go rh.run(&cmds)
-	w.Write([]byte("Success"))
+	exec := entity.NewExecution(uuid.New().String(), cmds)
+	exec.Teardown, err = teardownInstructions(cmds)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	rh.save(&exec)
+	rh.start(exec)
+	rh.writeJSON(w, 200, map[string]string{"id": exec.ID})
+}
+
+//GetExecutions handles the listing of all of the known executions
+func (rh *restHandler) GetExecutions(w http.ResponseWriter, r *http.Request) {
+	execs, err := rh.execs.List()
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	out := make([]entity.ExecutionSummary, len(execs))
+	for i := range execs {
+		out[i] = execs[i].Summary()
+	}
+	rh.writeJSON(w, 200, out)
+}
+
+func (rh *restHandler) getExecution(w http.ResponseWriter, r *http.Request) (entity.Execution, bool) {
+	exec, err := rh.execs.Get(mux.Vars(r)["id"])
+	if errors.Is(err, repository.ErrExecutionNotFound) {
+		http.Error(w, err.Error(), 404)
+		return exec, false
+	}
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return exec, false
+	}
+	return exec, true
+}
+
+//GetExecution handles the inspection of a single execution
+func (rh *restHandler) GetExecution(w http.ResponseWriter, r *http.Request) {
+	exec, ok := rh.getExecution(w, r)
+	if !ok {
+		return
+	}
+	out := exec.Summary()
+	if exec.IsRunning() {
+		out.Remaining = exec.Instructions.Commands
+	}
+	rh.writeJSON(w, 200, out)
+}
+
+//CancelExecution handles stopping a running execution and tearing down what it created
+func (rh *restHandler) CancelExecution(w http.ResponseWriter, r *http.Request) {
+	exec, ok := rh.getExecution(w, r)
+	if !ok {
+		return
+	}
+	rh.mu.Lock()
+	cancel, running := rh.cancels[exec.ID]
+	rh.mu.Unlock()
+	if !running {
+		http.Error(w, fmt.Sprintf("execution %s is not running", exec.ID), 409)
+		return
+	}
+	rh.log.WithField("execution", exec.ID).Info("cancelling an execution")
+	cancel()
+	rh.writeJSON(w, 202, exec.Summary())
+}
+
+//GetArtifacts handles listing the files produced by a test, such as collected logs
+func (rh *restHandler) GetArtifacts(w http.ResponseWriter, r *http.Request) {
+	names, err := rh.remote.ListArtifacts(mux.Vars(r)["test"])
+	if errors.Is(err, file.ErrInvalidArtifactName) {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	rh.writeJSON(w, 200, names)
+}
+
+//GetArtifact handles downloading a file produced by a test
+func (rh *restHandler) GetArtifact(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	rdr, err := rh.remote.GetArtifact(vars["test"], vars["name"])
+	if errors.Is(err, file.ErrInvalidArtifactName) {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		http.Error(w, "artifact not found", 404)
+		return
+	}
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	defer rdr.Close()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", vars["name"]))
+	_, err = io.Copy(w, rdr)
+	if err != nil {
+		rh.log.WithField("error", err).Error("failed to send an artifact")
+	}
+}
+
+//GetEmulation handles reporting the network emulation which is applied to a container. The network is
+//given by the network query parameter, and the docker host by host, which defaults to the local one.
+func (rh *restHandler) GetEmulation(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if len(query.Get("network")) == 0 {
+		http.Error(w, "missing the network query parameter", 400)
+		return
+	}
+	host := query.Get("host")
+	if len(host) == 0 {
+		host = "127.0.0.1"
+	}
+	cmd := command.Command{
+		ID:     uuid.New().String(),
+		Target: command.Target{IP: host},
+		Order: command.Order{
+			Type: entity.GetEmulationOrder,
+			Payload: entity.GetEmulation{
+				Container: mux.Vars(r)["container"],
+				Network:   query.Get("network"),
+			},
+		},
+	}
+	if test := query.Get("test"); len(test) > 0 {
+		cmd.Meta = map[string]string{command.TestIDKey: test}
+	}
+
+	res := rh.usecase.Run(r.Context(), cmd)
+	switch {
+	case res.IsSuccess():
+		rh.writeJSON(w, 200, res.Meta)
+	case res.IsFatal():
+		http.Error(w, res.Error.Error(), 400)
+	default:
+		rh.writeJSON(w, 500, res)
+	}
+}
+
+//GetStats handles exporting the resource usage of the containers of a test, which is JSON unless the
+//format query parameter is csv. It can be limited to some of the containers with the container query parameter.
+func (rh *restHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		http.Error(w, fmt.Sprintf("unknown format %q", format), 400)
+		return
+	}
+	samples, err := rh.stats.Samples(mux.Vars(r)["test"], query["container"])
+	if errors.Is(err, repository.ErrInvalidStatsName) {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	if errors.Is(err, repository.ErrStatsNotFound) {
+		http.Error(w, "stats not found", 404)
+		return
+	}
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	if format != "csv" {
+		rh.writeJSON(w, 200, samples)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	out := csv.NewWriter(w)
+	err = out.Write(entity.StatsCSVHeader)
+	for i := 0; err == nil && i < len(samples); i++ {
+		err = out.Write(samples[i].CSVRecord())
+	}
+	out.Flush()
+	if err == nil {
+		err = out.Error()
+	}
+	if err != nil {
+		rh.log.WithField("error", err).Error("failed to send the stats")
+	}
 }
 
-func (rh *restHandler) process(inst *command.Instructions) (result entity.Result) {
+//PreviewReap handles reporting the orphaned resources which would be removed by a reap
+func (rh *restHandler) PreviewReap(w http.ResponseWriter, r *http.Request) {
+	rh.writeJSON(w, 200, rh.reaper.Reap(r.Context(), true))
+}
+
+//Reap handles removing the orphaned resources, only reporting them if the dryRun query parameter is set
+func (rh *restHandler) Reap(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if val := r.URL.Query().Get("dryRun"); len(val) > 0 {
+		var err error
+		dryRun, err = strconv.ParseBool(val)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+	}
+	rh.writeJSON(w, 200, rh.reaper.Reap(r.Context(), dryRun))
+}
+
+//StreamEvents handles streaming the events of all executions, or of the test given by the id query parameter
+func (rh *restHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	rh.stream(w, r, r.URL.Query().Get("id"), false)
+}
+
+//StreamExecutionEvents handles streaming the events of a single execution until it finishes
+func (rh *restHandler) StreamExecutionEvents(w http.ResponseWriter, r *http.Request) {
+	exec, ok := rh.getExecution(w, r)
+	if !ok {
+		return
+	}
+	if !exec.IsRunning() {
+		ev := rh.newEvent(exec, entity.FinishedEvent)
+		ev.Result = exec.LastResult
+		ev.Data = map[string]interface{}{"state": exec.State}
+		if rh.startStream(w) {
+			rh.writeEvent(w, ev)
+		}
+		return
+	}
+	rh.stream(w, r, exec.ID, true)
+}
+
+// startStream writes the headers of a server-sent event stream
+func (rh *restHandler) startStream(w http.ResponseWriter) bool {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", 500)
+		return false
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(200)
+	flusher.Flush()
+	return true
+}
+
+// writeEvent writes a single event to a server-sent event stream, returning false if the client is gone
+func (rh *restHandler) writeEvent(w http.ResponseWriter, ev entity.Event) bool {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		rh.log.WithField("error", err).Error("failed to encode an event")
+		return true
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+	if err != nil {
+		rh.log.WithField("error", err).Debug("lost an event stream")
+		return false
+	}
+	w.(http.Flusher).Flush()
+	return true
+}
+
+// stream sends the events matching the id to the client until it disconnects. If untilFinished
+// is set, the stream also ends once the execution with that id finishes.
+func (rh *restHandler) stream(w http.ResponseWriter, r *http.Request, id string, untilFinished bool) {
+	events, unsubscribe := rh.events.Subscribe(id)
+	defer unsubscribe()
+	if !rh.startStream(w) {
+		return
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			_, err := fmt.Fprint(w, ": keep-alive\n\n")
+			if err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+		case ev, ok := <-events:
+			if !ok || !rh.writeEvent(w, ev) {
+				return
+			}
+			if untilFinished && ev.Type == entity.FinishedEvent && ev.Execution == id {
+				return
+			}
+		}
+	}
+}
+
+//ResumeExecutions restarts the executions which were still running when Genesis last stopped
+func (rh *restHandler) ResumeExecutions() {
+	execs, err := rh.execs.List()
+	if err != nil {
+		rh.log.WithField("error", err).Error("failed to load the stored executions")
+		return
+	}
+	for _, exec := range execs {
+		if !exec.IsRunning() {
+			continue
+		}
+		rh.log.WithFields(logrus.Fields{
+			"execution": exec.ID,
+			"testnet":   exec.Instructions.ID,
+			"step":      exec.Step,
+			"retries":   exec.Retries,
+		}).Info("resuming an unfinished execution")
+		rh.start(exec)
+	}
+}
+
+func (rh *restHandler) save(exec *entity.Execution) {
+	exec.Updated = time.Now()
+	err := rh.execs.Put(*exec)
+	if err != nil {
+		rh.log.WithFields(logrus.Fields{
+			"execution": exec.ID,
+			"error":     err,
+		}).Error("failed to store the state of an execution")
+	}
+}
+
+// start registers the execution as cancellable, and then runs it in the background
+func (rh *restHandler) start(exec entity.Execution) {
+	ctx, cancel := context.WithCancel(context.Background())
+	rh.mu.Lock()
+	rh.cancels[exec.ID] = cancel
+	rh.mu.Unlock()
+
+	go func() {
+		defer func() {
+			rh.mu.Lock()
+			delete(rh.cancels, exec.ID)
+			rh.mu.Unlock()
+			cancel()
+		}()
+		rh.run(ctx, exec)
+	}()
+}
+
+func (rh *restHandler) newEvent(exec entity.Execution, eventType entity.EventType) entity.Event {
+	ev := entity.NewEvent(eventType, exec.Instructions.ID)
+	ev.Execution = exec.ID
+	ev.Step = exec.Step
+	return ev
+}
+
+func (rh *restHandler) publish(exec entity.Execution, eventType entity.EventType, fn func(*entity.Event)) {
+	ev := rh.newEvent(exec, eventType)
+	if fn != nil {
+		fn(&ev)
+	}
+	rh.events.Publish(ev)
+}
+
+func (rh *restHandler) process(ctx context.Context, exec *entity.Execution) (result entity.Result) {
+	inst := &exec.Instructions
 	cmds, err := inst.Peek()
 
 	isLastOne := false
@@ -82,7 +516,13 @@ func (rh *restHandler) process(inst *command.Instructions) (result entity.Result
 		isLastOne = true
 	}
 
-	result = rh.aux.ExecuteCommands(cmds)
+	for i := range cmds {
+		cmd := cmds[i]
+		rh.publish(*exec, entity.CommandStartEvent, func(ev *entity.Event) {
+			ev.Command = &cmd
+		})
+	}
+	result = rh.aux.ExecuteCommands(ctx, cmds)
 
 	if result.IsFatal() {
 		rh.log.WithFields(logrus.Fields{"result": result, "error": result.Error.Error(),
@@ -125,40 +565,91 @@ func (rh *restHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (rh *restHandler) run(inst *command.Instructions) {
-	retries := 0
+// finish records the final state of an execution. Executions which failed or were cancelled
+// are torn down, so that nothing is left behind by them.
+func (rh *restHandler) finish(exec *entity.Execution, state entity.ExecutionState) {
+	exec.State = state
+	rh.save(exec)
+	// a trapped test is deliberately left running, so it is not cleaned up after
+	if exec.LastResult == nil || !exec.LastResult.IsTrap() {
+		rh.aux.Finish(exec.Instructions.ID)
+	}
+	rh.publish(*exec, entity.FinishedEvent, func(ev *entity.Event) {
+		ev.Result = exec.LastResult
+		ev.Data = map[string]interface{}{"state": state}
+	})
+	if state == entity.ExecutionFailed || state == entity.ExecutionCancelled {
+		rh.teardown(*exec)
+	}
+}
+
+// teardown executes the teardown instructions of an execution, step by step
+func (rh *restHandler) teardown(exec entity.Execution) {
+	for _, cmds := range exec.Teardown.Commands {
+		res := rh.aux.ExecuteCommands(context.Background(), cmds)
+		if !res.IsSuccess() {
+			rh.log.WithFields(logrus.Fields{
+				"execution": exec.ID,
+				"result":    res,
+			}).Error("failed to completely tear down an execution")
+		}
+	}
+}
+
+func (rh *restHandler) run(ctx context.Context, exec entity.Execution) {
 	for {
-		res := rh.process(inst)
+		res := rh.process(ctx, &exec)
+		exec.LastResult = &res
+		rh.publish(exec, entity.ResultEvent, func(ev *entity.Event) {
+			ev.Result = &res
+		})
+
+		if ctx.Err() != nil {
+			rh.log.WithField("execution", exec.ID).Info("execution was cancelled, tearing it down")
+			rh.finish(&exec, entity.ExecutionCancelled)
+			return
+		}
 
 		if res.IsAllDone() {
 			rh.log.Info("successfully completed")
+			rh.finish(&exec, entity.ExecutionFinished)
 			return
 		}
 		if res.IsFatal() {
 			rh.log.Error("a command could not execute")
+			rh.finish(&exec, entity.ExecutionFailed)
 			return
 		}
 
 		if res.IsIgnore() {
 			rh.log.Error("ignoring a message")
+			rh.finish(&exec, entity.ExecutionFailed)
 			return
 		}
 		if res.IsTrap() {
 			rh.log.Info("a trap was activated")
+			rh.finish(&exec, entity.ExecutionFinished)
 			return
 		}
 
-		if res.IsRequeue() || !res.IsSuccess() {
-			retries++
-			if retries > maxRetries {
+		if res.IsRequeue() && res.IsSuccess() { // moved on to the next step
+			exec.Step++
+			exec.Retries = 0
+			rh.publish(exec, entity.StepEvent, func(ev *entity.Event) {
+				ev.Data = map[string]interface{}{"remaining": len(exec.Instructions.Commands)}
+			})
+		} else if !res.IsSuccess() {
+			exec.Retries++
+			if exec.Retries > maxRetries {
 				rh.log.Error("too many retries for command")
+				rh.finish(&exec, entity.ExecutionFailed)
 				return
 			}
 			rh.log.Info("retrying command")
-			continue
-		} else {
-			retries = 0
+			rh.publish(exec, entity.RetryEvent, func(ev *entity.Event) {
+				ev.Data = map[string]interface{}{"retries": exec.Retries}
+			})
 		}
-		continue
+		rh.save(&exec)
 	}
 }
@@ -7,18 +7,31 @@
 package handler
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/whiteblock/definition/command"
+	fileMocks "github.com/whiteblock/genesis/mocks/pkg/file"
 	auxMocks "github.com/whiteblock/genesis/mocks/pkg/handler/auxillary"
+	serviceMocks "github.com/whiteblock/genesis/mocks/pkg/service"
+	usecaseMocks "github.com/whiteblock/genesis/mocks/pkg/usecase"
+	"github.com/whiteblock/genesis/pkg/config"
 	"github.com/whiteblock/genesis/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/handler/auxillary"
+	"github.com/whiteblock/genesis/pkg/repository"
+	"github.com/whiteblock/genesis/pkg/service"
 
+	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -43,6 +56,19 @@ var testCommands = command.Instructions{Commands: [][]command.Command{{
 	},
 }}}
 
+// isTeardown matches the step which tears down an execution
+var isTeardown = mock.MatchedBy(func(cmds []command.Command) bool {
+	return len(cmds) == 1 && cmds[0].Order.Type == entity.TeardownOrder
+})
+
+func testReaper() service.ReaperService {
+	return service.NewReaperService(nil, nil, config.Reaper{}, logrus.New())
+}
+
+func newTestRestHandler(aux auxillary.Executor, execs repository.ExecutionRepository) RestHandler {
+	return NewRestHandler(aux, nil, execs, service.NewEventService(logrus.New()), testReaper(), nil, nil, logrus.New())
+}
+
 func TestRestHandler(t *testing.T) {
 
 	data, err := json.Marshal(testCommands)
@@ -54,13 +80,14 @@ func TestRestHandler(t *testing.T) {
 	runChan := make(chan []command.Command)
 
 	aux := new(auxMocks.Executor)
-	aux.On("ExecuteCommands", mock.Anything).Return(entity.NewSuccessResult()).Run(func(args mock.Arguments) {
-		cmds, ok := args.Get(0).([]command.Command)
+	aux.On("Finish", "").Once()
+	aux.On("ExecuteCommands", mock.Anything, mock.Anything).Return(entity.NewSuccessResult()).Run(func(args mock.Arguments) {
+		cmds, ok := args.Get(1).([]command.Command)
 		assert.True(t, ok)
 		runChan <- cmds
 	}).Times(len(testCommands.Commands))
 
-	rh := NewRestHandler(aux, logrus.New())
+	rh := newTestRestHandler(aux, repository.NewMemoryExecutionRepository())
 
 	recorder := httptest.NewRecorder()
 	go rh.AddCommands(recorder, req)
@@ -85,16 +112,22 @@ func TestRestHandler_Requeue(t *testing.T) {
 	assert.NoError(t, err)
 
 	runChan := make(chan []command.Command)
+	tornDown := make(chan []command.Command)
 
 	aux := new(auxMocks.Executor)
-	aux.On("ExecuteCommands", mock.Anything).Return(entity.NewErrorResult("err")).Run(func(args mock.Arguments) {
-		cmds, ok := args.Get(0).([]command.Command)
+	aux.On("Finish", "").Once()
+	aux.On("ExecuteCommands", mock.Anything, isTeardown).Return(entity.NewSuccessResult()).Run(
+		func(args mock.Arguments) {
+			tornDown <- args.Get(1).([]command.Command)
+		}).Once()
+	aux.On("ExecuteCommands", mock.Anything, mock.Anything).Return(entity.NewErrorResult("err")).Run(func(args mock.Arguments) {
+		cmds, ok := args.Get(1).([]command.Command)
 		assert.True(t, ok)
 		runChan <- cmds
 
 	}).Times(len(testCommands.Commands) * (maxRetries + 1))
 
-	rh := NewRestHandler(aux, logrus.New())
+	rh := newTestRestHandler(aux, repository.NewMemoryExecutionRepository())
 
 	recorder := httptest.NewRecorder()
 	go rh.AddCommands(recorder, req)
@@ -107,6 +140,11 @@ func TestRestHandler_Requeue(t *testing.T) {
 				len(testCommands.Commands)*(maxRetries)))
 		}
 	}
+	select {
+	case <-tornDown:
+	case <-time.After(5 * time.Second):
+		t.Fatal("teardown did not happen within 5 seconds")
+	}
 	aux.AssertExpectations(t)
 }
 
@@ -118,17 +156,23 @@ func TestRestHandler_Fatal(t *testing.T) { //testCommands
 	assert.NoError(t, err)
 
 	runChan := make(chan []command.Command)
+	tornDown := make(chan []command.Command)
 
 	aux := new(auxMocks.Executor)
-	aux.On("ExecuteCommands", mock.Anything).Return(entity.NewFatalResult("err")).Run(func(args mock.Arguments) {
+	aux.On("Finish", "").Once()
+	aux.On("ExecuteCommands", mock.Anything, isTeardown).Return(entity.NewSuccessResult()).Run(
+		func(args mock.Arguments) {
+			tornDown <- args.Get(1).([]command.Command)
+		}).Once()
+	aux.On("ExecuteCommands", mock.Anything, mock.Anything).Return(entity.NewFatalResult("err")).Run(func(args mock.Arguments) {
 		t.Log("called run")
-		cmds, ok := args.Get(0).([]command.Command)
+		cmds, ok := args.Get(1).([]command.Command)
 		assert.True(t, ok)
 		runChan <- cmds
 
 	}).Times(len(testCommands.Commands))
 
-	rh := NewRestHandler(aux, logrus.New())
+	rh := newTestRestHandler(aux, repository.NewMemoryExecutionRepository())
 
 	recorder := httptest.NewRecorder()
 	rh.AddCommands(recorder, req)
@@ -140,6 +184,13 @@ func TestRestHandler_Fatal(t *testing.T) { //testCommands
 			t.Fatal("Report did not happen within 5 seconds")
 		}
 	}
+	select {
+	case cmds := <-tornDown:
+		assert.Equal(t, command.Target{IP: "0.0.0.0"}, cmds[0].Target)
+		assert.Equal(t, map[string]interface{}{"hosts": []interface{}{"0.0.0.0"}}, cmds[0].Order.Payload)
+	case <-time.After(5 * time.Second):
+		t.Fatal("teardown did not happen within 5 seconds")
+	}
 	aux.AssertExpectations(t)
 }
 
@@ -147,9 +198,375 @@ func TestRestHandler_HealthCheck(t *testing.T) {
 	req, err := http.NewRequest("GET", "/health", bytes.NewReader([]byte{}))
 	assert.NoError(t, err)
 
-	rh := NewRestHandler(nil, logrus.New())
+	rh := newTestRestHandler(nil, repository.NewMemoryExecutionRepository())
 	recorder := httptest.NewRecorder()
 	rh.HealthCheck(recorder, req)
 
 	assert.Equal(t, "OK", recorder.Body.String())
 }
+
+func TestRestHandler_ResumeExecutions(t *testing.T) {
+	execs := repository.NewMemoryExecutionRepository()
+	unfinished := entity.NewExecution("1", testCommands)
+	finished := entity.NewExecution("2", testCommands)
+	finished.State = entity.ExecutionFinished
+	assert.NoError(t, execs.Put(unfinished))
+	assert.NoError(t, execs.Put(finished))
+
+	runChan := make(chan []command.Command)
+
+	aux := new(auxMocks.Executor)
+	aux.On("Finish", "").Once()
+	aux.On("ExecuteCommands", mock.Anything, mock.Anything).Return(entity.NewSuccessResult()).Run(func(args mock.Arguments) {
+		cmds, ok := args.Get(1).([]command.Command)
+		assert.True(t, ok)
+		runChan <- cmds
+	}).Times(len(testCommands.Commands))
+
+	rh := newTestRestHandler(aux, execs)
+	rh.ResumeExecutions()
+
+	for range testCommands.Commands {
+		select {
+		case <-runChan:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Report did not happen within 5 seconds")
+		}
+	}
+
+	assert.Eventually(t, func() bool {
+		exec, err := execs.Get("1")
+		return err == nil && exec.State == entity.ExecutionFinished
+	}, 5*time.Second, 10*time.Millisecond)
+	aux.AssertExpectations(t)
+}
+
+func TestRestHandler_GetExecution(t *testing.T) {
+	execs := repository.NewMemoryExecutionRepository()
+	exec := entity.NewExecution("1", testCommands)
+	assert.NoError(t, execs.Put(exec))
+
+	rh := newTestRestHandler(nil, execs)
+
+	req, err := http.NewRequest("GET", "/executions", nil)
+	assert.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	rh.GetExecutions(recorder, req)
+	assert.Equal(t, 200, recorder.Code)
+
+	var summaries []entity.ExecutionSummary
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &summaries))
+	assert.Len(t, summaries, 1)
+
+	req, err = http.NewRequest("GET", "/executions/1", nil)
+	assert.NoError(t, err)
+	recorder = httptest.NewRecorder()
+	rh.GetExecution(recorder, mux.SetURLVars(req, map[string]string{"id": "1"}))
+	assert.Equal(t, 200, recorder.Code)
+
+	var summary entity.ExecutionSummary
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &summary))
+	assert.Equal(t, "1", summary.ID)
+	assert.Equal(t, entity.ExecutionRunning, summary.State)
+	assert.Equal(t, len(testCommands.Commands), summary.RemainingSteps)
+	assert.Len(t, summary.Remaining, len(testCommands.Commands))
+
+	req, err = http.NewRequest("GET", "/executions/2", nil)
+	assert.NoError(t, err)
+	recorder = httptest.NewRecorder()
+	rh.GetExecution(recorder, mux.SetURLVars(req, map[string]string{"id": "2"}))
+	assert.Equal(t, 404, recorder.Code)
+}
+
+func TestRestHandler_CancelExecution(t *testing.T) {
+	inst := command.Instructions{Commands: [][]command.Command{
+		{command.Command{
+			ID:     "TEST",
+			Target: command.Target{IP: "0.0.0.0"},
+			Order: command.Order{
+				Type:    "createContainer",
+				Payload: map[string]interface{}{"name": "foo"},
+			},
+		}},
+		{command.Command{
+			ID:     "TEST2",
+			Target: command.Target{IP: "0.0.0.0"},
+			Order: command.Order{
+				Type:    "startContainer",
+				Payload: map[string]interface{}{"name": "foo"},
+			},
+		}},
+	}}
+	data, err := json.Marshal(inst)
+	assert.NoError(t, err)
+
+	started := make(chan struct{})
+	tornDown := make(chan []command.Command)
+
+	aux := new(auxMocks.Executor)
+	aux.On("Finish", "").Once()
+	aux.On("ExecuteCommands", mock.Anything, mock.Anything).Return(entity.NewSuccessResult()).Run(
+		func(args mock.Arguments) {
+			cmds := args.Get(1).([]command.Command)
+			if cmds[0].Order.Type == entity.TeardownOrder {
+				tornDown <- cmds
+				return
+			}
+			close(started)
+			<-args.Get(0).(context.Context).Done()
+		}).Twice()
+
+	execs := repository.NewMemoryExecutionRepository()
+	rh := newTestRestHandler(aux, execs)
+
+	req, err := http.NewRequest("POST", "/command", bytes.NewReader(data))
+	assert.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	rh.AddCommands(recorder, req)
+	assert.Equal(t, 200, recorder.Code)
+
+	var resp map[string]string
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	id := resp["id"]
+	assert.NotEmpty(t, id)
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("execution did not start within 5 seconds")
+	}
+
+	req, err = http.NewRequest("DELETE", "/executions/"+id, nil)
+	assert.NoError(t, err)
+	recorder = httptest.NewRecorder()
+	rh.CancelExecution(recorder, mux.SetURLVars(req, map[string]string{"id": id}))
+	assert.Equal(t, 202, recorder.Code)
+
+	select {
+	case cmds := <-tornDown:
+		assert.Len(t, cmds, 1)
+		assert.Equal(t, map[string]interface{}{"hosts": []interface{}{"0.0.0.0"}}, cmds[0].Order.Payload)
+	case <-time.After(5 * time.Second):
+		t.Fatal("teardown did not happen within 5 seconds")
+	}
+
+	assert.Eventually(t, func() bool {
+		exec, err := execs.Get(id)
+		return err == nil && exec.State == entity.ExecutionCancelled
+	}, 5*time.Second, 10*time.Millisecond)
+	aux.AssertExpectations(t)
+}
+
+func TestRestHandler_StreamExecutionEvents(t *testing.T) {
+	inst := command.Instructions{Commands: [][]command.Command{
+		testCommands.Commands[0][:1],
+		testCommands.Commands[0][1:],
+	}}
+	data, err := json.Marshal(inst)
+	assert.NoError(t, err)
+
+	proceed := make(chan struct{})
+	aux := new(auxMocks.Executor)
+	aux.On("Finish", "").Once()
+	aux.On("ExecuteCommands", mock.Anything, mock.Anything).Return(entity.NewSuccessResult()).Run(
+		func(args mock.Arguments) {
+			<-proceed
+		})
+
+	rh := newTestRestHandler(aux, repository.NewMemoryExecutionRepository())
+	router := mux.NewRouter()
+	router.HandleFunc("/command", rh.AddCommands).Methods("POST")
+	router.HandleFunc("/executions/{id}/events", rh.StreamExecutionEvents).Methods("GET")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/command", "application/json", bytes.NewReader(data))
+	assert.NoError(t, err)
+	var created map[string]string
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+
+	resp, err = http.Get(server.URL + "/executions/" + created["id"] + "/events")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	close(proceed)
+
+	done := make(chan []entity.Event)
+	go func() {
+		events := []entity.Event{}
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if !strings.HasPrefix(scanner.Text(), "data: ") {
+				continue
+			}
+			var ev entity.Event
+			assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(scanner.Text(), "data: ")), &ev))
+			events = append(events, ev)
+		}
+		done <- events
+	}()
+
+	select {
+	case events := <-done:
+		types := []entity.EventType{}
+		for _, ev := range events {
+			assert.Equal(t, created["id"], ev.Execution)
+			types = append(types, ev.Type)
+		}
+		assert.Equal(t, []entity.EventType{entity.ResultEvent, entity.StepEvent}, types[:2])
+		assert.Contains(t, types, entity.CommandStartEvent)
+		assert.Equal(t, entity.FinishedEvent, types[len(types)-1])
+		assert.True(t, events[len(events)-1].Result.IsAllDone())
+	case <-time.After(5 * time.Second):
+		t.Fatal("the event stream did not finish within 5 seconds")
+	}
+}
+
+func TestRestHandler_Reap(t *testing.T) {
+	reaper := new(serviceMocks.ReaperService)
+	reaper.On("Reap", mock.Anything, true).Return(entity.ReapReport{DryRun: true}).Twice()
+	reaper.On("Reap", mock.Anything, false).Return(entity.ReapReport{}).Once()
+
+	rh := NewRestHandler(nil, nil, repository.NewMemoryExecutionRepository(),
+		service.NewEventService(logrus.New()), reaper, nil, nil, logrus.New())
+
+	req, err := http.NewRequest("GET", "/reaper", nil)
+	assert.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	rh.PreviewReap(recorder, req)
+	assert.Equal(t, 200, recorder.Code)
+
+	var report entity.ReapReport
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+	assert.True(t, report.DryRun)
+
+	for _, query := range []string{"?dryRun=true", ""} {
+		req, err = http.NewRequest("POST", "/reaper"+query, nil)
+		assert.NoError(t, err)
+		recorder = httptest.NewRecorder()
+		rh.Reap(recorder, req)
+		assert.Equal(t, 200, recorder.Code)
+	}
+
+	req, err = http.NewRequest("POST", "/reaper?dryRun=maybe", nil)
+	assert.NoError(t, err)
+	recorder = httptest.NewRecorder()
+	rh.Reap(recorder, req)
+	assert.Equal(t, 400, recorder.Code)
+
+	reaper.AssertExpectations(t)
+}
+
+func TestRestHandler_GetArtifact(t *testing.T) {
+	remote := new(fileMocks.RemoteSources)
+	remote.On("ListArtifacts", "test").Return([]string{"logs.tar.gz"}, nil).Once()
+	remote.On("GetArtifact", "test", "logs.tar.gz").Return(
+		ioutil.NopCloser(strings.NewReader("data")), nil).Once()
+	remote.On("GetArtifact", "test", "missing").Return(nil, os.ErrNotExist).Once()
+
+	rh := NewRestHandler(nil, nil, repository.NewMemoryExecutionRepository(),
+		service.NewEventService(logrus.New()), testReaper(), nil, remote, logrus.New())
+
+	req, err := http.NewRequest("GET", "/artifacts/test", nil)
+	assert.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	rh.GetArtifacts(recorder, mux.SetURLVars(req, map[string]string{"test": "test"}))
+	assert.Equal(t, 200, recorder.Code)
+	assert.JSONEq(t, `["logs.tar.gz"]`, recorder.Body.String())
+
+	req, err = http.NewRequest("GET", "/artifacts/test/logs.tar.gz", nil)
+	assert.NoError(t, err)
+	recorder = httptest.NewRecorder()
+	rh.GetArtifact(recorder, mux.SetURLVars(req, map[string]string{"test": "test", "name": "logs.tar.gz"}))
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, "data", recorder.Body.String())
+
+	req, err = http.NewRequest("GET", "/artifacts/test/missing", nil)
+	assert.NoError(t, err)
+	recorder = httptest.NewRecorder()
+	rh.GetArtifact(recorder, mux.SetURLVars(req, map[string]string{"test": "test", "name": "missing"}))
+	assert.Equal(t, 404, recorder.Code)
+
+	remote.AssertExpectations(t)
+}
+
+func TestRestHandler_GetEmulation(t *testing.T) {
+	uc := new(usecaseMocks.DockerUseCase)
+	uc.On("Run", mock.Anything, mock.Anything).Return(entity.NewSuccessResult().InjectMeta(
+		map[string]interface{}{"drops": 3})).Run(func(args mock.Arguments) {
+		cmd := args.Get(1).(command.Command)
+		assert.Equal(t, "127.0.0.1", cmd.Target.IP)
+		assert.Equal(t, "test", cmd.Meta[command.TestIDKey])
+		assert.Equal(t, entity.GetEmulationOrder, cmd.Order.Type)
+		assert.Equal(t, entity.GetEmulation{Container: "node0", Network: "net"}, cmd.Order.Payload)
+	}).Once()
+
+	rh := NewRestHandler(nil, uc, repository.NewMemoryExecutionRepository(),
+		service.NewEventService(logrus.New()), testReaper(), nil, nil, logrus.New())
+
+	req, err := http.NewRequest("GET", "/emulation/node0?network=net&test=test", nil)
+	assert.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	rh.GetEmulation(recorder, mux.SetURLVars(req, map[string]string{"container": "node0"}))
+	assert.Equal(t, 200, recorder.Code)
+	assert.JSONEq(t, `{"drops": 3}`, recorder.Body.String())
+
+	req, err = http.NewRequest("GET", "/emulation/node0", nil)
+	assert.NoError(t, err)
+	recorder = httptest.NewRecorder()
+	rh.GetEmulation(recorder, mux.SetURLVars(req, map[string]string{"container": "node0"}))
+	assert.Equal(t, 400, recorder.Code)
+
+	uc.AssertExpectations(t)
+}
+
+func TestRestHandler_GetStats(t *testing.T) {
+	samples := []entity.StatsSample{{
+		Time:        time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC),
+		Container:   "node0",
+		CPUPercent:  12.5,
+		MemoryUsage: 1024,
+	}}
+	stats := new(serviceMocks.StatsService)
+	stats.On("Samples", "test", []string(nil)).Return(samples, nil).Once()
+	stats.On("Samples", "test", []string{"node0"}).Return(samples, nil).Once()
+	stats.On("Samples", "missing", []string(nil)).Return(nil, repository.ErrStatsNotFound).Once()
+
+	rh := NewRestHandler(nil, nil, repository.NewMemoryExecutionRepository(),
+		service.NewEventService(logrus.New()), testReaper(), stats, nil, logrus.New())
+
+	req, err := http.NewRequest("GET", "/stats/test", nil)
+	assert.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	rh.GetStats(recorder, mux.SetURLVars(req, map[string]string{"test": "test"}))
+	assert.Equal(t, 200, recorder.Code)
+	var out []entity.StatsSample
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &out))
+	assert.Equal(t, samples, out)
+
+	req, err = http.NewRequest("GET", "/stats/test?container=node0&format=csv", nil)
+	assert.NoError(t, err)
+	recorder = httptest.NewRecorder()
+	rh.GetStats(recorder, mux.SetURLVars(req, map[string]string{"test": "test"}))
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Equal(t, strings.Join(entity.StatsCSVHeader, ","), lines[0])
+	assert.Equal(t, strings.Join(samples[0].CSVRecord(), ","), lines[1])
+
+	req, err = http.NewRequest("GET", "/stats/missing", nil)
+	assert.NoError(t, err)
+	recorder = httptest.NewRecorder()
+	rh.GetStats(recorder, mux.SetURLVars(req, map[string]string{"test": "missing"}))
+	assert.Equal(t, 404, recorder.Code)
+
+	req, err = http.NewRequest("GET", "/stats/test?format=xml", nil)
+	assert.NoError(t, err)
+	recorder = httptest.NewRecorder()
+	rh.GetStats(recorder, mux.SetURLVars(req, map[string]string{"test": "test"}))
+	assert.Equal(t, 400, recorder.Code)
+
+	stats.AssertExpectations(t)
+}
@@ -0,0 +1,63 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/google/uuid"
+	"github.com/whiteblock/definition/command"
+)
+
+// teardownInstructions creates the instructions which tear down the test of the given instructions,
+// with a single teardown order covering every host which they target. The teardown order removes
+// everything labelled with the test, including whatever was created while it ran, and stops its
+// background jobs.
+func teardownInstructions(inst command.Instructions) (out command.Instructions, err error) {
+	tmp := command.Instructions{
+		ID:           inst.ID,
+		OrgID:        inst.OrgID,
+		DefinitionID: inst.DefinitionID,
+		Auth:         inst.Auth,
+	}
+	var first *command.Command
+	hosts := []string{}
+	seen := map[string]bool{}
+	for i := range inst.Commands {
+		for j := range inst.Commands[i] {
+			cmd := &inst.Commands[i][j]
+			if first == nil {
+				first = cmd
+			}
+			if len(cmd.Target.IP) == 0 || seen[cmd.Target.IP] {
+				continue
+			}
+			seen[cmd.Target.IP] = true
+			hosts = append(hosts, cmd.Target.IP)
+		}
+	}
+	if first != nil {
+		tmp.Commands = [][]command.Command{{command.Command{
+			ID:     uuid.New().String(),
+			Target: first.Target,
+			Order: command.Order{
+				Type:    entity.TeardownOrder,
+				Payload: entity.Teardown{TestID: inst.ID, Hosts: hosts},
+			},
+			Meta: first.Meta,
+		}}}
+	}
+	// round trip through JSON so that the commands get linked back to their instructions
+	data, err := json.Marshal(tmp)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &out)
+	return
+}
@@ -0,0 +1,84 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package netem
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var sizeExp = regexp.MustCompile(`^[0-9]+([kmg]i?)?(b|bit)?$`)
+
+// defaultBurst is the size of the bucket of the token bucket filter when none is given
+const defaultBurst = "32kbit"
+
+// tbfLatency is the longest a packet may wait in the token bucket filter before it is dropped
+const tbfLatency = "400ms"
+
+// Ingress is the emulation of the traffic which a device receives. A qdisc can only shape the traffic
+// which is sent, so the traffic is redirected to an ifb device and shaped as it leaves that instead.
+type Ingress struct {
+	Netem
+	// Bandwidth limits the rate with a token bucket filter beneath the netem, such as 10mbit. Unlike
+	// the rate of netem, the traffic over the limit is dropped rather than queued.
+	Bandwidth string `json:"bandwidth,omitempty"`
+	// Burst is the size of the bucket of the token bucket filter, defaults to 32kbit
+	Burst string `json:"burst,omitempty"`
+}
+
+// Validate checks that the parameters are within range, and are a combination which tc accepts
+func (in Ingress) Validate() error {
+	err := in.Netem.Validate()
+	if err != nil {
+		return err
+	}
+	if len(in.Bandwidth) > 0 && !rateExp.MatchString(strings.ToLower(in.Bandwidth)) {
+		return fmt.Errorf("invalid bandwidth \"%s\"", in.Bandwidth)
+	}
+	if len(in.Burst) > 0 {
+		if len(in.Bandwidth) == 0 {
+			return fmt.Errorf("burst requires a bandwidth")
+		}
+		if !sizeExp.MatchString(strings.ToLower(in.Burst)) {
+			return fmt.Errorf("invalid burst \"%s\"", in.Burst)
+		}
+	}
+	return nil
+}
+
+// IngressCommands gives the commands which redirect the traffic received by the device to the ifb
+// device, and shape it there. The ifb device must not exist yet, and the device must not have an
+// ingress qdisc.
+func IngressCommands(device string, ifb string, in Ingress) []string {
+	out := []string{
+		fmt.Sprintf("ip link add %s type ifb", ifb),
+		fmt.Sprintf("ip link set dev %s up", ifb),
+		fmt.Sprintf("tc qdisc add dev %s handle ffff: ingress", device),
+		fmt.Sprintf("tc filter add dev %s parent ffff: protocol all u32 match u32 0 0 "+
+			"action mirred egress redirect dev %s", device, ifb),
+		strings.TrimSpace(fmt.Sprintf("tc qdisc add dev %s root handle 1: netem %s",
+			ifb, strings.Join(in.Args(), " "))),
+	}
+	if len(in.Bandwidth) > 0 {
+		burst := in.Burst
+		if len(burst) == 0 {
+			burst = defaultBurst
+		}
+		out = append(out, fmt.Sprintf("tc qdisc add dev %s parent 1:1 handle 10: tbf rate %s burst %s latency %s",
+			ifb, in.Bandwidth, burst, tbfLatency))
+	}
+	return out
+}
+
+// ClearIngressCommands gives the commands which undo IngressCommands
+func ClearIngressCommands(device string, ifb string) []string {
+	return []string{
+		fmt.Sprintf("tc qdisc del dev %s ingress", device),
+		fmt.Sprintf("ip link del %s", ifb),
+	}
+}
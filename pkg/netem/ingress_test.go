@@ -0,0 +1,47 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package netem
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIngressCommands(t *testing.T) {
+	in := Ingress{Netem: Netem{Delay: 20000}, Bandwidth: "5mbit"}
+	assert.NoError(t, in.Validate())
+	assert.Equal(t, []string{
+		"ip link add ifb0 type ifb",
+		"ip link set dev ifb0 up",
+		"tc qdisc add dev eth0 handle ffff: ingress",
+		"tc filter add dev eth0 parent ffff: protocol all u32 match u32 0 0 action mirred egress redirect dev ifb0",
+		"tc qdisc add dev ifb0 root handle 1: netem delay 20000us",
+		"tc qdisc add dev ifb0 parent 1:1 handle 10: tbf rate 5mbit burst 32kbit latency 400ms",
+	}, IngressCommands("eth0", "ifb0", in))
+
+	cmds := IngressCommands("eth0", "ifb0", Ingress{Netem: Netem{Loss: 1}})
+	assert.Len(t, cmds, 5)
+	assert.Equal(t, "tc qdisc add dev ifb0 root handle 1: netem loss random 1%", cmds[4])
+
+	assert.Equal(t, []string{"tc qdisc del dev eth0 ingress", "ip link del ifb0"},
+		ClearIngressCommands("eth0", "ifb0"))
+}
+
+func TestIngress_Validate(t *testing.T) {
+	assert.NoError(t, Ingress{Bandwidth: "1mbit", Burst: "64kb"}.Validate())
+
+	invalid := []Ingress{
+		{Netem: Netem{Loss: 101}},
+		{Bandwidth: "fast"},
+		{Burst: "32kbit"},
+		{Bandwidth: "1mbit", Burst: "32kbit; reboot"},
+	}
+	for _, in := range invalid {
+		assert.Error(t, in.Validate(), in)
+	}
+}
@@ -0,0 +1,67 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package netem
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// linkRate is the rate of the htb classes, high enough to never be the limit, so that only netem shapes the traffic
+const linkRate = "10gbit"
+
+// maxLinks is the most links which fit into the class ids of a single htb qdisc
+const maxLinks = 0xfff0
+
+// Link is the emulation of the traffic which goes to a single destination
+type Link struct {
+	// Destination is the IP address which the traffic goes to
+	Destination string
+	Netem
+}
+
+// LinkCommands gives the tc commands which give the traffic from the device to each destination its own netem,
+// while leaving the rest of the traffic alone. An htb qdisc becomes the root of the device, with a class and
+// netem qdisc for each link, and a u32 filter on the destination address to pick the class. The device is
+// expected to have the default root qdisc.
+func LinkCommands(device string, links []Link) ([]string, error) {
+	if len(links) > maxLinks {
+		return nil, fmt.Errorf("too many links, there can be at most %d", maxLinks)
+	}
+	seen := map[string]bool{}
+	out := []string{
+		fmt.Sprintf("tc qdisc add dev %s root handle 1: htb default 1", device),
+		fmt.Sprintf("tc class add dev %s parent 1: classid 1:1 htb rate %s", device, linkRate),
+	}
+	for i, link := range links {
+		ip := net.ParseIP(link.Destination)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid destination \"%s\"", link.Destination)
+		}
+		if seen[ip.String()] {
+			return nil, fmt.Errorf("more than one link goes to %s", ip)
+		}
+		seen[ip.String()] = true
+		err := link.Validate()
+		if err != nil {
+			return nil, fmt.Errorf("link to %s: %v", ip, err)
+		}
+
+		match := fmt.Sprintf("protocol ip prio 1 u32 match ip dst %s/32", ip)
+		if ip.To4() == nil {
+			match = fmt.Sprintf("protocol ipv6 prio 2 u32 match ip6 dst %s/128", ip)
+		}
+		id := i + 2
+		out = append(out,
+			fmt.Sprintf("tc class add dev %s parent 1: classid 1:%x htb rate %s", device, id, linkRate),
+			strings.TrimSpace(fmt.Sprintf("tc qdisc add dev %s parent 1:%x handle %x: netem %s",
+				device, id, id, strings.Join(link.Args(), " "))),
+			fmt.Sprintf("tc filter add dev %s parent 1: %s flowid 1:%x", device, match, id))
+	}
+	return out, nil
+}
@@ -0,0 +1,44 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package netem
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkCommands(t *testing.T) {
+	cmds, err := LinkCommands("eth0", []Link{
+		{Destination: "10.0.0.2", Netem: Netem{Delay: 200000}},
+		{Destination: "fd00::3", Netem: Netem{Loss: 1}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"tc qdisc add dev eth0 root handle 1: htb default 1",
+		"tc class add dev eth0 parent 1: classid 1:1 htb rate 10gbit",
+		"tc class add dev eth0 parent 1: classid 1:2 htb rate 10gbit",
+		"tc qdisc add dev eth0 parent 1:2 handle 2: netem delay 200000us",
+		"tc filter add dev eth0 parent 1: protocol ip prio 1 u32 match ip dst 10.0.0.2/32 flowid 1:2",
+		"tc class add dev eth0 parent 1: classid 1:3 htb rate 10gbit",
+		"tc qdisc add dev eth0 parent 1:3 handle 3: netem loss random 1%",
+		"tc filter add dev eth0 parent 1: protocol ipv6 prio 2 u32 match ip6 dst fd00::3/128 flowid 1:3",
+	}, cmds)
+}
+
+func TestLinkCommands_Invalid(t *testing.T) {
+	invalid := [][]Link{
+		{{Destination: "node1"}},
+		{{Destination: "10.0.0.2"}, {Destination: "10.0.0.2", Netem: Netem{Delay: 1}}},
+		{{Destination: "10.0.0.2", Netem: Netem{Loss: -1}}},
+	}
+	for _, links := range invalid {
+		_, err := LinkCommands("eth0", links)
+		assert.Error(t, err, links)
+	}
+}
@@ -0,0 +1,405 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package netem
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The distributions which the delay may follow, these are the tables shipped with iproute2
+const (
+	DistributionUniform      = "uniform"
+	DistributionNormal       = "normal"
+	DistributionPareto       = "pareto"
+	DistributionParetoNormal = "paretonormal"
+)
+
+var rateExp = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?([kmgt]i?)?(bit|bps)?$`)
+
+// LossState is the 4-state Markov loss model. The probabilities are percentages, and
+// any of them after the first may be left as zero to use the default of tc.
+type LossState struct {
+	// P13 is the probability of going from the good state to the burst loss state
+	P13 float64 `json:"p13"`
+	// P31 is the probability of going from the burst loss state back to the good state
+	P31 float64 `json:"p31,omitempty"`
+	// P32 is the probability of going from the burst loss state to the good burst state
+	P32 float64 `json:"p32,omitempty"`
+	// P23 is the probability of going from the good burst state to the burst loss state
+	P23 float64 `json:"p23,omitempty"`
+	// P14 is the probability of an isolated loss
+	P14 float64 `json:"p14,omitempty"`
+}
+
+// GEModel is the Gilbert-Elliot loss model. The probabilities are percentages, and
+// any of them after the first may be left as zero to use the default of tc.
+type GEModel struct {
+	// P is the probability of going from the good state to the bad state
+	P float64 `json:"p"`
+	// R is the probability of going from the bad state to the good state
+	R float64 `json:"r,omitempty"`
+	// H is the loss probability in the bad state, 1-h in tc
+	H float64 `json:"h,omitempty"`
+	// K is the loss probability in the good state, 1-k in tc
+	K float64 `json:"k,omitempty"`
+}
+
+// Slot holds packets back and releases them in bursts, emulating slotted links such as wifi
+type Slot struct {
+	// Min is the minimum delay between bursts in microseconds
+	Min int `json:"min"`
+	// Max is the maximum delay between bursts in microseconds, defaults to Min
+	Max int `json:"max,omitempty"`
+	// Packets is the most packets to release in a burst
+	Packets int `json:"packets,omitempty"`
+	// Bytes is the most bytes to release in a burst
+	Bytes int `json:"bytes,omitempty"`
+}
+
+// Netem is the configuration of the netem queueing discipline. The fields from limit to reorder
+// match those of command.Netconf, so that its payloads can be used as is.
+type Netem struct {
+	// Limit is the max number of packets to hold the in queue
+	Limit int `json:"limit,omitempty"`
+	// Loss is the percentage of packets to drop at random
+	Loss float64 `json:"loss,omitempty"`
+	// Delay is the latency to be applied in microseconds
+	Delay int `json:"delay,omitempty"`
+	// Rate is the bandwidth constraint, such as 10mbit
+	Rate string `json:"rate,omitempty"`
+	// Duplication is the percentage of packets to duplicate
+	Duplication float64 `json:"duplicate,omitempty"`
+	// Corrupt is the percentage of packets to corrupt
+	Corrupt float64 `json:"corrupt,omitempty"`
+	// Reorder is the percentage of packets to send immediately, ahead of the delayed ones
+	Reorder float64 `json:"reorder,omitempty"`
+
+	// Jitter is the random variation of the delay in microseconds
+	Jitter int `json:"jitter,omitempty"`
+	// DelayCorrelation is the percentage by which the delay of a packet depends on the previous one
+	DelayCorrelation float64 `json:"delayCorrelation,omitempty"`
+	// Distribution is the distribution of the jitter, one of uniform, normal, pareto or paretonormal
+	Distribution string `json:"distribution,omitempty"`
+
+	// LossCorrelation is the percentage by which the loss of a packet depends on the previous one
+	LossCorrelation float64 `json:"lossCorrelation,omitempty"`
+	// LossState drops packets according to a 4-state Markov model instead of at random
+	LossState *LossState `json:"lossState,omitempty"`
+	// LossGEModel drops packets according to the Gilbert-Elliot model instead of at random
+	LossGEModel *GEModel `json:"lossGEModel,omitempty"`
+	// ECN marks packets as congested rather than dropping them
+	ECN bool `json:"ecn,omitempty"`
+
+	// DuplicateCorrelation is the percentage by which the duplication of a packet depends on the previous one
+	DuplicateCorrelation float64 `json:"duplicateCorrelation,omitempty"`
+	// CorruptCorrelation is the percentage by which the corruption of a packet depends on the previous one
+	CorruptCorrelation float64 `json:"corruptCorrelation,omitempty"`
+	// ReorderCorrelation is the percentage by which the reordering of a packet depends on the previous one
+	ReorderCorrelation float64 `json:"reorderCorrelation,omitempty"`
+	// Gap only reorders every Nth packet
+	Gap int `json:"gap,omitempty"`
+
+	// PacketOverhead is the bytes added to or, when negative, removed from each packet for the rate
+	PacketOverhead int `json:"packetOverhead,omitempty"`
+	// CellSize is the size of the cells of link layers such as ATM, for the rate
+	CellSize int `json:"cellSize,omitempty"`
+	// CellOverhead is the bytes added to each cell, for the rate
+	CellOverhead int `json:"cellOverhead,omitempty"`
+
+	// Slot releases packets in bursts
+	Slot *Slot `json:"slot,omitempty"`
+}
+
+func checkPercent(name string, value float64) error {
+	if value < 0 || value > 100 {
+		return fmt.Errorf("%s must be a percentage between 0 and 100, got %v", name, value)
+	}
+	return nil
+}
+
+func checkPercents(percents map[string]float64) error {
+	for name, value := range percents {
+		if err := checkPercent(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkNonNegative(values map[string]int) error {
+	for name, value := range values {
+		if value < 0 {
+			return fmt.Errorf("%s must not be negative, got %d", name, value)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the parameters are within range, and are a combination which tc accepts
+func (n Netem) Validate() error {
+	warnings, err := n.CheckNetconf()
+	if err != nil {
+		return err
+	}
+	if len(warnings) > 0 {
+		return errors.New(warnings[0])
+	}
+	return nil
+}
+
+// CheckNetconf checks the parameters the way that the payloads of command.Netconf are checked. The
+// parameters which go beyond command.Netconf must be valid. The ones which it shares were never
+// checked before, and tc accepts or ignores the problems with them, so they only give warnings.
+func (n Netem) CheckNetconf() (warnings []string, err error) {
+	for _, problem := range []error{
+		checkPercents(map[string]float64{
+			"loss":      n.Loss,
+			"duplicate": n.Duplication,
+			"corrupt":   n.Corrupt,
+			"reorder":   n.Reorder,
+		}),
+		checkNonNegative(map[string]int{"limit": n.Limit, "delay": n.Delay}),
+	} {
+		if problem != nil {
+			warnings = append(warnings, problem.Error())
+		}
+	}
+	if n.Reorder > 0 && n.Delay == 0 {
+		warnings = append(warnings, "reorder requires a delay, tc ignores it without one")
+	}
+	if len(n.Rate) > 0 && !rateExp.MatchString(strings.ToLower(n.Rate)) {
+		warnings = append(warnings, fmt.Sprintf("invalid rate \"%s\"", n.Rate))
+	}
+	return warnings, n.validateExtensions()
+}
+
+// validateExtensions checks the parameters which go beyond command.Netconf
+func (n Netem) validateExtensions() error {
+	err := checkPercents(map[string]float64{
+		"delayCorrelation":     n.DelayCorrelation,
+		"lossCorrelation":      n.LossCorrelation,
+		"duplicateCorrelation": n.DuplicateCorrelation,
+		"corruptCorrelation":   n.CorruptCorrelation,
+		"reorderCorrelation":   n.ReorderCorrelation,
+	})
+	if err != nil {
+		return err
+	}
+	err = checkNonNegative(map[string]int{
+		"jitter":       n.Jitter,
+		"gap":          n.Gap,
+		"cellSize":     n.CellSize,
+		"cellOverhead": n.CellOverhead,
+	})
+	if err != nil {
+		return err
+	}
+
+	if n.Jitter > 0 && n.Delay == 0 {
+		return fmt.Errorf("jitter requires a delay")
+	}
+	if n.DelayCorrelation > 0 && n.Jitter == 0 {
+		return fmt.Errorf("delayCorrelation requires a jitter")
+	}
+	switch n.Distribution {
+	case "":
+	case DistributionUniform, DistributionNormal, DistributionPareto, DistributionParetoNormal:
+		if n.Jitter == 0 {
+			return fmt.Errorf("distribution requires a jitter")
+		}
+	default:
+		return fmt.Errorf("unknown distribution \"%s\"", n.Distribution)
+	}
+
+	models := 0
+	if n.Loss > 0 {
+		models++
+	}
+	if n.LossState != nil {
+		models++
+		err = checkPercents(map[string]float64{"lossState.p13": n.LossState.P13,
+			"lossState.p31": n.LossState.P31, "lossState.p32": n.LossState.P32,
+			"lossState.p23": n.LossState.P23, "lossState.p14": n.LossState.P14})
+		if err != nil {
+			return err
+		}
+	}
+	if n.LossGEModel != nil {
+		models++
+		err = checkPercents(map[string]float64{"lossGEModel.p": n.LossGEModel.P,
+			"lossGEModel.r": n.LossGEModel.R, "lossGEModel.h": n.LossGEModel.H,
+			"lossGEModel.k": n.LossGEModel.K})
+		if err != nil {
+			return err
+		}
+	}
+	if models > 1 {
+		return fmt.Errorf("only one of loss, lossState and lossGEModel may be given")
+	}
+	if n.LossCorrelation > 0 && n.Loss == 0 {
+		return fmt.Errorf("lossCorrelation requires a loss")
+	}
+	if n.ECN && models == 0 {
+		return fmt.Errorf("ecn requires a loss")
+	}
+
+	if n.DuplicateCorrelation > 0 && n.Duplication == 0 {
+		return fmt.Errorf("duplicateCorrelation requires a duplicate")
+	}
+	if n.CorruptCorrelation > 0 && n.Corrupt == 0 {
+		return fmt.Errorf("corruptCorrelation requires a corrupt")
+	}
+	if (n.ReorderCorrelation > 0 || n.Gap > 0) && n.Reorder == 0 {
+		return fmt.Errorf("reorderCorrelation and gap require a reorder")
+	}
+
+	if len(n.Rate) == 0 && (n.PacketOverhead != 0 || n.CellSize > 0 || n.CellOverhead > 0) {
+		return fmt.Errorf("packetOverhead, cellSize and cellOverhead require a rate")
+	}
+
+	if n.Slot != nil {
+		if n.Slot.Min <= 0 {
+			return fmt.Errorf("slot.min must be positive, got %d", n.Slot.Min)
+		}
+		if n.Slot.Max != 0 && n.Slot.Max < n.Slot.Min {
+			return fmt.Errorf("slot.max must not be less than slot.min")
+		}
+		err = checkNonNegative(map[string]int{"slot.packets": n.Slot.Packets, "slot.bytes": n.Slot.Bytes})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quoteRate quotes a rate which is not valid, which is only warned about, so that it cannot break
+// out of the command. tc still gets to reject it.
+func quoteRate(rate string) string {
+	if rateExp.MatchString(strings.ToLower(rate)) {
+		return rate
+	}
+	return "'" + strings.Replace(rate, "'", `'\''`, -1) + "'"
+}
+
+func percent(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64) + "%"
+}
+
+func usec(value int) string {
+	return strconv.Itoa(value) + "us"
+}
+
+// trailing gives the percentages up to and including the last one which is set, so
+// that tc uses its defaults for the rest
+func trailing(values ...float64) []string {
+	end := 1
+	for i := range values {
+		if values[i] != 0 {
+			end = i + 1
+		}
+	}
+	out := make([]string, end)
+	for i := range out {
+		out[i] = percent(values[i])
+	}
+	return out
+}
+
+// Args gives the arguments for netem, in the order of the tc grammar
+func (n Netem) Args() []string {
+	out := []string{}
+	if n.Limit > 0 {
+		out = append(out, "limit", strconv.Itoa(n.Limit))
+	}
+	if n.Delay > 0 {
+		out = append(out, "delay", usec(n.Delay))
+		if n.Jitter > 0 {
+			out = append(out, usec(n.Jitter))
+			if n.DelayCorrelation > 0 {
+				out = append(out, percent(n.DelayCorrelation))
+			}
+		}
+		if len(n.Distribution) > 0 {
+			out = append(out, "distribution", n.Distribution)
+		}
+	}
+	if n.Corrupt > 0 {
+		out = append(out, "corrupt", percent(n.Corrupt))
+		if n.CorruptCorrelation > 0 {
+			out = append(out, percent(n.CorruptCorrelation))
+		}
+	}
+	if n.Duplication > 0 {
+		out = append(out, "duplicate", percent(n.Duplication))
+		if n.DuplicateCorrelation > 0 {
+			out = append(out, percent(n.DuplicateCorrelation))
+		}
+	}
+	switch {
+	case n.Loss > 0:
+		out = append(out, "loss", "random", percent(n.Loss))
+		if n.LossCorrelation > 0 {
+			out = append(out, percent(n.LossCorrelation))
+		}
+	case n.LossState != nil:
+		out = append(out, "loss", "state")
+		out = append(out, trailing(n.LossState.P13, n.LossState.P31, n.LossState.P32,
+			n.LossState.P23, n.LossState.P14)...)
+	case n.LossGEModel != nil:
+		out = append(out, "loss", "gemodel")
+		out = append(out, trailing(n.LossGEModel.P, n.LossGEModel.R,
+			n.LossGEModel.H, n.LossGEModel.K)...)
+	}
+	if n.ECN {
+		out = append(out, "ecn")
+	}
+	if n.Reorder > 0 {
+		out = append(out, "reorder", percent(n.Reorder))
+		if n.ReorderCorrelation > 0 {
+			out = append(out, percent(n.ReorderCorrelation))
+		}
+		if n.Gap > 0 {
+			out = append(out, "gap", strconv.Itoa(n.Gap))
+		}
+	}
+	if len(n.Rate) > 0 {
+		out = append(out, "rate", quoteRate(n.Rate))
+		if n.PacketOverhead != 0 || n.CellSize > 0 || n.CellOverhead > 0 {
+			out = append(out, strconv.Itoa(n.PacketOverhead))
+		}
+		if n.CellSize > 0 || n.CellOverhead > 0 {
+			out = append(out, strconv.Itoa(n.CellSize))
+		}
+		if n.CellOverhead > 0 {
+			out = append(out, strconv.Itoa(n.CellOverhead))
+		}
+	}
+	if n.Slot != nil {
+		out = append(out, "slot", usec(n.Slot.Min))
+		if n.Slot.Max > 0 {
+			out = append(out, usec(n.Slot.Max))
+		}
+		if n.Slot.Packets > 0 {
+			out = append(out, "packets", strconv.Itoa(n.Slot.Packets))
+		}
+		if n.Slot.Bytes > 0 {
+			out = append(out, "bytes", strconv.Itoa(n.Slot.Bytes))
+		}
+	}
+	return out
+}
+
+// Command gives the tc command which performs the action, such as add, with the netem qdisc as the
+// root of the device
+func (n Netem) Command(action string, device string) string {
+	return strings.TrimSpace(fmt.Sprintf("tc qdisc %s dev %s root netem %s", action, device,
+		strings.Join(n.Args(), " ")))
+}
@@ -0,0 +1,154 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package netem
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/whiteblock/definition/command"
+)
+
+func TestNetem_Args(t *testing.T) {
+	tests := []struct {
+		netem    Netem
+		expected string
+	}{
+		{
+			netem:    Netem{},
+			expected: "",
+		},
+		{
+			netem: Netem{Limit: 1000, Loss: 0.5, Delay: 100000, Rate: "10mbit",
+				Duplication: 1, Corrupt: 0.1, Reorder: 25},
+			expected: "limit 1000 delay 100000us corrupt 0.1% duplicate 1% loss random 0.5% " +
+				"reorder 25% rate 10mbit",
+		},
+		{
+			netem: Netem{Delay: 100000, Jitter: 10000, DelayCorrelation: 25,
+				Distribution: DistributionNormal},
+			expected: "delay 100000us 10000us 25% distribution normal",
+		},
+		{
+			netem:    Netem{Loss: 1, LossCorrelation: 30, ECN: true},
+			expected: "loss random 1% 30% ecn",
+		},
+		{
+			netem:    Netem{LossState: &LossState{P13: 1, P31: 50}},
+			expected: "loss state 1% 50%",
+		},
+		{
+			netem:    Netem{LossGEModel: &GEModel{P: 5, R: 0, H: 100}},
+			expected: "loss gemodel 5% 0% 100%",
+		},
+		{
+			netem:    Netem{Delay: 10, Reorder: 25, ReorderCorrelation: 50, Gap: 5},
+			expected: "delay 10us reorder 25% 50% gap 5",
+		},
+		{
+			netem:    Netem{Rate: "1mbit", CellSize: 53},
+			expected: "rate 1mbit 0 53",
+		},
+		{
+			netem:    Netem{Slot: &Slot{Min: 800, Max: 1000, Packets: 32, Bytes: 64000}},
+			expected: "slot 800us 1000us packets 32 bytes 64000",
+		},
+	}
+	for _, test := range tests {
+		assert.NoError(t, test.netem.Validate())
+		assert.Equal(t, test.expected, strings.Join(test.netem.Args(), " "))
+	}
+}
+
+func TestNetem_Validate(t *testing.T) {
+	invalid := []Netem{
+		{Loss: 101},
+		{Duplication: -1},
+		{Limit: -1},
+		{Jitter: 10},
+		{Delay: 10, DelayCorrelation: 10},
+		{Delay: 10, Jitter: 10, Distribution: "gaussian"},
+		{Delay: 10, Distribution: DistributionPareto},
+		{Loss: 1, LossState: &LossState{P13: 1}},
+		{LossGEModel: &GEModel{P: 200}},
+		{LossCorrelation: 5},
+		{ECN: true},
+		{CorruptCorrelation: 5},
+		{DuplicateCorrelation: 5},
+		{Reorder: 25},
+		{Delay: 10, Gap: 5},
+		{Rate: "fast"},
+		{Rate: "1mbit; reboot"},
+		{PacketOverhead: 10},
+		{Slot: &Slot{}},
+		{Slot: &Slot{Min: 10, Max: 5}},
+	}
+	for _, netem := range invalid {
+		assert.Error(t, netem.Validate(), netem)
+	}
+}
+
+func TestNetem_CheckNetconf(t *testing.T) {
+	for _, netem := range []Netem{
+		{Loss: 101},
+		{Duplication: -1},
+		{Limit: -1},
+		{Reorder: 25},
+		{Rate: "fast"},
+	} {
+		warnings, err := netem.CheckNetconf()
+		assert.NoError(t, err, netem)
+		assert.Len(t, warnings, 1, netem)
+	}
+
+	warnings, err := Netem{Delay: 10, Reorder: 25}.CheckNetconf()
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+
+	_, err = Netem{Reorder: 25, Gap: 5}.CheckNetconf()
+	assert.NoError(t, err)
+	_, err = Netem{Jitter: 10}.CheckNetconf()
+	assert.Error(t, err)
+
+	// an invalid rate is passed on to tc, but cannot break out of the command
+	assert.Equal(t, []string{"rate", `'1mbit; reboot'`}, Netem{Rate: "1mbit; reboot"}.Args())
+}
+
+func TestNetem_Command(t *testing.T) {
+	netem := Netem{Delay: 100, Loss: 1}
+	assert.Equal(t, "tc qdisc add dev eth0 root netem delay 100us loss random 1%",
+		netem.Command("add", "eth0"))
+	assert.Equal(t, "tc qdisc del dev eth0 root netem", Netem{}.Command("del", "eth0"))
+}
+
+func TestNetem_Netconf(t *testing.T) {
+	data, err := json.Marshal(command.Netconf{
+		Limit:       4,
+		Loss:        2,
+		Delay:       4,
+		Rate:        "1mbit",
+		Duplication: 3,
+		Corrupt:     5,
+	})
+	require.NoError(t, err)
+
+	var netem struct {
+		Container string `json:"container"`
+		Network   string `json:"network"`
+		Netem
+	}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.DisallowUnknownFields()
+	require.NoError(t, dec.Decode(&netem))
+	assert.Equal(t, Netem{Limit: 4, Loss: 2, Delay: 4, Rate: "1mbit", Duplication: 3, Corrupt: 5},
+		netem.Netem)
+	assert.Contains(t, netem.Args(), "corrupt")
+	assert.Equal(t, "5%", netem.Args()[5])
+}
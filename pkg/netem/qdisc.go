@@ -0,0 +1,98 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package netem
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	qdiscExp   = regexp.MustCompile(`^qdisc (\S+) (\S+) (?:dev \S+ )?(root|parent (\S+))?\s*(.*)$`)
+	sentExp    = regexp.MustCompile(`Sent (\d+) bytes (\d+) pkt \(dropped (\d+), overlimits (\d+) requeues (\d+)\)`)
+	backlogExp = regexp.MustCompile(`backlog (\d+)\S* (\d+)p`)
+)
+
+// Qdisc is a queueing discipline of a device, along with its counters, as reported by tc
+type Qdisc struct {
+	// Kind is the type of the qdisc, such as netem
+	Kind string `json:"kind"`
+	// Handle is the handle of the qdisc, such as 8001:
+	Handle string `json:"handle"`
+	// Root is true if it is the root qdisc of the device
+	Root bool `json:"root,omitempty"`
+	// Parent is the class which the qdisc belongs to, if it is not the root
+	Parent string `json:"parent,omitempty"`
+	// Options are the parameters of the qdisc. When tc is unable to output JSON, they are
+	// only available as the text which tc printed, under "raw".
+	Options map[string]interface{} `json:"options,omitempty"`
+	// Bytes is how many bytes have been sent through the qdisc
+	Bytes uint64 `json:"bytes"`
+	// Packets is how many packets have been sent through the qdisc
+	Packets uint64 `json:"packets"`
+	// Drops is how many packets the qdisc has dropped
+	Drops uint64 `json:"drops"`
+	// Overlimits is how many times the qdisc has held packets back for being over its limits
+	Overlimits uint64 `json:"overlimits"`
+	// Requeues is how many packets have been requeued
+	Requeues uint64 `json:"requeues"`
+	// Backlog is how many bytes are waiting in the queue
+	Backlog uint64 `json:"backlog"`
+	// Qlen is how many packets are waiting in the queue
+	Qlen uint64 `json:"qlen"`
+}
+
+// ParseQdiscs parses the output of tc -s qdisc show, either with -j or without it for versions of
+// tc which can not output JSON
+func ParseQdiscs(out string) ([]Qdisc, error) {
+	out = strings.TrimSpace(out)
+	if len(out) == 0 {
+		return []Qdisc{}, nil
+	}
+	if strings.HasPrefix(out, "[") {
+		var qdiscs []Qdisc
+		err := json.Unmarshal([]byte(out), &qdiscs)
+		return qdiscs, err
+	}
+
+	qdiscs := []Qdisc{}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "qdisc ") {
+			match := qdiscExp.FindStringSubmatch(line)
+			if match == nil {
+				return nil, fmt.Errorf("unable to parse the qdisc \"%s\"", line)
+			}
+			qdiscs = append(qdiscs, Qdisc{
+				Kind:    match[1],
+				Handle:  match[2],
+				Root:    match[3] == "root",
+				Parent:  match[4],
+				Options: map[string]interface{}{"raw": match[5]},
+			})
+			continue
+		}
+		if len(qdiscs) == 0 {
+			continue
+		}
+		last := &qdiscs[len(qdiscs)-1]
+		if match := sentExp.FindStringSubmatch(line); match != nil {
+			last.Bytes, _ = strconv.ParseUint(match[1], 10, 64)
+			last.Packets, _ = strconv.ParseUint(match[2], 10, 64)
+			last.Drops, _ = strconv.ParseUint(match[3], 10, 64)
+			last.Overlimits, _ = strconv.ParseUint(match[4], 10, 64)
+			last.Requeues, _ = strconv.ParseUint(match[5], 10, 64)
+		} else if match := backlogExp.FindStringSubmatch(line); match != nil {
+			last.Backlog, _ = strconv.ParseUint(match[1], 10, 64)
+			last.Qlen, _ = strconv.ParseUint(match[2], 10, 64)
+		}
+	}
+	return qdiscs, nil
+}
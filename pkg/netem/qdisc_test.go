@@ -0,0 +1,57 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package netem
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQdiscs_JSON(t *testing.T) {
+	qdiscs, err := ParseQdiscs(`[{"kind":"netem","handle":"8001:","root":true,"refcnt":2,` +
+		`"options":{"limit":1000,"delay":{"delay":0.1,"jitter":0,"correlation":0},"ecn":false,"gap":0},` +
+		`"bytes":1514,"packets":12,"drops":3,"overlimits":1,"requeues":0,"backlog":0,"qlen":0}]`)
+	require.NoError(t, err)
+	require.Len(t, qdiscs, 1)
+	assert.Equal(t, "netem", qdiscs[0].Kind)
+	assert.Equal(t, "8001:", qdiscs[0].Handle)
+	assert.True(t, qdiscs[0].Root)
+	assert.Equal(t, float64(1000), qdiscs[0].Options["limit"])
+	assert.Equal(t, uint64(1514), qdiscs[0].Bytes)
+	assert.Equal(t, uint64(12), qdiscs[0].Packets)
+	assert.Equal(t, uint64(3), qdiscs[0].Drops)
+	assert.Equal(t, uint64(1), qdiscs[0].Overlimits)
+}
+
+func TestParseQdiscs_Text(t *testing.T) {
+	qdiscs, err := ParseQdiscs("qdisc netem 1: root refcnt 2 limit 1000 delay 20.0ms\n" +
+		" Sent 4000 bytes 40 pkt (dropped 2, overlimits 5 requeues 1)\n" +
+		" backlog 1514b 1p requeues 1\n" +
+		"qdisc tbf 10: parent 1:1 rate 5Mbit burst 4Kb lat 400.0ms\n" +
+		" Sent 0 bytes 0 pkt (dropped 0, overlimits 0 requeues 0)\n")
+	require.NoError(t, err)
+	assert.Equal(t, []Qdisc{
+		{
+			Kind: "netem", Handle: "1:", Root: true,
+			Options: map[string]interface{}{"raw": "refcnt 2 limit 1000 delay 20.0ms"},
+			Bytes:   4000, Packets: 40, Drops: 2, Overlimits: 5, Requeues: 1, Backlog: 1514, Qlen: 1,
+		},
+		{
+			Kind: "tbf", Handle: "10:", Parent: "1:1",
+			Options: map[string]interface{}{"raw": "rate 5Mbit burst 4Kb lat 400.0ms"},
+		},
+	}, qdiscs)
+
+	qdiscs, err = ParseQdiscs("")
+	require.NoError(t, err)
+	assert.Empty(t, qdiscs)
+
+	_, err = ParseQdiscs("[{")
+	assert.Error(t, err)
+}
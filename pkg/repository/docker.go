@@ -7,8 +7,10 @@
 package repository
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"strings"
@@ -20,6 +22,7 @@ import (
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/tlsconfig"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -47,6 +50,11 @@ type DockerRepository interface {
 
 	//Exec is sort of like docker exec
 	Exec(ctx context.Context, cli entity.Client, containerName string, details entity.Exec) error
+
+	//ExecOutput is like docker exec, except that it waits for the command to finish and
+	//captures what it outputs along with its exit code
+	ExecOutput(ctx context.Context, cli entity.Client, containerName string,
+		details entity.Exec) (entity.ExecOutput, error)
 }
 
 type dockerRepository struct {
@@ -178,45 +186,115 @@ func (da dockerRepository) GetContainerByName(ctx context.Context, cli entity.Cl
 	return types.Container{}, fmt.Errorf("could not find the container \"%s\"", containerName)
 }
 
-func (da dockerRepository) exec(ctx context.Context, cli entity.Client,
-	containerName string, details entity.Exec) error {
+const (
+	//minExecPollInterval is how long to wait before first checking on an exec again
+	minExecPollInterval = 10 * time.Millisecond
+	//maxExecPollInterval is the longest to ever wait between checks on an exec
+	maxExecPollInterval = time.Second
+)
 
-	da.log.WithFields(logrus.Fields{
-		"command": strings.Join(details.Cmd, " "),
-	}).Debug("executing a command")
+//waitForExec polls the exec with exponential backoff until it is no longer running,
+//returning its exit code
+func (da dockerRepository) waitForExec(ctx context.Context, cli entity.Client, id string) (int, error) {
+	interval := minExecPollInterval
+	for {
+		res, err := cli.ContainerExecInspect(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		if !res.Running {
+			return res.ExitCode, nil
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(interval):
+		}
+		interval *= 2
+		if interval > maxExecPollInterval {
+			interval = maxExecPollInterval
+		}
+	}
+}
+
+//runExec runs the command in the container, copying what it outputs into stdout and stderr.
+//Completion is detected by the end of the attached output stream, and when attaching is not possible,
+//by polling the exec instead. As attaching may fail after the daemon has started the exec, it is only
+//started again when it has not been.
+func (da dockerRepository) runExec(ctx context.Context, cli entity.Client, containerName string,
+	details entity.Exec, stdout, stderr io.Writer) (int, error) {
+
+	if details.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, details.Timeout)
+		defer cancel()
+	}
 	idRes, err := cli.ContainerExecCreate(ctx, containerName, types.ExecConfig{
-		User:         "",
+		User:         details.User,
 		Privileged:   details.Privileged,
-		Tty:          false,
-		AttachStdin:  false,
-		AttachStderr: false,
-		AttachStdout: false,
-		Detach:       true,
-		DetachKeys:   "",
-		Env:          nil,
-		WorkingDir:   "",
+		AttachStderr: true,
+		AttachStdout: true,
+		Env:          details.Env,
+		WorkingDir:   details.WorkingDir,
 		Cmd:          details.Cmd,
 	})
 	if err != nil {
-		return err
+		return 0, err
 	}
-	err = cli.ContainerExecStart(ctx, idRes.ID, types.ExecStartCheck{})
+
+	resp, err := cli.ContainerExecAttach(ctx, idRes.ID, types.ExecStartCheck{})
 	if err != nil {
-		return err
-	}
-	for {
-		res, err := cli.ContainerExecInspect(ctx, idRes.ID)
+		da.log.WithFields(logrus.Fields{
+			"container": containerName,
+			"error":     err,
+		}).Debug("unable to attach to the exec, falling back to polling")
+		inspect, err := cli.ContainerExecInspect(ctx, idRes.ID)
 		if err != nil {
-			return err
+			return 0, err
 		}
-		if !res.Running {
-			if res.ExitCode != 0 {
-				return fmt.Errorf(`command "%s" exited with exit code %d`, strings.Join(details.Cmd, " "), res.ExitCode)
+		if !inspect.Running && inspect.Pid == 0 {
+			err = cli.ContainerExecStart(ctx, idRes.ID, types.ExecStartCheck{Detach: true})
+			if err != nil {
+				return 0, err
 			}
-			break
 		}
+		return da.waitForExec(ctx, cli, idRes.ID)
+	}
+	defer resp.Close()
+
+	copyErr := make(chan error, 1)
+	go func() {
+		_, err := stdcopy.StdCopy(stdout, stderr, resp.Reader)
+		copyErr <- err
+	}()
+	select {
+	case <-ctx.Done():
+		resp.Close() // unblocks the copy
+		return 0, ctx.Err()
+	case err = <-copyErr:
 	}
+	if err != nil {
+		return 0, err
+	}
+	return da.waitForExec(ctx, cli, idRes.ID)
+}
+
+func (da dockerRepository) exec(ctx context.Context, cli entity.Client,
+	containerName string, details entity.Exec) error {
+
+	da.log.WithFields(logrus.Fields{
+		"command": strings.Join(details.Cmd, " "),
+	}).Debug("executing a command")
 
+	var stderr bytes.Buffer
+	exitCode, err := da.runExec(ctx, cli, containerName, details, ioutil.Discard, &stderr)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf(`command "%s" exited with exit code %d: %s`, strings.Join(details.Cmd, " "),
+			exitCode, strings.TrimSpace(stderr.String()))
+	}
 	return nil
 }
 
@@ -231,8 +309,10 @@ func (da dockerRepository) Exec(ctx context.Context, cli entity.Client,
 	}
 
 	for i := 0; i < details.Retries; i++ {
-		if details.Delay != 0 {
-			time.Sleep(details.Delay)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(details.Delay):
 		}
 		da.log.WithFields(logrus.Fields{
 			"command": details.Cmd,
@@ -245,3 +325,25 @@ func (da dockerRepository) Exec(ctx context.Context, cli entity.Client,
 	}
 	return err
 }
+
+//ExecOutput is like docker exec, except that it waits for the command to finish and
+//captures what it outputs along with its exit code
+func (da dockerRepository) ExecOutput(ctx context.Context, cli entity.Client,
+	containerName string, details entity.Exec) (entity.ExecOutput, error) {
+
+	da.log.WithFields(logrus.Fields{
+		"container": containerName,
+		"command":   strings.Join(details.Cmd, " "),
+	}).Debug("executing a command and capturing its output")
+
+	var stdout, stderr bytes.Buffer
+	exitCode, err := da.runExec(ctx, cli, containerName, details, &stdout, &stderr)
+	if err != nil {
+		return entity.ExecOutput{}, err
+	}
+	return entity.ExecOutput{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: exitCode,
+	}, nil
+}
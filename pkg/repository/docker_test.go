@@ -7,14 +7,21 @@
 package repository
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"strings"
 	"testing"
+	"time"
 
 	entityMock "github.com/whiteblock/genesis/mocks/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/entity"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -205,3 +212,132 @@ func TestDockerRepository_GetContainerByName_Failure(t *testing.T) {
 
 	cli.AssertExpectations(t)
 }
+
+func TestDockerRepository_ExecOutput(t *testing.T) {
+	var output bytes.Buffer
+	_, err := stdcopy.NewStdWriter(&output, stdcopy.Stdout).Write([]byte("out"))
+	require.NoError(t, err)
+	_, err = stdcopy.NewStdWriter(&output, stdcopy.Stderr).Write([]byte("err"))
+	require.NoError(t, err)
+	conn, _ := net.Pipe()
+
+	cli := new(entityMock.Client)
+	cli.On("ContainerExecCreate", mock.Anything, "test", mock.Anything).Return(
+		types.IDResponse{ID: "exec"}, nil).Run(func(args mock.Arguments) {
+		conf := args.Get(2).(types.ExecConfig)
+		assert.Equal(t, []string{"ls"}, conf.Cmd)
+		assert.Equal(t, []string{"FOO=bar"}, conf.Env)
+		assert.Equal(t, "root", conf.User)
+		assert.Equal(t, "/tmp", conf.WorkingDir)
+		assert.True(t, conf.AttachStdout)
+		assert.True(t, conf.AttachStderr)
+	}).Once()
+	cli.On("ContainerExecAttach", mock.Anything, "exec", mock.Anything).Return(
+		types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(&output)}, nil).Once()
+	cli.On("ContainerExecInspect", mock.Anything, "exec").Return(
+		types.ContainerExecInspect{Running: true}, nil).Once()
+	cli.On("ContainerExecInspect", mock.Anything, "exec").Return(
+		types.ContainerExecInspect{ExitCode: 2}, nil).Once()
+
+	repo := NewDockerRepository(logrus.New())
+	out, err := repo.ExecOutput(context.Background(), cli, "test", entity.Exec{
+		Cmd:        []string{"ls"},
+		Env:        []string{"FOO=bar"},
+		User:       "root",
+		WorkingDir: "/tmp",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, entity.ExecOutput{Stdout: "out", Stderr: "err", ExitCode: 2}, out)
+
+	cli.AssertExpectations(t)
+}
+
+func TestDockerRepository_Exec_Attached(t *testing.T) {
+	var output bytes.Buffer
+	_, err := stdcopy.NewStdWriter(&output, stdcopy.Stderr).Write([]byte("bad things\n"))
+	require.NoError(t, err)
+	conn, _ := net.Pipe()
+
+	cli := new(entityMock.Client)
+	cli.On("ContainerExecCreate", mock.Anything, "test", mock.Anything).Return(
+		types.IDResponse{ID: "exec"}, nil).Once()
+	cli.On("ContainerExecAttach", mock.Anything, "exec", mock.Anything).Return(
+		types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(&output)}, nil).Once()
+	cli.On("ContainerExecInspect", mock.Anything, "exec").Return(
+		types.ContainerExecInspect{ExitCode: 1}, nil).Once()
+
+	repo := NewDockerRepository(logrus.New())
+	err = repo.Exec(context.Background(), cli, "test", entity.Exec{Cmd: []string{"false"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exit code 1")
+	assert.Contains(t, err.Error(), "bad things")
+
+	cli.AssertExpectations(t)
+}
+
+func TestDockerRepository_Exec_Polling(t *testing.T) {
+	cli := new(entityMock.Client)
+	cli.On("ContainerExecCreate", mock.Anything, "test", mock.Anything).Return(
+		types.IDResponse{ID: "exec"}, nil).Once()
+	cli.On("ContainerExecAttach", mock.Anything, "exec", mock.Anything).Return(
+		types.HijackedResponse{}, fmt.Errorf("hijacking is not supported")).Once()
+	cli.On("ContainerExecInspect", mock.Anything, "exec").Return(
+		types.ContainerExecInspect{}, nil).Once()
+	cli.On("ContainerExecStart", mock.Anything, "exec", mock.Anything).Return(nil).Run(
+		func(args mock.Arguments) {
+			assert.True(t, args.Get(2).(types.ExecStartCheck).Detach)
+		}).Once()
+	cli.On("ContainerExecInspect", mock.Anything, "exec").Return(
+		types.ContainerExecInspect{Running: true}, nil).Times(3)
+	cli.On("ContainerExecInspect", mock.Anything, "exec").Return(
+		types.ContainerExecInspect{}, nil).Once()
+
+	repo := NewDockerRepository(logrus.New())
+	start := time.Now()
+	err := repo.Exec(context.Background(), cli, "test", entity.Exec{Cmd: []string{"true"}})
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= minExecPollInterval*7) // 10ms + 20ms + 40ms
+
+	cli.AssertExpectations(t)
+}
+
+func TestDockerRepository_Exec_AttachFailedAfterStart(t *testing.T) {
+	cli := new(entityMock.Client)
+	cli.On("ContainerExecCreate", mock.Anything, "test", mock.Anything).Return(
+		types.IDResponse{ID: "exec"}, nil).Once()
+	cli.On("ContainerExecAttach", mock.Anything, "exec", mock.Anything).Return(
+		types.HijackedResponse{}, fmt.Errorf("i/o timeout")).Once()
+	// the daemon already started the exec, so it must not be started again
+	cli.On("ContainerExecInspect", mock.Anything, "exec").Return(
+		types.ContainerExecInspect{Running: true, Pid: 42}, nil).Twice()
+	cli.On("ContainerExecInspect", mock.Anything, "exec").Return(
+		types.ContainerExecInspect{Pid: 42, ExitCode: 3}, nil).Once()
+
+	repo := NewDockerRepository(logrus.New())
+	err := repo.Exec(context.Background(), cli, "test", entity.Exec{Cmd: []string{"rm", "-r", "/data"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exit code 3")
+
+	cli.AssertExpectations(t)
+	cli.AssertNotCalled(t, "ContainerExecStart", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDockerRepository_Exec_Timeout(t *testing.T) {
+	conn, other := net.Pipe()
+	defer other.Close()
+
+	cli := new(entityMock.Client)
+	cli.On("ContainerExecCreate", mock.Anything, "test", mock.Anything).Return(
+		types.IDResponse{ID: "exec"}, nil).Once()
+	cli.On("ContainerExecAttach", mock.Anything, "exec", mock.Anything).Return(
+		types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(conn)}, nil).Once()
+
+	repo := NewDockerRepository(logrus.New())
+	err := repo.Exec(context.Background(), cli, "test", entity.Exec{
+		Cmd:     []string{"sleep", "infinity"},
+		Timeout: 50 * time.Millisecond,
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	cli.AssertExpectations(t)
+}
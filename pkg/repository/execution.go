@@ -0,0 +1,202 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/sirupsen/logrus"
+)
+
+const executionFileExt = ".json"
+
+// ErrExecutionNotFound is returned when there is no execution with the given id
+var ErrExecutionNotFound = fmt.Errorf("execution not found")
+
+// ExecutionRepository stores the state of executions, so that they can be resumed
+type ExecutionRepository interface {
+	// Put stores the given execution, replacing any existing execution with the same id
+	Put(exec entity.Execution) error
+
+	// Get fetches the execution with the given id
+	Get(id string) (entity.Execution, error)
+
+	// List fetches all of the stored executions, ordered by their creation time
+	List() ([]entity.Execution, error)
+
+	// Delete removes the execution with the given id
+	Delete(id string) error
+}
+
+type fileExecutionRepository struct {
+	dir string
+	mu  *sync.Mutex
+	log logrus.Ext1FieldLogger
+}
+
+// NewFileExecutionRepository creates a new ExecutionRepository which keeps each execution
+// as a JSON file inside of the given directory. The directory is only created once an execution
+// is stored, and only its owner may read it, since the executions contain the credentials of their tests.
+func NewFileExecutionRepository(dir string, log logrus.Ext1FieldLogger) ExecutionRepository {
+	return &fileExecutionRepository{dir: dir, mu: &sync.Mutex{}, log: log}
+}
+
+func (fer fileExecutionRepository) path(id string) string {
+	return filepath.Join(fer.dir, filepath.Base(id)+executionFileExt)
+}
+
+func (fer fileExecutionRepository) read(path string) (out entity.Execution, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &out)
+	return
+}
+
+// Put stores the given execution, replacing any existing execution with the same id
+func (fer fileExecutionRepository) Put(exec entity.Execution) error {
+	data, err := json.Marshal(exec)
+	if err != nil {
+		return err
+	}
+	fer.mu.Lock()
+	defer fer.mu.Unlock()
+	err = os.MkdirAll(fer.dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so a crash mid-write cannot corrupt the stored state
+	tmp := fer.path(exec.ID) + ".tmp"
+	os.Remove(tmp) // a leftover file would keep its permissions
+	err = ioutil.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, fer.path(exec.ID))
+}
+
+// Get fetches the execution with the given id
+func (fer fileExecutionRepository) Get(id string) (entity.Execution, error) {
+	fer.mu.Lock()
+	defer fer.mu.Unlock()
+	out, err := fer.read(fer.path(id))
+	if os.IsNotExist(err) {
+		return out, ErrExecutionNotFound
+	}
+	return out, err
+}
+
+// List fetches all of the stored executions, ordered by their creation time
+func (fer fileExecutionRepository) List() ([]entity.Execution, error) {
+	fer.mu.Lock()
+	defer fer.mu.Unlock()
+	files, err := ioutil.ReadDir(fer.dir)
+	if os.IsNotExist(err) {
+		return []entity.Execution{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := []entity.Execution{}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), executionFileExt) {
+			continue
+		}
+		exec, err := fer.read(filepath.Join(fer.dir, file.Name()))
+		if err != nil {
+			fer.log.WithFields(logrus.Fields{
+				"file":  file.Name(),
+				"error": err,
+			}).Warn("skipping an unreadable execution")
+			continue
+		}
+		out = append(out, exec)
+	}
+	sortExecutions(out)
+	return out, nil
+}
+
+// Delete removes the execution with the given id
+func (fer fileExecutionRepository) Delete(id string) error {
+	fer.mu.Lock()
+	defer fer.mu.Unlock()
+	err := os.Remove(fer.path(id))
+	if os.IsNotExist(err) {
+		return ErrExecutionNotFound
+	}
+	return err
+}
+
+type memoryExecutionRepository struct {
+	execs map[string]entity.Execution
+	mu    *sync.Mutex
+}
+
+// NewMemoryExecutionRepository creates a new ExecutionRepository which does not
+// persist anything. Executions stored in it will not survive a restart.
+func NewMemoryExecutionRepository() ExecutionRepository {
+	return &memoryExecutionRepository{execs: map[string]entity.Execution{}, mu: &sync.Mutex{}}
+}
+
+// Put stores the given execution, replacing any existing execution with the same id
+func (mer memoryExecutionRepository) Put(exec entity.Execution) error {
+	mer.mu.Lock()
+	defer mer.mu.Unlock()
+	mer.execs[exec.ID] = exec
+	return nil
+}
+
+// Get fetches the execution with the given id
+func (mer memoryExecutionRepository) Get(id string) (entity.Execution, error) {
+	mer.mu.Lock()
+	defer mer.mu.Unlock()
+	out, exists := mer.execs[id]
+	if !exists {
+		return out, ErrExecutionNotFound
+	}
+	return out, nil
+}
+
+// List fetches all of the stored executions, ordered by their creation time
+func (mer memoryExecutionRepository) List() ([]entity.Execution, error) {
+	mer.mu.Lock()
+	defer mer.mu.Unlock()
+	out := make([]entity.Execution, 0, len(mer.execs))
+	for _, exec := range mer.execs {
+		out = append(out, exec)
+	}
+	sortExecutions(out)
+	return out, nil
+}
+
+// Delete removes the execution with the given id
+func (mer memoryExecutionRepository) Delete(id string) error {
+	mer.mu.Lock()
+	defer mer.mu.Unlock()
+	if _, exists := mer.execs[id]; !exists {
+		return ErrExecutionNotFound
+	}
+	delete(mer.execs, id)
+	return nil
+}
+
+func sortExecutions(execs []entity.Execution) {
+	sort.Slice(execs, func(i, j int) bool {
+		return execs[i].Created.Before(execs[j].Created)
+	})
+}
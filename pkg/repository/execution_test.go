@@ -0,0 +1,95 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package repository
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/whiteblock/definition/command"
+)
+
+func testExecutionRepository(t *testing.T, repo ExecutionRepository) {
+	first := entity.NewExecution("1", command.Instructions{ID: "test1", Commands: [][]command.Command{{
+		command.Command{ID: "TEST", Order: command.Order{Type: command.Createcontainer}},
+	}}})
+	second := entity.NewExecution("2", command.Instructions{ID: "test2"})
+	second.Created = first.Created.Add(time.Second)
+	res := entity.NewErrorResult("err")
+	second.LastResult = &res
+	second.Retries = 2
+
+	require.NoError(t, repo.Put(second))
+	require.NoError(t, repo.Put(first))
+
+	exec, err := repo.Get("1")
+	require.NoError(t, err)
+	assert.Equal(t, "test1", exec.Instructions.ID)
+	require.Len(t, exec.Instructions.Commands, 1)
+	assert.Equal(t, "TEST", exec.Instructions.Commands[0][0].ID)
+	assert.True(t, exec.IsRunning())
+
+	execs, err := repo.List()
+	require.NoError(t, err)
+	require.Len(t, execs, 2)
+	assert.Equal(t, "1", execs[0].ID)
+	assert.Equal(t, "2", execs[1].ID)
+	require.NotNil(t, execs[1].LastResult)
+	assert.EqualError(t, execs[1].LastResult.Error, "err")
+	assert.Equal(t, 2, execs[1].Retries)
+
+	second.State = entity.ExecutionFinished
+	require.NoError(t, repo.Put(second))
+	exec, err = repo.Get("2")
+	require.NoError(t, err)
+	assert.False(t, exec.IsRunning())
+
+	require.NoError(t, repo.Delete("1"))
+	_, err = repo.Get("1")
+	assert.Equal(t, ErrExecutionNotFound, err)
+	assert.Equal(t, ErrExecutionNotFound, repo.Delete("1"))
+}
+
+func TestFileExecutionRepository(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genesis")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the directory is created once something is stored
+	dir = filepath.Join(dir, "state")
+	repo := NewFileExecutionRepository(dir, logrus.New())
+	execs, err := repo.List()
+	require.NoError(t, err)
+	assert.Empty(t, execs)
+	testExecutionRepository(t, repo)
+
+	// the executions contain the credentials of their tests
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+	info, err = os.Stat(filepath.Join(dir, "2"+executionFileExt))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	reopened := NewFileExecutionRepository(dir, logrus.New())
+	execs, err = reopened.List()
+	require.NoError(t, err)
+	require.Len(t, execs, 1)
+	assert.Equal(t, "2", execs[0].ID)
+}
+
+func TestMemoryExecutionRepository(t *testing.T) {
+	testExecutionRepository(t, NewMemoryExecutionRepository())
+}
@@ -0,0 +1,219 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package repository
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/sirupsen/logrus"
+)
+
+const statsFileExt = ".jsonl"
+
+// ErrStatsNotFound is returned when no samples have been stored for the test
+var ErrStatsNotFound = errors.New("stats not found")
+
+// ErrInvalidStatsName is returned when the name of a test or container would escape the stats directory
+var ErrInvalidStatsName = errors.New("invalid test or container name")
+
+// StatsRepository stores the resource usage samples of the containers of each test
+type StatsRepository interface {
+	// Append adds the samples to the time series of their containers
+	Append(testID string, samples []entity.StatsSample) error
+
+	// Get fetches the samples of the given containers of the test, or of all of them when
+	// none are given, ordered by their time
+	Get(testID string, containers ...string) ([]entity.StatsSample, error)
+
+	// Containers lists the names of the containers of the test which have samples
+	Containers(testID string) ([]string, error)
+
+	// Delete removes all of the samples of the test
+	Delete(testID string) error
+}
+
+type fileStatsRepository struct {
+	dir string
+	mu  *sync.Mutex
+	log logrus.Ext1FieldLogger
+}
+
+// NewFileStatsRepository creates a new StatsRepository which keeps the samples of each container
+// as JSON lines in a file, inside of a directory for each test. The directories are only created once
+// samples are stored.
+func NewFileStatsRepository(dir string, log logrus.Ext1FieldLogger) StatsRepository {
+	return &fileStatsRepository{dir: dir, mu: &sync.Mutex{}, log: log}
+}
+
+func validStatsName(name string) bool {
+	return len(name) > 0 && name != "." && name != ".." && !strings.ContainsAny(name, "/\\")
+}
+
+func (fsr fileStatsRepository) testDir(testID string) (string, error) {
+	if !validStatsName(testID) {
+		return "", ErrInvalidStatsName
+	}
+	return filepath.Join(fsr.dir, testID), nil
+}
+
+func (fsr fileStatsRepository) path(testID string, container string) (string, error) {
+	dir, err := fsr.testDir(testID)
+	if err != nil {
+		return "", err
+	}
+	if !validStatsName(container) {
+		return "", ErrInvalidStatsName
+	}
+	return filepath.Join(dir, container+statsFileExt), nil
+}
+
+func (fsr fileStatsRepository) read(path string) ([]entity.StatsSample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	out := []entity.StatsSample{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var sample entity.StatsSample
+		err = json.Unmarshal(scanner.Bytes(), &sample)
+		if err != nil {
+			// a crash mid-write can leave a partial line at the end, which is not worth failing over
+			fsr.log.WithFields(logrus.Fields{"file": path, "error": err}).Warn("skipping an unreadable sample")
+			continue
+		}
+		out = append(out, sample)
+	}
+	return out, scanner.Err()
+}
+
+// Append adds the samples to the time series of their containers
+func (fsr fileStatsRepository) Append(testID string, samples []entity.StatsSample) error {
+	byContainer := map[string][]entity.StatsSample{}
+	for _, sample := range samples {
+		byContainer[sample.Container] = append(byContainer[sample.Container], sample)
+	}
+
+	fsr.mu.Lock()
+	defer fsr.mu.Unlock()
+	for container, samples := range byContainer {
+		path, err := fsr.path(testID, container)
+		if err != nil {
+			return err
+		}
+		// the samples are only ever read back by genesis itself
+		err = os.MkdirAll(filepath.Dir(path), 0700)
+		if err != nil {
+			return err
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		for _, sample := range samples {
+			err = enc.Encode(sample)
+			if err != nil {
+				break
+			}
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get fetches the samples of the given containers of the test, or of all of them when none are given
+func (fsr fileStatsRepository) Get(testID string, containers ...string) ([]entity.StatsSample, error) {
+	if len(containers) == 0 {
+		var err error
+		containers, err = fsr.Containers(testID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fsr.mu.Lock()
+	defer fsr.mu.Unlock()
+	out := []entity.StatsSample{}
+	for _, container := range containers {
+		path, err := fsr.path(testID, container)
+		if err != nil {
+			return nil, err
+		}
+		samples, err := fsr.read(path)
+		if os.IsNotExist(err) {
+			return nil, ErrStatsNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, samples...)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Time.Equal(out[j].Time) {
+			return out[i].Container < out[j].Container
+		}
+		return out[i].Time.Before(out[j].Time)
+	})
+	return out, nil
+}
+
+// Containers lists the names of the containers of the test which have samples
+func (fsr fileStatsRepository) Containers(testID string) ([]string, error) {
+	dir, err := fsr.testDir(testID)
+	if err != nil {
+		return nil, err
+	}
+	fsr.mu.Lock()
+	defer fsr.mu.Unlock()
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, ErrStatsNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := []string{}
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), statsFileExt) {
+			out = append(out, strings.TrimSuffix(file.Name(), statsFileExt))
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// Delete removes all of the samples of the test
+func (fsr fileStatsRepository) Delete(testID string) error {
+	dir, err := fsr.testDir(testID)
+	if err != nil {
+		return err
+	}
+	fsr.mu.Lock()
+	defer fsr.mu.Unlock()
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return ErrStatsNotFound
+	}
+	return os.RemoveAll(dir)
+}
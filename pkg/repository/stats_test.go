@@ -0,0 +1,102 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package repository
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStatsRepository(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genesis")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the directory is created once samples are stored
+	dir = filepath.Join(dir, "stats")
+	repo := NewFileStatsRepository(dir, logrus.New())
+
+	_, err = repo.Get("test")
+	assert.Equal(t, ErrStatsNotFound, err)
+
+	start := time.Now().UTC()
+	require.NoError(t, repo.Append("test", []entity.StatsSample{
+		{Time: start, Container: "node1", CPUPercent: 10},
+		{Time: start, Container: "node0", CPUPercent: 20},
+	}))
+	require.NoError(t, repo.Append("test", []entity.StatsSample{
+		{Time: start.Add(time.Second), Container: "node0", CPUPercent: 30, MemoryUsage: 1024},
+	}))
+
+	containers, err := repo.Containers("test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node0", "node1"}, containers)
+
+	info, err := os.Stat(filepath.Join(dir, "test"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+	info, err = os.Stat(filepath.Join(dir, "test", "node0.jsonl"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	samples, err := repo.Get("test")
+	require.NoError(t, err)
+	require.Len(t, samples, 3)
+	assert.Equal(t, "node0", samples[0].Container)
+	assert.Equal(t, "node1", samples[1].Container)
+	assert.Equal(t, float64(30), samples[2].CPUPercent)
+	assert.Equal(t, uint64(1024), samples[2].MemoryUsage)
+	assert.True(t, start.Add(time.Second).Equal(samples[2].Time))
+
+	samples, err = repo.Get("test", "node1")
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, float64(10), samples[0].CPUPercent)
+
+	_, err = repo.Get("test", "node2")
+	assert.Equal(t, ErrStatsNotFound, err)
+
+	// a partial line left by a crash is skipped
+	f, err := os.OpenFile(filepath.Join(dir, "test", "node1.jsonl"), os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"time":`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	samples, err = repo.Get("test", "node1")
+	require.NoError(t, err)
+	assert.Len(t, samples, 1)
+
+	require.NoError(t, repo.Delete("test"))
+	_, err = repo.Containers("test")
+	assert.Equal(t, ErrStatsNotFound, err)
+	assert.Equal(t, ErrStatsNotFound, repo.Delete("test"))
+}
+
+func TestFileStatsRepository_InvalidNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genesis")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	repo := NewFileStatsRepository(filepath.Join(dir, "stats"), logrus.New())
+
+	assert.Equal(t, ErrInvalidStatsName, repo.Append("..", []entity.StatsSample{{Container: "node0"}}))
+	assert.Equal(t, ErrInvalidStatsName, repo.Append("test", []entity.StatsSample{{Container: "../node0"}}))
+	_, err = repo.Get("test", "a/b")
+	assert.Equal(t, ErrInvalidStatsName, err)
+	_, err = repo.Containers("")
+	assert.Equal(t, ErrInvalidStatsName, err)
+	assert.Equal(t, ErrInvalidStatsName, repo.Delete("."))
+}
@@ -0,0 +1,330 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/sirupsen/logrus"
+	"github.com/whiteblock/definition/command"
+)
+
+// The states of a chaos policy which are reported in its events
+const (
+	// ChaosInjected means that a fault was injected into the containers
+	ChaosInjected = "injected"
+	// ChaosRecovered means that the containers were recovered from a fault
+	ChaosRecovered = "recovered"
+	// ChaosFailed means that a fault could not be injected or recovered from
+	ChaosFailed = "failed"
+	// ChaosSkipped means that there were not enough matching containers to inject a fault into
+	ChaosSkipped = "skipped"
+	// ChaosFinished means that every round of the policy has been run
+	ChaosFinished = "finished"
+	// ChaosCancelled means that the policy was stopped before it finished
+	ChaosCancelled = "cancelled"
+)
+
+// ChaosService runs chaos policies in the background
+type ChaosService interface {
+	// Start begins injecting faults into the containers of the test, in the background. A policy
+	// of the test with the same name is replaced. Each fault is published as an event.
+	Start(cli entity.DockerCli, host string, c entity.Chaos) entity.Result
+
+	// Cancel stops all of the policies of the test, returning the names of the ones which were running
+	Cancel(testID string) []string
+}
+
+type chaosService struct {
+	service DockerService
+	events  EventService
+	log     logrus.Ext1FieldLogger
+	jobs    jobRegistry
+}
+
+// NewChaosService creates a new ChaosService
+func NewChaosService(
+	service DockerService,
+	events EventService,
+	log logrus.Ext1FieldLogger) ChaosService {
+
+	return &chaosService{
+		service: service,
+		events:  events,
+		log:     log,
+		jobs:    newJobRegistry(),
+	}
+}
+
+// chooseVictims picks the containers to inject the fault into. A partition needs at least one
+// container to be left on the other side.
+func chooseVictims(rng *rand.Rand, targets []string, fraction float64, fault string) []string {
+	if len(targets) == 0 {
+		return nil
+	}
+	count := int(math.Round(fraction * float64(len(targets))))
+	if count < 1 {
+		count = 1
+	}
+	if fault == entity.PartitionFault && count >= len(targets) {
+		count = len(targets) - 1
+	}
+	perm := rng.Perm(len(targets))
+	victims := make([]string, 0, count)
+	for _, i := range perm[:count] {
+		victims = append(victims, targets[i])
+	}
+	sort.Strings(victims)
+	return victims
+}
+
+// Start begins injecting faults into the containers of the test, in the background
+func (cs *chaosService) Start(cli entity.DockerCli, host string, c entity.Chaos) entity.Result {
+	name := c.PolicyName()
+	seed := c.Seed
+	if seed == 0 {
+		// keep it within the integers which a JSON number holds exactly, so that it can be reused
+		seed = time.Now().UnixNano() & (1<<53 - 1)
+	}
+	testID := testIDOf(cli)
+	labels := map[string]string{}
+	for key, value := range cli.Labels {
+		labels[key] = value
+	}
+	rng := rand.New(rand.NewSource(seed))
+	replaced := cs.jobs.start(testID, name, func(ctx context.Context) {
+		cs.run(ctx, host, entity.DockerCli{Labels: labels, TestID: testID}, name, c, rng)
+	})
+
+	return entity.NewSuccessResult().InjectMeta(map[string]interface{}{
+		"policy":   name,
+		"seed":     seed,
+		"faults":   c.Faults,
+		"interval": c.Interval.Duration.String(),
+		"duration": c.FaultDuration().String(),
+		"replaced": replaced,
+		"type":     "Chaos",
+	})
+}
+
+// run injects a fault every interval, until every round has been run or the policy is cancelled
+func (cs *chaosService) run(ctx context.Context, host string, cli entity.DockerCli, name string,
+	c entity.Chaos, rng *rand.Rand) {
+
+	log := cs.log.WithFields(logrus.Fields{"test": cli.TestID, "policy": name})
+
+	client, err := cs.service.CreateClient2(host, cli.TestID)
+	if err != nil {
+		log.WithField("error", err).Error("failed to create a client for a chaos policy")
+		res := entity.NewErrorResult(err)
+		cs.publish(cli.TestID, name, ChaosFailed, &res, nil)
+		return
+	}
+	defer client.Close()
+	cli.Client = client
+
+	ticker := time.NewTicker(c.Interval.Duration)
+	defer ticker.Stop()
+	round := 0
+	for ; c.Rounds == 0 || round < c.Rounds; round++ {
+		select {
+		case <-ctx.Done():
+			cs.publish(cli.TestID, name, ChaosCancelled, nil, map[string]interface{}{"rounds": round})
+			return
+		case <-ticker.C:
+		}
+		cs.inject(ctx, log, cli, name, c, rng, round)
+	}
+
+	if ctx.Err() != nil {
+		cs.publish(cli.TestID, name, ChaosCancelled, nil, map[string]interface{}{"rounds": round})
+		return
+	}
+	log.WithField("rounds", round).Info("finished a chaos policy")
+	cs.publish(cli.TestID, name, ChaosFinished, nil, map[string]interface{}{"rounds": round})
+}
+
+// runningContainers gets the names of the running containers of the test which match the selector,
+// other than the sidecars
+func runningContainers(ctx context.Context, cli entity.DockerCli,
+	selector map[string]string) ([]string, error) {
+
+	args := filters.NewArgs(
+		filters.Arg("label", command.TestIDKey+"="+cli.TestID),
+		filters.Arg("status", "running"))
+	for key, value := range selector {
+		args.Add("label", key+"="+value)
+	}
+	cntrs, err := cli.ContainerList(ctx, types.ContainerListOptions{Filters: args})
+	if err != nil {
+		return nil, err
+	}
+	out := []string{}
+	for _, cntr := range cntrs {
+		if _, isSidecar := cntr.Labels[entity.SidecarLabel]; !isSidecar {
+			out = append(out, containerName(cntr))
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// each applies fn to each of the containers, returning the ones it succeeded on along with the
+// first failure
+func each(names []string, fn func(name string) entity.Result) ([]string, entity.Result) {
+	done := []string{}
+	res := entity.NewSuccessResult()
+	for _, name := range names {
+		r := fn(name)
+		if !r.IsSuccess() {
+			if res.IsSuccess() {
+				res = r
+			}
+			continue
+		}
+		done = append(done, name)
+	}
+	return done, res
+}
+
+// inject runs a round of the policy, injecting a random fault into random containers and then
+// recovering from it once the fault is over, or the policy is cancelled
+func (cs *chaosService) inject(ctx context.Context, log logrus.Ext1FieldLogger, cli entity.DockerCli,
+	name string, c entity.Chaos, rng *rand.Rand, round int) {
+
+	data := map[string]interface{}{"round": round}
+	targets, err := runningContainers(ctx, cli, c.Selector)
+	if err != nil {
+		res := entity.NewErrorResult(err)
+		cs.publish(cli.TestID, name, ChaosFailed, &res, data)
+		return
+	}
+	fault := c.Faults[rng.Intn(len(c.Faults))]
+	victims := chooseVictims(rng, targets, c.Fraction, fault)
+	data["fault"] = fault
+	if len(victims) == 0 {
+		cs.publish(cli.TestID, name, ChaosSkipped, nil, data)
+		return
+	}
+
+	var heal func(ctx context.Context) ([]string, entity.Result)
+	var injected []string
+	var res entity.Result
+	switch fault {
+	case entity.KillFault:
+		injected, res = each(victims, func(cntr string) entity.Result {
+			return cs.service.KillContainer(ctx, cli, entity.KillContainer{
+				Name:   cntr,
+				Signal: defaultKillSignal,
+			})
+		})
+		heal = func(ctx context.Context) ([]string, entity.Result) {
+			return each(injected, func(cntr string) entity.Result {
+				return cs.service.StartContainer(ctx, cli, command.StartContainer{Name: cntr})
+			})
+		}
+	case entity.PauseFault:
+		injected, res = each(victims, func(cntr string) entity.Result {
+			return cs.service.PauseContainer(ctx, cli, cntr)
+		})
+		heal = func(ctx context.Context) ([]string, entity.Result) {
+			return each(injected, func(cntr string) entity.Result {
+				return cs.service.UnpauseContainer(ctx, cli, cntr)
+			})
+		}
+	case entity.RestartFault:
+		injected, res = each(victims, func(cntr string) entity.Result {
+			return cs.service.RestartContainer(ctx, cli, entity.RestartContainer{Name: cntr})
+		})
+	case entity.PartitionFault:
+		partition := "chaos." + name
+		isVictim := map[string]bool{}
+		for _, cntr := range victims {
+			isVictim[cntr] = true
+		}
+		others := []string{}
+		for _, cntr := range targets {
+			if !isVictim[cntr] {
+				others = append(others, cntr)
+			}
+		}
+		res = cs.service.PartitionNetwork(ctx, cli, entity.PartitionNetwork{
+			Name:    partition,
+			Network: c.Network,
+			Groups:  [][]string{victims, others},
+		})
+		if res.IsSuccess() {
+			injected = victims
+		}
+		heal = func(ctx context.Context) ([]string, entity.Result) {
+			return injected, cs.service.HealNetwork(ctx, cli, entity.HealNetwork{Name: partition})
+		}
+	}
+
+	data["containers"] = injected
+	state := ChaosInjected
+	if !res.IsSuccess() {
+		state = ChaosFailed
+		data["containers"] = victims
+		log.WithFields(logrus.Fields{"fault": fault, "containers": victims, "error": res.Error}).Warn(
+			"failed to inject a fault")
+	}
+	cs.publish(cli.TestID, name, state, &res, data)
+	if heal == nil || len(injected) == 0 {
+		return
+	}
+
+	timer := time.NewTimer(c.FaultDuration())
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+	case <-timer.C:
+	}
+	// the containers are recovered even when the policy is cancelled, so they are not left faulty
+	recovered, res := heal(context.Background())
+	state = ChaosRecovered
+	if !res.IsSuccess() {
+		state = ChaosFailed
+		log.WithFields(logrus.Fields{"fault": fault, "containers": injected, "error": res.Error}).Warn(
+			"failed to recover from a fault")
+	}
+	cs.publish(cli.TestID, name, state, &res, map[string]interface{}{
+		"round":      round,
+		"fault":      fault,
+		"containers": recovered,
+	})
+}
+
+func (cs *chaosService) publish(testID string, name string, state string, res *entity.Result,
+	data map[string]interface{}) {
+
+	ev := entity.NewEvent(entity.ChaosEvent, testID)
+	ev.Result = res
+	ev.Data = map[string]interface{}{"policy": name, "state": state}
+	for key, value := range data {
+		ev.Data[key] = value
+	}
+	cs.events.Publish(ev)
+}
+
+// Cancel stops all of the policies of the test, and waits for them to stop
+func (cs *chaosService) Cancel(testID string) []string {
+	names := cs.jobs.cancel(testID)
+	if len(names) > 0 {
+		cs.log.WithFields(logrus.Fields{"test": testID, "policies": names}).Info(
+			"cancelled the chaos policies")
+	}
+	return names
+}
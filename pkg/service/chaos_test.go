@@ -0,0 +1,145 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	entityMock "github.com/whiteblock/genesis/mocks/pkg/entity"
+	serviceMock "github.com/whiteblock/genesis/mocks/pkg/service"
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/whiteblock/definition/command"
+)
+
+func chaosClient(names ...string) *entityMock.Client {
+	cntrs := []types.Container{{Names: []string{"/sidecar"}, Labels: map[string]string{entity.SidecarLabel: "true"}}}
+	for _, name := range names {
+		cntrs = append(cntrs, types.Container{Names: []string{"/" + name}})
+	}
+	cli := new(entityMock.Client)
+	cli.On("Close").Return(nil)
+	cli.On("ContainerList", mock.Anything, mock.MatchedBy(func(opts types.ContainerListOptions) bool {
+		return opts.Filters.ExactMatch("label", command.TestIDKey+"=test") &&
+			opts.Filters.ExactMatch("label", "role=validator") &&
+			opts.Filters.ExactMatch("status", "running")
+	})).Return(cntrs, nil)
+	return cli
+}
+
+func TestChooseVictims(t *testing.T) {
+	targets := []string{"node0", "node1", "node2", "node3"}
+	victims := chooseVictims(rand.New(rand.NewSource(7)), targets, 0.5, entity.KillFault)
+	assert.Len(t, victims, 2)
+	assert.Equal(t, victims, chooseVictims(rand.New(rand.NewSource(7)), targets, 0.5, entity.KillFault))
+
+	assert.Len(t, chooseVictims(rand.New(rand.NewSource(1)), targets, 0.01, entity.KillFault), 1)
+	assert.Len(t, chooseVictims(rand.New(rand.NewSource(1)), targets, 1, entity.PauseFault), 4)
+	assert.Len(t, chooseVictims(rand.New(rand.NewSource(1)), targets, 1, entity.PartitionFault), 3)
+	assert.Empty(t, chooseVictims(rand.New(rand.NewSource(1)), targets[:1], 1, entity.PartitionFault))
+	assert.Empty(t, chooseVictims(rand.New(rand.NewSource(1)), nil, 1, entity.KillFault))
+}
+
+func TestChaosService_Start(t *testing.T) {
+	cli := chaosClient("node0", "node1", "node2", "node3")
+	ds := new(serviceMock.DockerService)
+	ds.On("CreateClient2", "127.0.0.1", "test").Return(cli, nil).Once()
+	ds.On("KillContainer", mock.Anything, mock.Anything, mock.MatchedBy(func(kc entity.KillContainer) bool {
+		return kc.Signal == "SIGKILL"
+	})).Return(entity.NewSuccessResult()).Twice()
+	ds.On("StartContainer", mock.Anything, mock.Anything, mock.Anything).Return(
+		entity.NewSuccessResult()).Twice()
+
+	events := NewEventService(logrus.New())
+	sub, unsubscribe := events.Subscribe("test")
+	defer unsubscribe()
+
+	cs := NewChaosService(ds, events, logrus.New())
+	c := entity.Chaos{
+		Selector: map[string]string{"role": "validator"},
+		Faults:   []string{entity.KillFault},
+		Fraction: 0.5,
+		Seed:     42,
+		Rounds:   1,
+	}
+	c.Interval.Duration = 10 * time.Millisecond
+	res := cs.Start(entity.DockerCli{TestID: "test"}, "127.0.0.1", c)
+	require.NoError(t, res.Error)
+	assert.Equal(t, "chaos", res.Meta["policy"])
+	assert.Equal(t, int64(42), res.Meta["seed"])
+	assert.Equal(t, "5ms", res.Meta["duration"])
+
+	// the fault is drawn before the victims, so the same seed always picks the same containers
+	rng := rand.New(rand.NewSource(42))
+	rng.Intn(1)
+	expected := chooseVictims(rng, []string{"node0", "node1", "node2", "node3"}, 0.5, entity.KillFault)
+
+	ev := nextEvent(t, sub, entity.ChaosEvent)
+	assert.Equal(t, ChaosInjected, ev.Data["state"])
+	assert.Equal(t, entity.KillFault, ev.Data["fault"])
+	assert.Equal(t, expected, ev.Data["containers"])
+
+	ev = nextEvent(t, sub, entity.ChaosEvent)
+	assert.Equal(t, ChaosRecovered, ev.Data["state"])
+	assert.Equal(t, expected, ev.Data["containers"])
+
+	ev = nextEvent(t, sub, entity.ChaosEvent)
+	assert.Equal(t, ChaosFinished, ev.Data["state"])
+	assert.Equal(t, 1, ev.Data["rounds"])
+
+	for _, name := range expected {
+		ds.AssertCalled(t, "StartContainer", mock.Anything, mock.Anything, command.StartContainer{Name: name})
+	}
+	assert.Empty(t, cs.Cancel("test"))
+	ds.AssertExpectations(t)
+}
+
+func TestChaosService_Cancel(t *testing.T) {
+	cli := chaosClient("node0", "node1")
+	ds := new(serviceMock.DockerService)
+	ds.On("CreateClient2", mock.Anything, mock.Anything).Return(cli, nil)
+	ds.On("PartitionNetwork", mock.Anything, mock.Anything, mock.MatchedBy(func(pn entity.PartitionNetwork) bool {
+		return pn.Name == "chaos.faults" && pn.Network == "net" && len(pn.Groups) == 2 &&
+			len(pn.Groups[0]) == 1 && len(pn.Groups[1]) == 1
+	})).Return(entity.NewSuccessResult()).Once()
+	ds.On("HealNetwork", mock.Anything, mock.Anything, entity.HealNetwork{Name: "chaos.faults"}).Return(
+		entity.NewSuccessResult()).Once()
+
+	events := NewEventService(logrus.New())
+	sub, unsubscribe := events.Subscribe("test")
+	defer unsubscribe()
+
+	cs := NewChaosService(ds, events, logrus.New())
+	c := entity.Chaos{
+		Name:     "faults",
+		Selector: map[string]string{"role": "validator"},
+		Faults:   []string{entity.PartitionFault},
+		Network:  "net",
+		Fraction: 1,
+	}
+	c.Interval.Duration = 10 * time.Millisecond
+	c.Duration.Duration = time.Hour
+	res := cs.Start(entity.DockerCli{Labels: map[string]string{command.TestIDKey: "test"}}, "127.0.0.1", c)
+	require.NoError(t, res.Error)
+
+	ev := nextEvent(t, sub, entity.ChaosEvent)
+	assert.Equal(t, ChaosInjected, ev.Data["state"])
+
+	assert.Equal(t, []string{"faults"}, cs.Cancel("test"))
+	ev = nextEvent(t, sub, entity.ChaosEvent)
+	assert.Equal(t, ChaosRecovered, ev.Data["state"])
+	ev = nextEvent(t, sub, entity.ChaosEvent)
+	assert.Equal(t, ChaosCancelled, ev.Data["state"])
+	ds.AssertExpectations(t)
+}
@@ -0,0 +1,156 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	entityMock "github.com/whiteblock/genesis/mocks/pkg/entity"
+	repoMock "github.com/whiteblock/genesis/mocks/pkg/repository"
+	"github.com/whiteblock/genesis/pkg/config"
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-units"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/whiteblock/definition/command"
+)
+
+func TestDockerService_CreateContainer_Options(t *testing.T) {
+	hc := &entity.Healthcheck{Test: []string{"CMD-SHELL", "curl -f localhost || exit 1"}, Retries: 3}
+	hc.Interval.Duration = 10 * time.Second
+	cntr := entity.Container{
+		Container: command.Container{
+			Name:   "node0",
+			Image:  "alpine",
+			Cpus:   "1",
+			Memory: "1GB",
+			Args:   []string{"--datadir", "/data"},
+		},
+		Workdir:       "/data",
+		Ulimits:       map[string]entity.Ulimit{"nofile": {Soft: 1024, Hard: 4096}},
+		Sysctls:       map[string]string{"net.core.somaxconn": "1024"},
+		CapAdd:        []string{"NET_ADMIN"},
+		CapDrop:       []string{"MKNOD"},
+		Privileged:    true,
+		Tmpfs:         map[string]string{"/run": "size=64m"},
+		ShmSize:       "256MB",
+		ExtraHosts:    []string{"bootnode:10.0.0.2"},
+		DNS:           []string{"8.8.8.8"},
+		RestartPolicy: "on-failure:3",
+		Healthcheck:   hc,
+	}
+
+	cli := new(entityMock.Client)
+	cli.On("ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, "node0").Return(
+		container.ContainerCreateCreatedBody{}, nil).Run(func(args mock.Arguments) {
+		config := args.Get(1).(*container.Config)
+		assert.Nil(t, config.Entrypoint)
+		assert.Equal(t, []string{"--datadir", "/data"}, []string(config.Cmd))
+		assert.Equal(t, "/data", config.WorkingDir)
+		require.NotNil(t, config.Healthcheck)
+		assert.Equal(t, hc.Test, config.Healthcheck.Test)
+		assert.Equal(t, 10*time.Second, config.Healthcheck.Interval)
+		assert.Equal(t, 3, config.Healthcheck.Retries)
+
+		hostConfig := args.Get(2).(*container.HostConfig)
+		assert.Equal(t, []*units.Ulimit{{Name: "nofile", Soft: 1024, Hard: 4096}}, hostConfig.Ulimits)
+		assert.Equal(t, cntr.Sysctls, hostConfig.Sysctls)
+		assert.Equal(t, []string{"NET_ADMIN"}, []string(hostConfig.CapAdd))
+		assert.Equal(t, []string{"MKNOD"}, []string(hostConfig.CapDrop))
+		assert.True(t, hostConfig.Privileged)
+		assert.Equal(t, cntr.Tmpfs, hostConfig.Tmpfs)
+		assert.Equal(t, int64(256*1024*1024), hostConfig.ShmSize)
+		assert.Equal(t, cntr.ExtraHosts, hostConfig.ExtraHosts)
+		assert.Equal(t, cntr.DNS, hostConfig.DNS)
+		assert.Equal(t, container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3}, hostConfig.RestartPolicy)
+	}).Once()
+
+	repo := new(repoMock.DockerRepository)
+	repo.On("EnsureImagePulled", mock.Anything, mock.Anything, "alpine", mock.Anything).Return(nil).Once()
+
+	ds := NewDockerService(repo, config.Docker{}, nil, logrus.New())
+	res := ds.CreateContainer(nil, entity.DockerCli{Client: cli, Labels: map[string]string{}}, cntr)
+	require.NoError(t, res.Error)
+	cli.AssertExpectations(t)
+	repo.AssertExpectations(t)
+}
+
+func TestDockerService_CreateContainer_Networks(t *testing.T) {
+	cntr := entity.Container{
+		Container: command.Container{
+			Name:    "node0",
+			Image:   "alpine",
+			Cpus:    "1",
+			Memory:  "1GB",
+			Network: "net0",
+			IP:      "10.0.0.2",
+		},
+		Networks: []entity.NetworkAttachment{
+			{Name: "net1", IP: "10.1.0.2", Aliases: []string{"rpc"}, MacAddress: "02:42:0a:01:00:02"},
+			{Name: "net0", Aliases: []string{"node"}, MacAddress: "02:42:0a:00:00:02"},
+			{Name: "net2"},
+		},
+	}
+
+	cli := new(entityMock.Client)
+	cli.On("ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, "node0").Return(
+		container.ContainerCreateCreatedBody{}, nil).Run(func(args mock.Arguments) {
+		assert.Equal(t, "02:42:0a:00:00:02", args.Get(1).(*container.Config).MacAddress)
+		endpoints := args.Get(3).(*network.NetworkingConfig).EndpointsConfig
+		require.Len(t, endpoints, 1)
+		require.Contains(t, endpoints, "net0")
+		assert.Equal(t, "10.0.0.2", endpoints["net0"].IPAMConfig.IPv4Address)
+		assert.Equal(t, []string{"node"}, endpoints["net0"].Aliases)
+	}).Twice()
+	cli.On("NetworkConnect", mock.Anything, "net1", "node0", &network.EndpointSettings{
+		IPAMConfig: &network.EndpointIPAMConfig{IPv4Address: "10.1.0.2"},
+		Aliases:    []string{"rpc"},
+		MacAddress: "02:42:0a:01:00:02",
+	}).Return(nil).Twice()
+	cli.On("NetworkConnect", mock.Anything, "net2", "node0", mock.MatchedBy(func(es *network.EndpointSettings) bool {
+		return len(es.MacAddress) > 0 && es.IPAMConfig.IPv4Address == ""
+	})).Return(nil).Once()
+	cli.On("NetworkConnect", mock.Anything, "net2", "node0", mock.Anything).Return(
+		fmt.Errorf("network net2 not found")).Once()
+	cli.On("ContainerRemove", mock.Anything, "node0", types.ContainerRemoveOptions{Force: true}).Return(nil).Once()
+
+	repo := new(repoMock.DockerRepository)
+	repo.On("EnsureImagePulled", mock.Anything, mock.Anything, "alpine", mock.Anything).Return(nil)
+
+	ds := NewDockerService(repo, config.Docker{}, nil, logrus.New())
+	res := ds.CreateContainer(nil, entity.DockerCli{Client: cli, Labels: map[string]string{}}, cntr)
+	require.NoError(t, res.Error)
+	assert.Equal(t, []string{"net0", "net1", "net2"}, res.Meta["networks"])
+
+	res = ds.CreateContainer(nil, entity.DockerCli{Client: cli, Labels: map[string]string{}}, cntr)
+	assert.Error(t, res.Error)
+	assert.False(t, res.IsFatal())
+	assert.Equal(t, "net2", res.Meta["failedNetwork"])
+	cli.AssertExpectations(t)
+
+	// a container which already existed is left alone, since it may not be one which this order created
+	cli = new(entityMock.Client)
+	cli.On("ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, "node0").Return(
+		container.ContainerCreateCreatedBody{}, fmt.Errorf(`the name "node0" is already in use by container "abc"`)).Once()
+	cli.On("NetworkConnect", mock.Anything, "net1", "node0", mock.Anything).Return(
+		fmt.Errorf("network net1 not found")).Once()
+
+	res = ds.CreateContainer(nil, entity.DockerCli{Client: cli, Labels: map[string]string{}}, cntr)
+	assert.Error(t, res.Error)
+	assert.False(t, res.IsFatal())
+	assert.Equal(t, "net1", res.Meta["failedNetwork"])
+	cli.AssertExpectations(t)
+	cli.AssertNotCalled(t, "ContainerRemove", mock.Anything, mock.Anything, mock.Anything)
+}
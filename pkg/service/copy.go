@@ -0,0 +1,86 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/sirupsen/logrus"
+)
+
+// copyFromContainer stores the tar of the path in the container as an artifact, returning where it was stored
+func (ds dockerService) copyFromContainer(ctx context.Context, cli entity.DockerCli, testID string,
+	container string, path string, artifact string) (string, error) {
+
+	rdr, _, err := cli.CopyFromContainer(ctx, container, path)
+	if err != nil {
+		return "", err
+	}
+	defer rdr.Close()
+	return ds.remote.PutArtifact(testID, artifact, rdr)
+}
+
+// CopyFromContainer copies a path out of the containers of a test and stores a tar of it for
+// each container as an artifact. The containers which the path could not be copied out of
+// are reported in the meta, and it is only an error when it could not be copied out of any.
+func (ds dockerService) CopyFromContainer(ctx context.Context, cli entity.DockerCli,
+	cfc entity.CopyFromContainer) entity.Result {
+
+	testID := testIDOf(cli)
+	if len(testID) == 0 {
+		return entity.NewFatalResult("unable to determine which test to copy the files of")
+	}
+	names := cfc.Containers
+	if len(names) == 0 {
+		var err error
+		names, err = ds.testContainers(ctx, cli, testID, true)
+		if err != nil {
+			return entity.NewErrorResult(err)
+		}
+	}
+	prefix := cfc.Name
+	if len(prefix) == 0 {
+		prefix = fmt.Sprintf("copy-%d", time.Now().Unix())
+	}
+
+	artifacts := map[string]string{}
+	locations := map[string]string{}
+	failed := map[string]string{}
+	for _, name := range names {
+		artifact := fmt.Sprintf("%s-%s.tar", prefix, name)
+		location, err := ds.copyFromContainer(ctx, cli, testID, name, cfc.Path, artifact)
+		if err != nil {
+			ds.withFields(cli, logrus.Fields{"container": name, "path": cfc.Path, "error": err}).Warn(
+				"failed to copy a path out of a container")
+			failed[name] = err.Error()
+			continue
+		}
+		artifacts[name] = artifact
+		locations[name] = location
+	}
+	meta := map[string]interface{}{
+		"path":      cfc.Path,
+		"artifacts": artifacts,
+		"locations": locations,
+		"failed":    failed,
+		"type":      "CopyFromContainer",
+	}
+	if len(artifacts) == 0 {
+		return entity.NewErrorResult(fmt.Errorf("unable to copy %s out of any of the containers", cfc.Path)).
+			InjectMeta(meta)
+	}
+	ds.withFields(cli, logrus.Fields{
+		"path":       cfc.Path,
+		"containers": len(artifacts),
+		"failed":     len(failed),
+	}).Info("copied a path out of the containers of a test")
+	return entity.NewSuccessResult().InjectMeta(meta)
+}
@@ -0,0 +1,83 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	entityMock "github.com/whiteblock/genesis/mocks/pkg/entity"
+	fileMock "github.com/whiteblock/genesis/mocks/pkg/file"
+	"github.com/whiteblock/genesis/pkg/config"
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/whiteblock/definition/command"
+)
+
+func TestDockerService_CopyFromContainer(t *testing.T) {
+	cli := new(entityMock.Client)
+	cli.On("ContainerList", mock.Anything, mock.MatchedBy(func(opts types.ContainerListOptions) bool {
+		return opts.All && opts.Filters.ExactMatch("label", command.TestIDKey+"=test")
+	})).Return([]types.Container{
+		{Names: []string{"/node0"}},
+		{Names: []string{"/node1"}},
+		{Names: []string{"/node0-netem"}, Labels: map[string]string{entity.SidecarLabel: NetemSidecar}},
+	}, nil).Once()
+	cli.On("CopyFromContainer", mock.Anything, "node0", "/data/keys").Return(
+		ioutil.NopCloser(strings.NewReader("tar")), types.ContainerPathStat{Name: "keys"}, nil).Once()
+	cli.On("CopyFromContainer", mock.Anything, "node1", "/data/keys").Return(
+		nil, types.ContainerPathStat{}, fmt.Errorf("no such file")).Once()
+
+	remote := new(fileMock.RemoteSources)
+	remote.On("PutArtifact", "test", "keys-node0.tar", mock.Anything).Return("/keys-node0.tar", nil).Run(
+		func(args mock.Arguments) {
+			data, err := ioutil.ReadAll(args.Get(2).(io.Reader))
+			require.NoError(t, err)
+			assert.Equal(t, "tar", string(data))
+		}).Once()
+
+	ds := NewDockerService(nil, config.Docker{}, remote, logrus.New())
+	res := ds.CopyFromContainer(nil, entity.DockerCli{Client: cli, Labels: map[string]string{
+		command.TestIDKey: "test"}}, entity.CopyFromContainer{Path: "/data/keys", Name: "keys"})
+
+	assert.NoError(t, res.Error)
+	assert.Equal(t, map[string]string{"node0": "keys-node0.tar"}, res.Meta["artifacts"])
+	assert.Equal(t, map[string]string{"node0": "/keys-node0.tar"}, res.Meta["locations"])
+	assert.Equal(t, map[string]string{"node1": "no such file"}, res.Meta["failed"])
+	cli.AssertExpectations(t)
+	remote.AssertExpectations(t)
+}
+
+func TestDockerService_CopyFromContainer_Failure(t *testing.T) {
+	cli := new(entityMock.Client)
+	cli.On("CopyFromContainer", mock.Anything, "node0", "/data").Return(
+		nil, types.ContainerPathStat{}, fmt.Errorf("no such file")).Once()
+
+	remote := new(fileMock.RemoteSources)
+	ds := NewDockerService(nil, config.Docker{}, remote, logrus.New())
+	res := ds.CopyFromContainer(nil, entity.DockerCli{Client: cli, TestID: "test"},
+		entity.CopyFromContainer{Containers: []string{"node0"}, Path: "/data"})
+
+	assert.Error(t, res.Error)
+	assert.False(t, res.IsFatal())
+	assert.Equal(t, map[string]string{"node0": "no such file"}, res.Meta["failed"])
+
+	res = ds.CopyFromContainer(nil, entity.DockerCli{Client: cli},
+		entity.CopyFromContainer{Containers: []string{"node0"}, Path: "/data"})
+	assert.True(t, res.IsFatal())
+
+	cli.AssertExpectations(t)
+	remote.AssertNotCalled(t, "PutArtifact", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -40,7 +40,7 @@ type DockerService interface {
 
 	// CreateContainer attempts to create a docker container
 	CreateContainer(ctx context.Context, cli entity.DockerCli,
-		container command.Container) entity.Result
+		container entity.Container) entity.Result
 
 	// StartContainer attempts to start an already created docker container
 	StartContainer(ctx context.Context, cli entity.DockerCli, sc command.StartContainer) entity.Result
@@ -48,19 +48,72 @@ type DockerService interface {
 	// RemoveContainer attempts to remove (a) container(s)
 	RemoveContainer(ctx context.Context, cli entity.DockerCli, names ...string) entity.Result
 
+	//StopContainer stops a container, killing it if it does not stop in time
+	StopContainer(ctx context.Context, cli entity.DockerCli, sc entity.StopContainer) entity.Result
+
+	//RestartContainer stops a container and then starts it again
+	RestartContainer(ctx context.Context, cli entity.DockerCli, rc entity.RestartContainer) entity.Result
+
+	//KillContainer sends a signal to the main process of a container
+	KillContainer(ctx context.Context, cli entity.DockerCli, kc entity.KillContainer) entity.Result
+
+	//PauseContainer freezes the processes of a container
+	PauseContainer(ctx context.Context, cli entity.DockerCli, name string) entity.Result
+
+	//UnpauseContainer resumes the processes of a paused container
+	UnpauseContainer(ctx context.Context, cli entity.DockerCli, name string) entity.Result
+
+	//UpdateContainerResources changes the resource limits of a running container
+	UpdateContainerResources(ctx context.Context, cli entity.DockerCli,
+		ucr entity.UpdateContainerResources) entity.Result
+
 	// CreateNetwork attempts to create a network
-	CreateNetwork(ctx context.Context, cli entity.DockerCli, net command.Network) entity.Result
+	CreateNetwork(ctx context.Context, cli entity.DockerCli, net entity.Network) entity.Result
 
 	// RemoveNetwork attempts to remove a network
 	RemoveNetwork(ctx context.Context, cli entity.DockerCli, name string) entity.Result
-	AttachNetwork(ctx context.Context, cli entity.DockerCli, cmd command.ContainerNetwork) entity.Result
+	AttachNetwork(ctx context.Context, cli entity.DockerCli, cmd entity.ContainerNetwork) entity.Result
 	DetachNetwork(ctx context.Context, cli entity.DockerCli, network string,
 		container string) entity.Result
 	CreateVolume(ctx context.Context, cli entity.DockerCli, volume command.Volume) entity.Result
 	RemoveVolume(ctx context.Context, cli entity.DockerCli, name string) entity.Result
+
+	// Teardown removes everything which was created for a test from the docker hosts
+	Teardown(ctx context.Context, cli entity.DockerCli, td entity.Teardown) entity.Result
+
+	// CollectLogs archives the logs of the containers of a test and stores the archive as an artifact
+	CollectLogs(ctx context.Context, cli entity.DockerCli, cl entity.CollectLogs) entity.Result
+
+	// CopyFromContainer copies a path out of the containers of a test and stores a tar of it for
+	// each container as an artifact
+	CopyFromContainer(ctx context.Context, cli entity.DockerCli, cfc entity.CopyFromContainer) entity.Result
+
+	//ExecInContainer runs a command in a container, the output and exit code end up in the meta
+	ExecInContainer(ctx context.Context, cli entity.DockerCli, e entity.ExecInContainer) entity.Result
+
+	//WaitForReady waits until the check of a container passes, or it times out
+	WaitForReady(ctx context.Context, cli entity.DockerCli, wr entity.WaitForReady) entity.Result
 	PlaceFileInContainer(ctx context.Context, cli entity.DockerCli,
 		containerName string, file command.File) entity.Result
-	Emulation(ctx context.Context, cli entity.DockerCli, netem command.Netconf) entity.Result
+	Emulation(ctx context.Context, cli entity.DockerCli, emu entity.Emulation) entity.Result
+
+	//UpdateEmulation changes the netem of the interface of a container, adding it when there is none
+	UpdateEmulation(ctx context.Context, cli entity.DockerCli, emu entity.Emulation) entity.Result
+
+	//ClearEmulation removes the emulation from the interface of a container
+	ClearEmulation(ctx context.Context, cli entity.DockerCli, ce entity.ClearEmulation) entity.Result
+
+	//GetEmulation reports the qdiscs of the interface of a container, along with their counters
+	GetEmulation(ctx context.Context, cli entity.DockerCli, ge entity.GetEmulation) entity.Result
+
+	//LinkEmulation applies a separate emulation to the traffic between each of the pairs of containers
+	LinkEmulation(ctx context.Context, cli entity.DockerCli, le entity.LinkEmulation) entity.Result
+
+	//PartitionNetwork stops the groups of containers from reaching each other
+	PartitionNetwork(ctx context.Context, cli entity.DockerCli, pn entity.PartitionNetwork) entity.Result
+
+	//HealNetwork removes the rules which were added by PartitionNetwork
+	HealNetwork(ctx context.Context, cli entity.DockerCli, hn entity.HealNetwork) entity.Result
 	SwarmCluster(ctx context.Context, cli entity.DockerCli, swarm command.SetupSwarm) entity.Result
 	PullImage(ctx context.Context, cli entity.DockerCli, imagePull command.PullImage) entity.Result
 	VolumeShare(ctx context.Context, cli entity.DockerCli, vs command.VolumeShare) entity.Result
@@ -78,6 +131,18 @@ var (
 const (
 	//GlusterContainerName is the name of the gluster container
 	GlusterContainerName = "gluster-container"
+
+	//GlusterSidecar is the kind of the gluster sidecar containers
+	GlusterSidecar = "gluster"
+
+	//NetemSidecar is the kind of the sidecar containers which apply network emulation
+	NetemSidecar = "netem"
+
+	//PartitionSidecar is the kind of the sidecar containers which partition the network
+	PartitionSidecar = "partition"
+
+	//ReadySidecar is the kind of the sidecar containers which check whether a container is ready
+	ReadySidecar = "ready"
 )
 
 type dockerService struct {
@@ -167,7 +232,7 @@ func (ds dockerService) withField(cli entity.DockerCli, key string, value interf
 
 //CreateContainer attempts to create a docker container
 func (ds dockerService) CreateContainer(ctx context.Context, cli entity.DockerCli,
-	dContainer command.Container) entity.Result {
+	dContainer entity.Container) entity.Result {
 
 	ds.withFields(cli, logrus.Fields{"container": dContainer}).Trace("create container")
 	errChan := make(chan error)
@@ -189,7 +254,10 @@ func (ds dockerService) CreateContainer(ctx context.Context, cli entity.DockerCl
 		Env:          dContainer.GetEnv(),
 		Image:        dContainer.Image,
 		Entrypoint:   dContainer.GetEntryPoint(),
+		Cmd:          dContainer.GetCmd(),
+		WorkingDir:   dContainer.Workdir,
 		Labels:       cli.Labels,
+		Healthcheck:  dContainer.GetHealthcheck(),
 	}
 
 	mem, err := dContainer.GetMemory()
@@ -204,6 +272,16 @@ func (ds dockerService) CreateContainer(ctx context.Context, cli entity.DockerCl
 		})
 	}
 
+	shmSize, err := dContainer.GetShmSize()
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+
+	restartPolicy, err := dContainer.GetRestartPolicy()
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+
 	hostConfig := &container.HostConfig{
 		PortBindings: portMap,
 		AutoRemove:   false, //dContainer.AutoRemove && !ds.conf.LocalMode,
@@ -213,21 +291,50 @@ func (ds dockerService) CreateContainer(ctx context.Context, cli entity.DockerCl
 				"labels": ds.conf.LogLabels,
 			},
 		},
-		Mounts: dContainer.GetMounts(),
+		Mounts:        dContainer.GetMounts(),
+		Sysctls:       dContainer.Sysctls,
+		CapAdd:        dContainer.CapAdd,
+		CapDrop:       dContainer.CapDrop,
+		Privileged:    dContainer.Privileged,
+		Tmpfs:         dContainer.Tmpfs,
+		ShmSize:       shmSize,
+		ExtraHosts:    dContainer.ExtraHosts,
+		DNS:           dContainer.DNS,
+		RestartPolicy: restartPolicy,
 	}
 	hostConfig.NanoCPUs = int64(1000000000 * cpus)
 	hostConfig.Memory = mem
 	hostConfig.CpusetCpus = dContainer.GetCPUSet()
+	hostConfig.Ulimits = dContainer.GetUlimits()
 
+	// docker only takes one network on creation, the rest are connected before the container starts
+	attachments := dContainer.GetNetworks()
 	networkConfig := &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{}}
-	if len(dContainer.Network) > 0 {
-		networkConfig.EndpointsConfig[dContainer.Network] = &network.EndpointSettings{
-			NetworkID: dContainer.Network,
-			IPAddress: dContainer.IP,
+	if len(attachments) > 0 {
+		primary := attachments[0]
+		networkConfig.EndpointsConfig[primary.Name] = &network.EndpointSettings{
+			NetworkID:         primary.Name,
+			IPAddress:         primary.IP,
+			GlobalIPv6Address: primary.IPv6,
 			IPAMConfig: &network.EndpointIPAMConfig{
-				IPv4Address: dContainer.IP,
+				IPv4Address: primary.IP,
+				IPv6Address: primary.IPv6,
 			},
+			Aliases:    primary.Aliases,
+			MacAddress: primary.MacAddress,
 		}
+		config.MacAddress = primary.MacAddress
+	}
+	networks := []string{}
+	for _, attachment := range attachments {
+		networks = append(networks, attachment.Name)
+	}
+	meta := map[string]interface{}{
+		"image":    dContainer.Image,
+		"name":     dContainer.Name,
+		"network":  dContainer.Network,
+		"networks": networks,
+		"type":     "CreateContainer",
 	}
 
 	err = <-errChan
@@ -236,16 +343,53 @@ func (ds dockerService) CreateContainer(ctx context.Context, cli entity.DockerCl
 	}
 
 	_, err = cli.ContainerCreate(ctx, config, hostConfig, networkConfig, dContainer.Name)
+	created := err == nil
 	res := ds.errorWhitelistHandler(err, "already in use by container")
 	if !res.IsSuccess() {
-		res = res.Fatal()
+		return res.Fatal().InjectMeta(meta)
+	}
+	for i := 1; i < len(attachments); i++ {
+		connRes := ds.connectNetwork(ctx, cli, dContainer.Name, attachments[i])
+		if connRes.IsSuccess() {
+			continue
+		}
+		meta["failedNetwork"] = attachments[i].Name
+		if !created {
+			// the container already existed, so it may not be one which this order created
+			return connRes.InjectMeta(meta)
+		}
+		// remove the container, so that it is not left without all of its networks when this is retried
+		rmErr := cli.ContainerRemove(ctx, dContainer.Name, types.ContainerRemoveOptions{Force: true})
+		if rmErr != nil {
+			ds.withFields(cli, logrus.Fields{"name": dContainer.Name, "error": rmErr}).Warn(
+				"failed to remove a container which could not be attached to its networks")
+		}
+		return connRes.InjectMeta(meta)
+	}
+	return res.InjectMeta(meta)
+}
+
+// connectNetwork attaches a container which has just been created to one of its networks
+func (ds dockerService) connectNetwork(ctx context.Context, cli entity.DockerCli, name string,
+	attachment entity.NetworkAttachment) entity.Result {
+
+	macAddress := attachment.MacAddress
+	if len(macAddress) == 0 {
+		var err error
+		macAddress, err = generateMacAddress()
+		if err != nil {
+			return entity.NewErrorResult(err)
+		}
 	}
-	return res.InjectMeta(map[string]interface{}{
-		"image":   dContainer.Image,
-		"name":    dContainer.Name,
-		"network": dContainer.Network,
-		"type":    "CreateContainer",
+	err := cli.NetworkConnect(ctx, attachment.Name, name, &network.EndpointSettings{
+		IPAMConfig: &network.EndpointIPAMConfig{
+			IPv4Address: attachment.IP,
+			IPv6Address: attachment.IPv6,
+		},
+		Aliases:    attachment.Aliases,
+		MacAddress: macAddress,
 	})
+	return ds.errorWhitelistHandler(err, "is already attached to network")
 }
 
 //StartContainer attempts to start an already created docker container
@@ -335,33 +479,51 @@ func (ds dockerService) RemoveContainer(ctx context.Context, cli entity.DockerCl
 
 // CreateNetwork attempts to create a network
 func (ds dockerService) CreateNetwork(ctx context.Context, cli entity.DockerCli,
-	net command.Network) entity.Result {
+	net entity.Network) entity.Result {
+
+	driver := net.GetDriver(!ds.conf.LocalMode)
+	if driver == entity.NoneDriver {
+		ds.withField(cli, "name", net.Name).Debug("not creating a network without a driver")
+		return entity.NewSuccessResult().InjectMeta(map[string]interface{}{
+			"name":    net.Name,
+			"driver":  driver,
+			"created": false,
+		})
+	}
+	if driver == entity.OverlayDriver && ds.conf.LocalMode {
+		return entity.NewFatalResult("an overlay network needs a swarm, which there is not in local mode")
+	}
 
 	networkCreate := types.NetworkCreate{
 		CheckDuplicate: true,
 		Attachable:     true,
 		Ingress:        false,
-		Internal:       false,
+		Internal:       net.Internal,
+		EnableIPv6:     net.IsIPv6(),
+		Driver:         driver,
+		Scope:          "local",
 		Labels:         cli.Labels,
 		IPAM: &network.IPAM{
 			Driver:  "default",
 			Options: nil,
-			Config: []network.IPAMConfig{
-				network.IPAMConfig{
-					Subnet:  net.Subnet,
-					Gateway: net.Gateway,
-				},
-			},
+			Config:  net.GetIPAMConfig(),
 		},
 		Options: map[string]string{},
 	}
-	if ds.conf.LocalMode || !net.Global {
-		networkCreate.Driver = "bridge"
-		networkCreate.Scope = "local"
+	switch driver {
+	case entity.BridgeDriver:
 		networkCreate.Options["com.docker.network.bridge.name"] = net.Name
-	} else {
-		networkCreate.Driver = "overlay"
+	case entity.OverlayDriver:
 		networkCreate.Scope = "swarm"
+		if net.Encrypted {
+			networkCreate.Options["encrypted"] = ""
+		}
+	}
+	if net.MTU > 0 {
+		networkCreate.Options["com.docker.network.driver.mtu"] = strconv.Itoa(net.MTU)
+	}
+	for key, value := range net.Options {
+		networkCreate.Options[key] = value
 	}
 	ds.withFields(cli, logrus.Fields{"name": net.Name,
 		"conf": networkCreate}).Debug("creating a network")
@@ -390,7 +552,7 @@ func generateMacAddress() (string, error) {
 }
 
 func (ds dockerService) AttachNetwork(ctx context.Context, cli entity.DockerCli,
-	cmd command.ContainerNetwork) entity.Result {
+	cmd entity.ContainerNetwork) entity.Result {
 
 	ds.withField(cli, "cmd", cmd).Info("attaching a network")
 	macAddress, err := generateMacAddress()
@@ -400,6 +562,7 @@ func (ds dockerService) AttachNetwork(ctx context.Context, cli entity.DockerCli,
 	err = cli.NetworkConnect(ctx, cmd.Network, cmd.Container, &network.EndpointSettings{
 		IPAMConfig: &network.EndpointIPAMConfig{
 			IPv4Address: cmd.IP,
+			IPv6Address: cmd.IPv6,
 		},
 		MacAddress: macAddress,
 	})
@@ -419,9 +582,17 @@ func (ds dockerService) DetachNetwork(ctx context.Context, cli entity.DockerCli,
 func (ds dockerService) CreateVolume(ctx context.Context, ecli entity.DockerCli,
 	vol command.Volume) entity.Result {
 
+	labels := map[string]string{}
+	for key, value := range vol.Labels {
+		labels[key] = value
+	}
+	for key, value := range ecli.Labels {
+		labels[key] = value
+	}
+
 	if !vol.Global || ds.conf.LocalMode {
 		volConfig := volume.VolumeCreateBody{
-			Labels: vol.Labels,
+			Labels: labels,
 			Name:   vol.Name,
 		}
 
@@ -448,6 +619,7 @@ func (ds dockerService) CreateVolume(ctx context.Context, ecli entity.DockerCli,
 			errChan <- ds.repo.Exec(ctx, clients[i], GlusterContainerName, entity.Exec{
 				Cmd:        []string{"mkdir", "-p", brickDir},
 				Privileged: true,
+				Timeout:    ds.conf.ExecTimeout,
 				Retries:    5,
 			})
 		}(i)
@@ -462,6 +634,7 @@ func (ds dockerService) CreateVolume(ctx context.Context, ecli entity.DockerCli,
 	err := ds.repo.Exec(ctx, clients[0], GlusterContainerName, entity.Exec{
 		Cmd:        []string{"gluster", "volume", "status", vol.Name},
 		Privileged: true,
+		Timeout:    ds.conf.ExecTimeout,
 		Retries:    1,
 	}) //check if it already exists, if so, don't try to create it
 
@@ -475,6 +648,7 @@ func (ds dockerService) CreateVolume(ctx context.Context, ecli entity.DockerCli,
 		err = ds.repo.Exec(ctx, clients[0], GlusterContainerName, entity.Exec{
 			Cmd:        cmds,
 			Privileged: true,
+			Timeout:    ds.conf.ExecTimeout,
 			Retries:    5,
 		}) //create the replica volume
 		if err != nil {
@@ -485,6 +659,7 @@ func (ds dockerService) CreateVolume(ctx context.Context, ecli entity.DockerCli,
 	err = ds.repo.Exec(ctx, clients[0], GlusterContainerName, entity.Exec{
 		Cmd:        []string{"gluster", "volume", "start", vol.Name},
 		Privileged: true,
+		Timeout:    ds.conf.ExecTimeout,
 		Retries:    5,
 	})
 	if err != nil {
@@ -494,6 +669,7 @@ func (ds dockerService) CreateVolume(ctx context.Context, ecli entity.DockerCli,
 	err = ds.repo.Exec(ctx, clients[0], GlusterContainerName, entity.Exec{
 		Cmd:        []string{"gluster", "volume", "set", vol.Name, "ctime", "off"},
 		Privileged: true,
+		Timeout:    ds.conf.ExecTimeout,
 		Retries:    5,
 	}) //compatibility
 	if err != nil {
@@ -503,6 +679,7 @@ func (ds dockerService) CreateVolume(ctx context.Context, ecli entity.DockerCli,
 	err = ds.repo.Exec(ctx, clients[0], GlusterContainerName, entity.Exec{
 		Cmd:        []string{"gluster", "volume", "set", vol.Name, "auth.allow", strings.Join(vol.Hosts, ",") + ",127.0.0.1"},
 		Privileged: true,
+		Timeout:    ds.conf.ExecTimeout,
 		Retries:    5,
 	}) // restrict access by ip
 	if err != nil {
@@ -514,6 +691,7 @@ func (ds dockerService) CreateVolume(ctx context.Context, ecli entity.DockerCli,
 			_, err = clients[i].VolumeCreate(ctx, volume.VolumeCreateBody{
 				Driver: ds.conf.GlusterDriver,
 				Name:   vol.Name,
+				Labels: labels,
 				DriverOpts: map[string]string{
 					"glusteropts": fmt.Sprintf("--volfile-server=%s --volfile-id=/%s", ds.hostName(ecli, i), vol.Name),
 				},
@@ -612,15 +790,23 @@ func (ds dockerService) PlaceFileInContainer(ctx context.Context, cli entity.Doc
 }
 
 func (ds dockerService) Emulation(ctx context.Context, cli entity.DockerCli,
-	netem command.Netconf) entity.Result {
+	emu entity.Emulation) entity.Result {
+
+	warnings, err := emu.CheckNetconf()
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	if len(warnings) > 0 {
+		ds.withFields(cli, logrus.Fields{"container": emu.Container, "warnings": warnings}).Warn(
+			"the emulation has parameters which tc may reject or ignore")
+	}
 
-	netemImage := "gaiadocker/iproute2:latest"
 	errChan := make(chan error, 1)
 	go func() {
-		errChan <- ds.repo.EnsureImagePulled(ctx, cli, netemImage, command.Credentials{})
+		errChan <- ds.repo.EnsureImagePulled(ctx, cli, NetemImage, command.Credentials{})
 	}()
 
-	net, err := ds.repo.GetNetworkByName(ctx, cli, netem.Network)
+	net, err := ds.repo.GetNetworkByName(ctx, cli, emu.Network)
 	if err != nil {
 		return entity.NewErrorResult(err)
 	}
@@ -630,47 +816,29 @@ func (ds dockerService) Emulation(ctx context.Context, cli entity.DockerCli,
 		return entity.NewErrorResult(err)
 	}
 
-	name := netem.Container + "-" + net.ID
-	netemCmd := fmt.Sprintf(
-		"tc qdisc add dev $(ip -o addr show to %s | sed -n 's/.*\\(eth[0-9]*\\).*/\\1/p') root netem",
-		net.IPAM.Config[0].Subnet)
-
-	if netem.Limit > 0 {
-		netemCmd += fmt.Sprintf(" limit %d", netem.Limit)
-	}
-
-	if netem.Loss > 0 {
-		netemCmd += fmt.Sprintf(" loss %.4f", netem.Loss)
-	}
-
-	if netem.Delay > 0 {
-		netemCmd += fmt.Sprintf(" delay %dus", netem.Delay)
-	}
-
-	if len(netem.Rate) > 0 {
-		netemCmd += fmt.Sprintf(" rate %s", netem.Rate)
-	}
-
-	if netem.Duplication > 0 {
-		netemCmd += fmt.Sprintf(" duplicate %.4f", netem.Duplication)
+	subnets, err := subnetsOf(net)
+	if err != nil {
+		return entity.NewErrorResult(err)
 	}
 
-	if netem.Corrupt > 0 {
-		netemCmd += fmt.Sprintf(" corrupt %.4f", netem.Duplication)
+	name := emu.Container + "-" + net.ID
+	script := deviceScript(subnets...) + "set -e\n"
+	if emu.HasEgress() {
+		script += emu.Command("add", "$dev") + "\n"
 	}
-
-	if netem.Reorder > 0 {
-		netemCmd += fmt.Sprintf(" reorder %.4f", netem.Reorder)
+	if emu.Ingress != nil {
+		script += ingressScript(*emu.Ingress)
 	}
 
 	config := &container.Config{
-		Image:      netemImage,
-		Entrypoint: strslice.StrSlice([]string{"/bin/sh", "-c", netemCmd}),
+		Image:      NetemImage,
+		Entrypoint: strslice.StrSlice([]string{"/bin/sh", "-c", script}),
+		Labels:     cli.SidecarLabels(NetemSidecar),
 	}
 
 	hostConfig := &container.HostConfig{
 		AutoRemove:  true,
-		NetworkMode: container.NetworkMode(fmt.Sprintf("container:%s", netem.Container)),
+		NetworkMode: container.NetworkMode(fmt.Sprintf("container:%s", emu.Container)),
 		CapAdd:      strslice.StrSlice([]string{"NET_ADMIN"}),
 	}
 
@@ -758,12 +926,14 @@ func (ds dockerService) PullImage(ctx context.Context, cli entity.DockerCli,
 	return entity.NewResult(err)
 }
 
-func (ds dockerService) mkConfigs() (*container.Config, *container.HostConfig, *network.NetworkingConfig, string) {
+func (ds dockerService) mkConfigs(labels map[string]string) (*container.Config,
+	*container.HostConfig, *network.NetworkingConfig, string) {
 	return &container.Config{
 			Hostname:   GlusterContainerName,
 			Domainname: GlusterContainerName,
 			Image:      ds.conf.GlusterImage,
 			Entrypoint: strslice.StrSlice([]string{"glusterd", "--no-daemon"}),
+			Labels:     labels,
 		},
 		&container.HostConfig{
 			AutoRemove:  true,
@@ -812,7 +982,7 @@ func (ds dockerService) VolumeShare(ctx context.Context, ecli entity.DockerCli,
 		}
 	}
 
-	config, hostConfig, networkConfig, name := ds.mkConfigs()
+	config, hostConfig, networkConfig, name := ds.mkConfigs(ecli.SidecarLabels(GlusterSidecar))
 
 	for i := range vs.Hosts {
 		go func(i int) {
@@ -854,6 +1024,7 @@ func (ds dockerService) VolumeShare(ctx context.Context, ecli entity.DockerCli,
 						Cmd: []string{"bash", "-c", fmt.Sprintf(`echo "%s  %s" >> /etc/hosts`,
 							"127.0.0.1", ds.hostName(ecli, j))},
 						Privileged: true,
+						Timeout:    ds.conf.ExecTimeout,
 						Retries:    2,
 					})
 				} else {
@@ -862,6 +1033,7 @@ func (ds dockerService) VolumeShare(ctx context.Context, ecli entity.DockerCli,
 							"bash", "-c", fmt.Sprintf(`echo "%s  %s" >> /etc/hosts`,
 								vs.Hosts[j], ds.hostName(ecli, j))},
 						Privileged: true,
+						Timeout:    ds.conf.ExecTimeout,
 						Retries:    2,
 					})
 				}
@@ -888,6 +1060,7 @@ func (ds dockerService) VolumeShare(ctx context.Context, ecli entity.DockerCli,
 			errChan <- ds.repo.Exec(ctx, clients[i], GlusterContainerName, entity.Exec{
 				Cmd:        []string{"gluster", "peer", "probe", ds.hostName(ecli, j)},
 				Privileged: true,
+				Timeout:    ds.conf.ExecTimeout,
 				Retries:    20,
 				Delay:      100 * time.Millisecond,
 			})
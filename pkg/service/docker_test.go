@@ -42,12 +42,12 @@ func TestDockerService_CreateContainer(t *testing.T) {
 		Environment: map[string]string{
 			"FOO": "BAR",
 		},
-		Name:    "TEST",
-		Network: "Testnet",
-		Ports:   map[string]string{"8888": "8889"},
-		Volumes: []command.Mount{{Name: "volume1", Directory: "/foo/bar", ReadOnly: false}},
-		Image:   "alpine",
-		Args:    []string{"test"},
+		Name:     "TEST",
+		Network:  "Testnet",
+		TCPPorts: map[int]int{8888: 8889},
+		Volumes:  []command.Mount{{Name: "volume1", Directory: "/foo/bar", ReadOnly: false}},
+		Image:    "alpine",
+		Args:     []string{"test"},
 	}
 	testContainer.Cpus = "2.5"
 	testContainer.Memory = "5gb"
@@ -139,7 +139,7 @@ func TestDockerService_CreateContainer(t *testing.T) {
 		Labels: map[string]string{
 			"FOO": "BAR",
 		},
-	}, testContainer)
+	}, entity.Container{Container: testContainer})
 	assert.NoError(t, res.Error)
 }
 
@@ -229,7 +229,7 @@ func TestDockerService_CreateNetwork_Success(t *testing.T) {
 		Labels: map[string]string{
 			"FOO": "BAR",
 		},
-	}, testNetwork)
+	}, entity.Network{Network: testNetwork})
 	assert.NoError(t, res.Error)
 
 	testNetwork.Global = false
@@ -238,7 +238,7 @@ func TestDockerService_CreateNetwork_Success(t *testing.T) {
 		Labels: map[string]string{
 			"FOO": "BAR",
 		},
-	}, testNetwork)
+	}, entity.Network{Network: testNetwork})
 	assert.NoError(t, res.Error)
 
 	cli.AssertExpectations(t)
@@ -261,7 +261,7 @@ func TestDockerService_CreateNetwork_Failure(t *testing.T) {
 	repo := new(repoMock.DockerRepository)
 	ds := NewDockerService(repo, config.Docker{}, nil, logrus.New())
 
-	res := ds.CreateNetwork(nil, entity.DockerCli{Client: cli}, testNetwork)
+	res := ds.CreateNetwork(nil, entity.DockerCli{Client: cli}, entity.Network{Network: testNetwork})
 	assert.Error(t, res.Error)
 
 	cli.AssertExpectations(t)
@@ -377,7 +377,7 @@ func TestDockerService_AttachNetwork(t *testing.T) {
 
 	ds := NewDockerService(nil, config.Docker{}, nil, logrus.New())
 
-	res := ds.AttachNetwork(nil, entity.DockerCli{Client: cli}, cn)
+	res := ds.AttachNetwork(nil, entity.DockerCli{Client: cli}, entity.ContainerNetwork{ContainerNetwork: cn})
 	assert.NoError(t, res.Error)
 
 	cli.AssertExpectations(t)
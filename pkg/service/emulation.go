@@ -0,0 +1,318 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/netem"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultQdisc matches the root qdiscs which the kernel sets up itself, which have no handle
+var defaultQdisc = regexp.MustCompile(`^qdisc \S+ 0: `)
+
+// clearRootScript prints the root qdisc of $dev, and then deletes it unless it is the default one
+const clearRootScript = "prev=$(tc qdisc show dev $dev root)\n" +
+	"echo \"$prev\"\n" +
+	"case \"$prev\" in\n" +
+	"\"\"|\"qdisc \"*\" 0: \"*) ;;\n" +
+	"*) tc qdisc del dev $dev root ;;\n" +
+	"esac\n"
+
+// ifbScript stores the name of the ifb device which the ingress traffic of $dev is redirected to in $ifb
+const ifbScript = "ifb=\"ifb${dev#eth}\"\n"
+
+// clearIngressScript removes the ingress emulation of $dev, if it has one
+func clearIngressScript() string {
+	return ifbScript + strings.Join(netem.ClearIngressCommands("$dev", "$ifb"), " 2>/dev/null || true\n") +
+		" 2>/dev/null || true\n"
+}
+
+// ingressScript replaces the ingress emulation of $dev
+func ingressScript(in netem.Ingress) string {
+	return clearIngressScript() + "set -e\n" + strings.Join(netem.IngressCommands("$dev", "$ifb", in), "\n") + "\n"
+}
+
+// UpdateEmulation replaces the netem of the interface of the container on the network. The netem is
+// changed in place if there already is one, otherwise it replaces whatever the root qdisc is. Either way,
+// the previous root qdisc is reported in the meta. When the ingress is given, its emulation is replaced
+// as well, and it is left alone otherwise.
+func (ds dockerService) UpdateEmulation(ctx context.Context, cli entity.DockerCli,
+	emu entity.Emulation) entity.Result {
+
+	err := emu.Validate()
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	meta := map[string]interface{}{
+		"container": emu.Container,
+		"network":   emu.Network,
+		"type":      "UpdateEmulation",
+	}
+	net, err := ds.repo.GetNetworkByName(ctx, cli, emu.Network)
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+	subnets, err := subnetsOf(net)
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+
+	script := deviceScript(subnets...)
+	if emu.HasEgress() {
+		script += "prev=$(tc qdisc show dev $dev root)\n" +
+			"echo \"$prev\"\n" +
+			"case \"$prev\" in\n" +
+			"\"qdisc netem \"*) " + emu.Command("change", "$dev") + " ;;\n" +
+			"*) " + emu.Command("replace", "$dev") + " ;;\n" +
+			"esac\n"
+	}
+	if emu.Ingress != nil {
+		script += ingressScript(*emu.Ingress)
+	}
+	out, err := ds.runNetScript(ctx, cli, NetemSidecar, NetemImage, emu.Container, script)
+	meta["previous"] = out.Stdout
+	meta["ingress"] = emu.Ingress != nil
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+	if emu.HasEgress() {
+		meta["action"] = "replace"
+		if strings.HasPrefix(out.Stdout, "qdisc netem ") {
+			meta["action"] = "change"
+		}
+	}
+	ds.withFields(cli, logrus.Fields{"container": emu.Container, "previous": out.Stdout}).Info(
+		"updated the emulation")
+	return entity.NewSuccessResult().InjectMeta(meta)
+}
+
+// ClearEmulation restores the default root qdisc of the interface of the container on the network, and
+// removes its ingress emulation. It does nothing if there is no emulation, and the previous root qdisc
+// is reported in the meta.
+func (ds dockerService) ClearEmulation(ctx context.Context, cli entity.DockerCli,
+	ce entity.ClearEmulation) entity.Result {
+
+	meta := map[string]interface{}{
+		"container": ce.Container,
+		"network":   ce.Network,
+		"type":      "ClearEmulation",
+	}
+	net, err := ds.repo.GetNetworkByName(ctx, cli, ce.Network)
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+	subnets, err := subnetsOf(net)
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+
+	out, err := ds.runNetScript(ctx, cli, NetemSidecar, NetemImage, ce.Container,
+		deviceScript(subnets...)+clearRootScript+clearIngressScript())
+	meta["previous"] = out.Stdout
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+	meta["cleared"] = len(out.Stdout) > 0 && !defaultQdisc.MatchString(out.Stdout)
+	return entity.NewSuccessResult().InjectMeta(meta)
+}
+
+// qdiscSeparator separates the qdiscs of the interface from those of its ifb device in the output of
+// the get emulation script
+const qdiscSeparator = "--- ingress ---"
+
+// showQdiscsScript prints the qdiscs of the device with their counters, as JSON when tc supports it
+func showQdiscsScript(device string) string {
+	return fmt.Sprintf("tc -s -j qdisc show dev %s 2>/dev/null || tc -s qdisc show dev %s\n", device, device)
+}
+
+// GetEmulation reports the qdiscs of the interface of the container on the network, and those of the
+// ifb device of its ingress emulation if it has one. Their parameters and counters go into the meta.
+func (ds dockerService) GetEmulation(ctx context.Context, cli entity.DockerCli,
+	ge entity.GetEmulation) entity.Result {
+
+	meta := map[string]interface{}{
+		"container": ge.Container,
+		"network":   ge.Network,
+		"type":      "GetEmulation",
+	}
+	net, err := ds.repo.GetNetworkByName(ctx, cli, ge.Network)
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+	subnets, err := subnetsOf(net)
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+
+	script := deviceScript(subnets...) + ifbScript +
+		"echo \"$dev\"\n" +
+		showQdiscsScript("$dev") +
+		"echo \"" + qdiscSeparator + "\"\n" +
+		"if ip link show \"$ifb\" >/dev/null 2>&1; then\n" + showQdiscsScript("$ifb") + "fi\n"
+	out, err := ds.runNetScript(ctx, cli, NetemSidecar, NetemImage, ge.Container, script)
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+
+	parts := strings.SplitN(out.Stdout, "\n", 2)
+	sections := []string{"", ""}
+	if len(parts) == 2 {
+		sections = strings.SplitN(parts[1], qdiscSeparator, 2)
+	}
+	if len(sections) != 2 {
+		return entity.NewErrorResult(fmt.Errorf("unexpected output from tc: %s", out.Stdout)).InjectMeta(meta)
+	}
+	egress, err := netem.ParseQdiscs(sections[0])
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+	ingress, err := netem.ParseQdiscs(sections[1])
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+
+	var drops, overlimits uint64
+	for _, qdiscs := range [][]netem.Qdisc{egress, ingress} {
+		for _, qdisc := range qdiscs {
+			drops += qdisc.Drops
+			overlimits += qdisc.Overlimits
+		}
+	}
+	meta["device"] = parts[0]
+	meta["egress"] = egress
+	meta["ingress"] = ingress
+	meta["drops"] = drops
+	meta["overlimits"] = overlimits
+	return entity.NewSuccessResult().InjectMeta(meta)
+}
+
+// containerIP gets the IP address of the container on the network, which is its IPv6 address
+// when it is on an IPv6 only network
+func (ds dockerService) containerIP(ctx context.Context, cli entity.DockerCli,
+	name string, network string) (string, error) {
+
+	ips, err := ds.containerIPs(ctx, cli, name, network)
+	if err != nil {
+		return "", err
+	}
+	return ips[0], nil
+}
+
+// containerIPs gets all of the addresses of a container on a network, its IPv4 address first and
+// then its IPv6 address, either of which a container on a dual-stack network may have
+func (ds dockerService) containerIPs(ctx context.Context, cli entity.DockerCli,
+	name string, network string) ([]string, error) {
+
+	info, err := cli.ContainerInspect(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if info.NetworkSettings == nil || info.NetworkSettings.Networks[network] == nil {
+		return nil, fmt.Errorf("container \"%s\" is not on network \"%s\"", name, network)
+	}
+	ep := info.NetworkSettings.Networks[network]
+	out := []string{}
+	for _, ip := range []string{ep.IPAddress, ep.GlobalIPv6Address} {
+		if len(ip) > 0 {
+			out = append(out, ip)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("container \"%s\" has no address on network \"%s\"", name, network)
+	}
+	return out, nil
+}
+
+// linkScripts creates the script for each source container which applies the emulation of its links
+func (ds dockerService) linkScripts(ctx context.Context, cli entity.DockerCli, subnets []string,
+	le entity.LinkEmulation) (map[string]string, entity.Result) {
+
+	bySource := map[string][]entity.Link{}
+	for _, link := range le.Links {
+		if len(link.Source) == 0 || len(link.Destination) == 0 {
+			return nil, entity.NewFatalResult("every link needs a source and a destination")
+		}
+		if link.Source == link.Destination {
+			return nil, entity.NewFatalResult(fmt.Sprintf("%s has a link to itself", link.Source))
+		}
+		bySource[link.Source] = append(bySource[link.Source], link)
+		if link.Bidirectional {
+			reverse := link
+			reverse.Source, reverse.Destination = link.Destination, link.Source
+			bySource[reverse.Source] = append(bySource[reverse.Source], reverse)
+		}
+	}
+
+	ips := map[string]string{}
+	scripts := map[string]string{}
+	for source, links := range bySource {
+		netemLinks := make([]netem.Link, len(links))
+		for i, link := range links {
+			if _, ok := ips[link.Destination]; !ok {
+				ip, err := ds.containerIP(ctx, cli, link.Destination, le.Network)
+				if err != nil {
+					return nil, entity.NewErrorResult(err)
+				}
+				ips[link.Destination] = ip
+			}
+			netemLinks[i] = netem.Link{Destination: ips[link.Destination], Netem: link.Netem}
+		}
+		cmds, err := netem.LinkCommands("$dev", netemLinks)
+		if err != nil {
+			return nil, entity.NewFatalResult(fmt.Errorf("links of %s: %v", source, err))
+		}
+		scripts[source] = deviceScript(subnets...) + clearRootScript + "set -e\n" + strings.Join(cmds, "\n") + "\n"
+	}
+	return scripts, entity.NewSuccessResult()
+}
+
+// LinkEmulation replaces the root qdisc of the interface of each source container on the network with a
+// tree which applies the emulation of each of its links to the traffic going to that destination. Everything
+// is validated before any of the containers are changed.
+func (ds dockerService) LinkEmulation(ctx context.Context, cli entity.DockerCli,
+	le entity.LinkEmulation) entity.Result {
+
+	meta := map[string]interface{}{
+		"network": le.Network,
+		"type":    "LinkEmulation",
+	}
+	net, err := ds.repo.GetNetworkByName(ctx, cli, le.Network)
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+	subnets, err := subnetsOf(net)
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+	scripts, res := ds.linkScripts(ctx, cli, subnets, le)
+	if !res.IsSuccess() {
+		return res.InjectMeta(meta)
+	}
+
+	outputs, failed := ds.runNetScripts(ctx, cli, NetemSidecar, NetemImage, scripts)
+	previous := map[string]string{}
+	for source, out := range outputs {
+		previous[source] = out.Stdout
+	}
+	meta["previous"] = previous
+	meta["failed"] = failed
+	meta["sources"] = len(scripts)
+	if len(failed) > 0 {
+		ds.withFields(cli, logrus.Fields{"network": le.Network, "failed": failed}).Warn(
+			"unable to apply the emulation of some of the links")
+		return entity.NewErrorResult(
+			fmt.Sprintf("failed to apply the links of %d containers", len(failed))).InjectMeta(meta)
+	}
+	return entity.NewSuccessResult().InjectMeta(meta)
+}
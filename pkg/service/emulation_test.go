@@ -0,0 +1,301 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	entityMock "github.com/whiteblock/genesis/mocks/pkg/entity"
+	repoMock "github.com/whiteblock/genesis/mocks/pkg/repository"
+	"github.com/whiteblock/genesis/pkg/config"
+	"github.com/whiteblock/genesis/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/netem"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/whiteblock/definition/command"
+)
+
+// netSidecarMux guards the scripts of mockNetSidecar, as the sidecars may be run at the same time
+var netSidecarMux sync.Mutex
+
+// mockNetSidecar sets up the client to run a network sidecar for the target, which prints stdout
+// and exits with the exit code. The scripts which were run are appended to scripts.
+func mockNetSidecar(t *testing.T, cli *entityMock.Client, target string, stdout string,
+	exitCode int64, scripts *[]string) {
+
+	id := "sidecar-" + target
+	cli.On("ContainerCreate", mock.Anything, mock.Anything, mock.MatchedBy(
+		func(hostConf *container.HostConfig) bool {
+			return hostConf.NetworkMode == container.NetworkMode("container:"+target)
+		}), mock.Anything, "").Return(container.ContainerCreateCreatedBody{ID: id}, nil).Run(
+		func(args mock.Arguments) {
+			conf := args.Get(1).(*container.Config)
+			hostConf := args.Get(2).(*container.HostConfig)
+			assert.Contains(t, hostConf.CapAdd, "NET_ADMIN")
+			assert.Contains(t, conf.Labels, entity.SidecarLabel)
+			require.Len(t, conf.Entrypoint, 3)
+			netSidecarMux.Lock()
+			defer netSidecarMux.Unlock()
+			*scripts = append(*scripts, conf.Entrypoint[2])
+		}).Once()
+	cli.On("ContainerStart", mock.Anything, id, mock.Anything).Return(nil).Once()
+
+	resChan := make(chan container.ContainerWaitOKBody, 1)
+	resChan <- container.ContainerWaitOKBody{StatusCode: exitCode}
+	cli.On("ContainerWait", mock.Anything, id, container.WaitConditionNotRunning).Return(
+		(<-chan container.ContainerWaitOKBody)(resChan), (<-chan error)(make(chan error))).Once()
+
+	var logs bytes.Buffer
+	_, err := stdcopy.NewStdWriter(&logs, stdcopy.Stdout).Write([]byte(stdout + "\n"))
+	require.NoError(t, err)
+	cli.On("ContainerLogs", mock.Anything, id, mock.Anything).Return(
+		ioutil.NopCloser(&logs), nil).Once()
+	cli.On("ContainerRemove", mock.Anything, id, mock.Anything).Return(nil).Once()
+}
+
+func testNetRepo() *repoMock.DockerRepository {
+	repo := new(repoMock.DockerRepository)
+	repo.On("GetNetworkByName", mock.Anything, mock.Anything, "net").Return(types.NetworkResource{
+		Name: "net",
+		IPAM: network.IPAM{Config: []network.IPAMConfig{{Subnet: "10.1.0.0/16"}}},
+	}, nil)
+	repo.On("EnsureImagePulled", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	return repo
+}
+
+func TestDockerService_Emulation_Netconf(t *testing.T) {
+	// tc ignores the reorder without a delay, and such payloads have always been run
+	data, err := json.Marshal(command.Netconf{Container: "node0", Network: "net", Loss: 2,
+		Reorder: 25, Rate: "1mbit"})
+	require.NoError(t, err)
+	var emu entity.Emulation
+	require.NoError(t, json.Unmarshal(data, &emu))
+
+	var script string
+	cli := new(entityMock.Client)
+	cli.On("ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, "node0-").Return(
+		container.ContainerCreateCreatedBody{}, nil).Run(func(args mock.Arguments) {
+		script = args.Get(1).(*container.Config).Entrypoint[2]
+	}).Once()
+	cli.On("ContainerStart", mock.Anything, "node0-", mock.Anything).Return(nil).Once()
+
+	ds := NewDockerService(testNetRepo(), config.Docker{}, nil, logrus.New())
+	res := ds.Emulation(nil, entity.DockerCli{Client: cli}, emu)
+	require.NoError(t, res.Error)
+	assert.Contains(t, script, "tc qdisc add dev $dev root netem loss random 2% reorder 25% rate 1mbit")
+
+	emu.Jitter = 10
+	res = ds.Emulation(nil, entity.DockerCli{Client: cli}, emu)
+	assert.True(t, res.IsFatal())
+	cli.AssertExpectations(t)
+}
+
+func TestDockerService_UpdateEmulation(t *testing.T) {
+	scripts := []string{}
+	cli := new(entityMock.Client)
+	mockNetSidecar(t, cli, "node0", "qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms", 0, &scripts)
+	mockNetSidecar(t, cli, "node0", "qdisc noqueue 0: root refcnt 2", 0, &scripts)
+
+	ds := NewDockerService(testNetRepo(), config.Docker{}, nil, logrus.New())
+	emu := entity.Emulation{Container: "node0", Network: "net", Netem: netem.Netem{Delay: 50000}}
+
+	res := ds.UpdateEmulation(nil, entity.DockerCli{Client: cli}, emu)
+	require.NoError(t, res.Error)
+	assert.Equal(t, "change", res.Meta["action"])
+	assert.Equal(t, "qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms", res.Meta["previous"])
+
+	res = ds.UpdateEmulation(nil, entity.DockerCli{Client: cli}, emu)
+	require.NoError(t, res.Error)
+	assert.Equal(t, "replace", res.Meta["action"])
+
+	require.Len(t, scripts, 2)
+	assert.Contains(t, scripts[0], "ip -o addr show to 10.1.0.0/16")
+	assert.Contains(t, scripts[0], "tc qdisc change dev $dev root netem delay 50000us")
+	assert.Contains(t, scripts[0], "tc qdisc replace dev $dev root netem delay 50000us")
+
+	res = ds.UpdateEmulation(nil, entity.DockerCli{Client: cli}, entity.Emulation{
+		Container: "node0", Network: "net", Netem: netem.Netem{Loss: 200}})
+	assert.True(t, res.IsFatal())
+	cli.AssertExpectations(t)
+}
+
+func TestDockerService_UpdateEmulation_Ingress(t *testing.T) {
+	scripts := []string{}
+	cli := new(entityMock.Client)
+	mockNetSidecar(t, cli, "node0", "", 0, &scripts)
+	mockNetSidecar(t, cli, "node0", "qdisc noqueue 0: root refcnt 2", 0, &scripts)
+
+	ds := NewDockerService(testNetRepo(), config.Docker{}, nil, logrus.New())
+
+	res := ds.UpdateEmulation(nil, entity.DockerCli{Client: cli}, entity.Emulation{
+		Container: "node0",
+		Network:   "net",
+		Ingress:   &netem.Ingress{Netem: netem.Netem{Delay: 20000}, Bandwidth: "5mbit"},
+	})
+	require.NoError(t, res.Error)
+	assert.Equal(t, true, res.Meta["ingress"])
+	assert.NotContains(t, res.Meta, "action")
+	require.Len(t, scripts, 1)
+	assert.NotContains(t, scripts[0], "root netem")
+	assert.Contains(t, scripts[0], "ifb=\"ifb${dev#eth}\"")
+	assert.Contains(t, scripts[0], "action mirred egress redirect dev $ifb")
+	assert.Contains(t, scripts[0], "tc qdisc add dev $ifb root handle 1: netem delay 20000us")
+	assert.Contains(t, scripts[0], "tc qdisc add dev $ifb parent 1:1 handle 10: tbf rate 5mbit")
+
+	res = ds.UpdateEmulation(nil, entity.DockerCli{Client: cli}, entity.Emulation{
+		Container: "node0",
+		Network:   "net",
+		Netem:     netem.Netem{Delay: 100},
+		Ingress:   &netem.Ingress{Netem: netem.Netem{Loss: 1}},
+	})
+	require.NoError(t, res.Error)
+	assert.Equal(t, "replace", res.Meta["action"])
+	require.Len(t, scripts, 2)
+	assert.Contains(t, scripts[1], "tc qdisc replace dev $dev root netem delay 100us")
+	assert.Contains(t, scripts[1], "tc qdisc add dev $ifb root handle 1: netem loss random 1%")
+
+	res = ds.UpdateEmulation(nil, entity.DockerCli{Client: cli}, entity.Emulation{
+		Container: "node0",
+		Network:   "net",
+		Ingress:   &netem.Ingress{Bandwidth: "fast"},
+	})
+	assert.True(t, res.IsFatal())
+	cli.AssertExpectations(t)
+}
+
+func TestDockerService_ClearEmulation(t *testing.T) {
+	scripts := []string{}
+	cli := new(entityMock.Client)
+	mockNetSidecar(t, cli, "node0", "qdisc netem 8001: root refcnt 2 limit 1000", 0, &scripts)
+	mockNetSidecar(t, cli, "node0", "qdisc noqueue 0: root refcnt 2", 0, &scripts)
+	mockNetSidecar(t, cli, "node1", "", 3, &scripts)
+
+	ds := NewDockerService(testNetRepo(), config.Docker{}, nil, logrus.New())
+
+	res := ds.ClearEmulation(nil, entity.DockerCli{Client: cli},
+		entity.ClearEmulation{Container: "node0", Network: "net"})
+	require.NoError(t, res.Error)
+	assert.Equal(t, true, res.Meta["cleared"])
+	assert.Contains(t, scripts[0], "tc qdisc del dev $dev root")
+	assert.Contains(t, scripts[0], "tc qdisc del dev $dev ingress 2>/dev/null || true")
+
+	res = ds.ClearEmulation(nil, entity.DockerCli{Client: cli},
+		entity.ClearEmulation{Container: "node0", Network: "net"})
+	require.NoError(t, res.Error)
+	assert.Equal(t, false, res.Meta["cleared"])
+
+	res = ds.ClearEmulation(nil, entity.DockerCli{Client: cli},
+		entity.ClearEmulation{Container: "node1", Network: "net"})
+	assert.Error(t, res.Error)
+	cli.AssertExpectations(t)
+}
+
+func TestDockerService_LinkEmulation(t *testing.T) {
+	scripts := []string{}
+	cli := new(entityMock.Client)
+	for name, ip := range map[string]string{"node1": "10.1.0.3", "node2": "10.1.0.4"} {
+		cli.On("ContainerInspect", mock.Anything, name).Return(types.ContainerJSON{
+			NetworkSettings: &types.NetworkSettings{Networks: map[string]*network.EndpointSettings{
+				"net": {IPAddress: ip}}}}, nil).Once()
+	}
+	mockNetSidecar(t, cli, "node0", "qdisc noqueue 0: root refcnt 2", 0, &scripts)
+
+	ds := NewDockerService(testNetRepo(), config.Docker{}, nil, logrus.New())
+	res := ds.LinkEmulation(nil, entity.DockerCli{Client: cli}, entity.LinkEmulation{
+		Network: "net",
+		Links: []entity.Link{
+			{Source: "node0", Destination: "node1", Netem: netem.Netem{Delay: 200000}},
+			{Source: "node0", Destination: "node2", Netem: netem.Netem{Delay: 5000}},
+		},
+	})
+	require.NoError(t, res.Error)
+	assert.Equal(t, map[string]string{"node0": "qdisc noqueue 0: root refcnt 2"}, res.Meta["previous"])
+	require.Len(t, scripts, 1)
+	assert.Contains(t, scripts[0], clearRootScript)
+	assert.Contains(t, scripts[0], "match ip dst 10.1.0.3/32 flowid 1:2")
+	assert.Contains(t, scripts[0], "match ip dst 10.1.0.4/32 flowid 1:3")
+	assert.Contains(t, scripts[0], "parent 1:2 handle 2: netem delay 200000us")
+	cli.AssertExpectations(t)
+}
+
+func TestDockerService_LinkEmulation_Invalid(t *testing.T) {
+	cli := new(entityMock.Client)
+	cli.On("ContainerInspect", mock.Anything, "node1").Return(types.ContainerJSON{
+		NetworkSettings: &types.NetworkSettings{Networks: map[string]*network.EndpointSettings{
+			"net": {IPAddress: "10.1.0.3"}}}}, nil)
+	cli.On("ContainerInspect", mock.Anything, "node2").Return(types.ContainerJSON{
+		NetworkSettings: &types.NetworkSettings{}}, nil)
+
+	ds := NewDockerService(testNetRepo(), config.Docker{}, nil, logrus.New())
+	tests := []struct {
+		links []entity.Link
+		fatal bool
+	}{
+		{links: []entity.Link{{Source: "node0", Destination: "node0"}}, fatal: true},
+		{links: []entity.Link{{Source: "node0"}}, fatal: true},
+		{links: []entity.Link{{Source: "node0", Destination: "node1", Netem: netem.Netem{Loss: 101}}}, fatal: true},
+		{links: []entity.Link{{Source: "node0", Destination: "node2"}}, fatal: false},
+	}
+	for _, test := range tests {
+		res := ds.LinkEmulation(nil, entity.DockerCli{Client: cli},
+			entity.LinkEmulation{Network: "net", Links: test.links})
+		assert.Error(t, res.Error)
+		assert.Equal(t, test.fatal, res.IsFatal())
+	}
+	cli.AssertNotCalled(t, "ContainerCreate", mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything)
+}
+
+func TestDockerService_GetEmulation(t *testing.T) {
+	scripts := []string{}
+	cli := new(entityMock.Client)
+	mockNetSidecar(t, cli, "node0", "eth1\n"+
+		`[{"kind":"netem","handle":"8001:","root":true,"options":{"limit":1000},`+
+		`"bytes":100,"packets":2,"drops":1,"overlimits":0,"requeues":0,"backlog":0,"qlen":0}]`+"\n"+
+		"--- ingress ---\n"+
+		"qdisc netem 1: root refcnt 2 limit 1000 delay 20.0ms\n"+
+		" Sent 4000 bytes 40 pkt (dropped 2, overlimits 5 requeues 0)", 0, &scripts)
+	mockNetSidecar(t, cli, "node1", "eth0\n--- ingress ---", 0, &scripts)
+
+	ds := NewDockerService(testNetRepo(), config.Docker{}, nil, logrus.New())
+
+	res := ds.GetEmulation(nil, entity.DockerCli{Client: cli},
+		entity.GetEmulation{Container: "node0", Network: "net"})
+	require.NoError(t, res.Error)
+	assert.Equal(t, "eth1", res.Meta["device"])
+	assert.Equal(t, uint64(3), res.Meta["drops"])
+	assert.Equal(t, uint64(5), res.Meta["overlimits"])
+	egress, ok := res.Meta["egress"].([]netem.Qdisc)
+	require.True(t, ok)
+	require.Len(t, egress, 1)
+	assert.Equal(t, "netem", egress[0].Kind)
+	assert.Equal(t, float64(1000), egress[0].Options["limit"])
+	ingress, ok := res.Meta["ingress"].([]netem.Qdisc)
+	require.True(t, ok)
+	require.Len(t, ingress, 1)
+	assert.Equal(t, uint64(4000), ingress[0].Bytes)
+	require.Len(t, scripts, 1)
+	assert.Contains(t, scripts[0], "tc -s -j qdisc show dev $dev")
+
+	res = ds.GetEmulation(nil, entity.DockerCli{Client: cli},
+		entity.GetEmulation{Container: "node1", Network: "net"})
+	require.NoError(t, res.Error)
+	assert.Empty(t, res.Meta["egress"])
+	assert.Empty(t, res.Meta["ingress"])
+	cli.AssertExpectations(t)
+}
@@ -0,0 +1,86 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"sync"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/sirupsen/logrus"
+)
+
+// eventBufferSize is how many events a subscriber can fall behind by before events are dropped
+const eventBufferSize = 256
+
+// EventService distributes the events which occur during execution to any interested subscribers
+type EventService interface {
+	// Publish sends the event to all of the matching subscribers. It never blocks, a
+	// subscriber which is not keeping up will miss events.
+	Publish(event entity.Event)
+
+	// Subscribe creates a subscription to the events of the given execution or test. An empty
+	// id subscribes to all events. The returned function ends the subscription.
+	Subscribe(id string) (<-chan entity.Event, func())
+}
+
+type subscription struct {
+	id     string
+	events chan entity.Event
+}
+
+type eventService struct {
+	mu   *sync.RWMutex
+	subs map[*subscription]bool
+	log  logrus.Ext1FieldLogger
+}
+
+// NewEventService creates a new EventService
+func NewEventService(log logrus.Ext1FieldLogger) EventService {
+	return &eventService{
+		mu:   &sync.RWMutex{},
+		subs: map[*subscription]bool{},
+		log:  log,
+	}
+}
+
+// Publish sends the event to all of the matching subscribers
+func (es eventService) Publish(event entity.Event) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	for sub := range es.subs {
+		if !event.Matches(sub.id) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			es.log.WithFields(logrus.Fields{
+				"subscription": sub.id,
+				"type":         event.Type,
+			}).Warn("dropping an event for a slow subscriber")
+		}
+	}
+}
+
+// Subscribe creates a subscription to the events of the given execution or test
+func (es eventService) Subscribe(id string) (<-chan entity.Event, func()) {
+	sub := &subscription{id: id, events: make(chan entity.Event, eventBufferSize)}
+	es.mu.Lock()
+	es.subs[sub] = true
+	es.mu.Unlock()
+
+	once := &sync.Once{}
+	return sub.events, func() {
+		once.Do(func() {
+			es.mu.Lock()
+			delete(es.subs, sub)
+			es.mu.Unlock()
+			close(sub.events)
+		})
+	}
+}
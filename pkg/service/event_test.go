@@ -0,0 +1,63 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nextEvent waits for the next event, which must be of the given type
+func nextEvent(t *testing.T, events <-chan entity.Event, eventType entity.EventType) entity.Event {
+	select {
+	case ev := <-events:
+		require.Equal(t, eventType, ev.Type)
+		return ev
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for an event", eventType)
+	}
+	return entity.Event{}
+}
+
+func TestEventService(t *testing.T) {
+	es := NewEventService(logrus.New())
+
+	all, unsubAll := es.Subscribe("")
+	one, unsubOne := es.Subscribe("test1")
+
+	es.Publish(entity.NewEvent(entity.StepEvent, "test1"))
+	es.Publish(entity.NewEvent(entity.StepEvent, "test2"))
+
+	assert.Len(t, all, 2)
+	assert.Len(t, one, 1)
+	assert.Equal(t, "test1", (<-one).Test)
+
+	unsubOne()
+	unsubOne()
+	es.Publish(entity.NewEvent(entity.StepEvent, "test1"))
+	_, ok := <-one
+	assert.False(t, ok)
+	assert.Len(t, all, 3)
+	unsubAll()
+}
+
+func TestEventService_SlowSubscriber(t *testing.T) {
+	es := NewEventService(logrus.New())
+	events, unsubscribe := es.Subscribe("")
+	defer unsubscribe()
+
+	for i := 0; i < eventBufferSize+10; i++ {
+		es.Publish(entity.NewEvent(entity.StepEvent, "test"))
+	}
+	assert.Len(t, events, eventBufferSize)
+}
@@ -0,0 +1,75 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxExecOutput is the most of each output stream of an exec which is kept in the meta
+const maxExecOutput = 64 * 1024
+
+// truncateOutput keeps the end of the output, as that is usually where the interesting part is.
+// The cut is moved forward to the start of a rune, so that no rune is split.
+func truncateOutput(out string) string {
+	if len(out) <= maxExecOutput {
+		return out
+	}
+	start := len(out) - maxExecOutput
+	for start < len(out) && !utf8.RuneStart(out[start]) {
+		start++
+	}
+	return out[start:]
+}
+
+// ExecInContainer runs a command in a container, and fails if the command does not
+// exit with the expected exit code. Not being able to run the command is retried, but
+// an unexpected exit code is fatal, since it is a failed assertion of the test.
+func (ds dockerService) ExecInContainer(ctx context.Context, cli entity.DockerCli,
+	e entity.ExecInContainer) entity.Result {
+
+	env := make([]string, 0, len(e.Env))
+	for key, value := range e.Env {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+
+	ds.withFields(cli, logrus.Fields{"container": e.Container, "cmd": e.Cmd}).Debug(
+		"executing a command in a container")
+	out, err := ds.repo.ExecOutput(ctx, cli, e.Container, entity.Exec{
+		Cmd:        e.Cmd,
+		Privileged: e.Privileged,
+		Env:        env,
+		User:       e.User,
+		WorkingDir: e.WorkingDir,
+		Timeout:    ds.conf.ExecTimeout,
+	})
+	meta := map[string]interface{}{
+		"container": e.Container,
+		"cmd":       e.Cmd,
+		"type":      "ExecInContainer",
+	}
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+	meta["stdout"] = truncateOutput(out.Stdout)
+	meta["stderr"] = truncateOutput(out.Stderr)
+	meta["exitCode"] = out.ExitCode
+
+	if !e.IgnoreExitCode && out.ExitCode != e.ExpectedExitCode {
+		return entity.NewFatalResult(fmt.Sprintf("command exited with %d, expected %d",
+			out.ExitCode, e.ExpectedExitCode)).InjectMeta(meta)
+	}
+	return entity.NewSuccessResult().InjectMeta(meta)
+}
@@ -0,0 +1,74 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	repoMock "github.com/whiteblock/genesis/mocks/pkg/repository"
+	"github.com/whiteblock/genesis/pkg/config"
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDockerService_ExecInContainer(t *testing.T) {
+	repo := new(repoMock.DockerRepository)
+	repo.On("ExecOutput", mock.Anything, mock.Anything, "node0", entity.Exec{
+		Cmd: []string{"ls"},
+		Env: []string{"A=1", "B=2"},
+	}).Return(entity.ExecOutput{Stdout: "out", Stderr: strings.Repeat("e", maxExecOutput+1),
+		ExitCode: 1}, nil).Times(3)
+	repo.On("ExecOutput", mock.Anything, mock.Anything, "node1", mock.Anything).Return(
+		entity.ExecOutput{}, fmt.Errorf("no such container")).Once()
+
+	ds := NewDockerService(repo, config.Docker{}, nil, logrus.New())
+	exec := entity.ExecInContainer{
+		Container: "node0",
+		Cmd:       []string{"ls"},
+		Env:       map[string]string{"B": "2", "A": "1"},
+	}
+
+	res := ds.ExecInContainer(nil, entity.DockerCli{}, exec)
+	assert.Error(t, res.Error)
+	assert.True(t, res.IsFatal())
+	assert.Equal(t, 1, res.Meta["exitCode"])
+	assert.Equal(t, "out", res.Meta["stdout"])
+	assert.Len(t, res.Meta["stderr"], maxExecOutput)
+
+	exec.ExpectedExitCode = 1
+	res = ds.ExecInContainer(nil, entity.DockerCli{}, exec)
+	assert.NoError(t, res.Error)
+
+	exec.ExpectedExitCode = 0
+	exec.IgnoreExitCode = true
+	res = ds.ExecInContainer(nil, entity.DockerCli{}, exec)
+	assert.NoError(t, res.Error)
+
+	exec.Container = "node1"
+	res = ds.ExecInContainer(nil, entity.DockerCli{}, exec)
+	assert.Error(t, res.Error)
+	assert.False(t, res.IsFatal())
+	assert.Equal(t, "node1", res.Meta["container"])
+
+	repo.AssertExpectations(t)
+}
+
+func TestTruncateOutput(t *testing.T) {
+	assert.Equal(t, "short", truncateOutput("short"))
+
+	// each of the runes takes 3 bytes, so a cut at the limit would land inside one
+	out := truncateOutput("x" + strings.Repeat("世", maxExecOutput/3+1))
+	assert.True(t, utf8.ValidString(out))
+	assert.True(t, len(out) <= maxExecOutput)
+	assert.Equal(t, strings.Repeat("世", maxExecOutput/3), out)
+}
@@ -0,0 +1,86 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// backgroundJob is a job which runs in the background for a test, until it is done or cancelled
+type backgroundJob struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// jobRegistry keeps track of the background jobs of each test by name, so that they can be
+// replaced and cancelled
+type jobRegistry struct {
+	mu    *sync.Mutex
+	tests map[string]map[string]*backgroundJob
+}
+
+func newJobRegistry() jobRegistry {
+	return jobRegistry{mu: &sync.Mutex{}, tests: map[string]map[string]*backgroundJob{}}
+}
+
+// start runs the job in the background, replacing the job of the test with the same name. The job
+// which is replaced is cancelled, and the new one only starts once it has stopped.
+func (jr jobRegistry) start(testID string, name string, run func(ctx context.Context)) (replaced bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &backgroundJob{cancel: cancel, done: make(chan struct{})}
+
+	jr.mu.Lock()
+	if jr.tests[testID] == nil {
+		jr.tests[testID] = map[string]*backgroundJob{}
+	}
+	prev := jr.tests[testID][name]
+	jr.tests[testID][name] = job
+	jr.mu.Unlock()
+
+	go func() {
+		defer close(job.done)
+		defer jr.remove(testID, name, job)
+		if prev != nil {
+			prev.cancel()
+			<-prev.done
+		}
+		run(ctx)
+	}()
+	return prev != nil
+}
+
+// remove forgets the job, unless it has already been replaced
+func (jr jobRegistry) remove(testID string, name string, job *backgroundJob) {
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	if jr.tests[testID][name] != job {
+		return
+	}
+	delete(jr.tests[testID], name)
+	if len(jr.tests[testID]) == 0 {
+		delete(jr.tests, testID)
+	}
+}
+
+// cancel stops all of the jobs of the test and waits for them to stop, returning their names
+func (jr jobRegistry) cancel(testID string) []string {
+	jr.mu.Lock()
+	jobs := jr.tests[testID]
+	delete(jr.tests, testID)
+	jr.mu.Unlock()
+
+	names := []string{}
+	for name, job := range jobs {
+		job.cancel()
+		<-job.done
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,88 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/sirupsen/logrus"
+	"github.com/whiteblock/definition/command"
+)
+
+// defaultKillSignal is the signal which is sent by KillContainer when none is given
+const defaultKillSignal = "SIGKILL"
+
+// stopTimeout gets the timeout to give docker, nil leaves it to the default of docker
+func stopTimeout(timeout command.Duration) *time.Duration {
+	if timeout.Duration <= 0 {
+		return nil
+	}
+	return &timeout.Duration
+}
+
+// lifecycleResult turns the outcome of changing the state of a container into a result
+func lifecycleResult(err error, meta map[string]interface{}) entity.Result {
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+	return entity.NewSuccessResult().InjectMeta(meta)
+}
+
+// StopContainer stops a container, killing it if it does not stop within the timeout
+func (ds dockerService) StopContainer(ctx context.Context, cli entity.DockerCli,
+	sc entity.StopContainer) entity.Result {
+
+	ds.withFields(cli, logrus.Fields{"name": sc.Name, "timeout": sc.Timeout.Duration}).Debug(
+		"stopping a container")
+	err := cli.ContainerStop(ctx, sc.Name, stopTimeout(sc.Timeout))
+	return lifecycleResult(err, map[string]interface{}{"name": sc.Name, "type": "StopContainer"})
+}
+
+// RestartContainer stops a container, killing it if it does not stop within the timeout, and
+// then starts it again
+func (ds dockerService) RestartContainer(ctx context.Context, cli entity.DockerCli,
+	rc entity.RestartContainer) entity.Result {
+
+	ds.withFields(cli, logrus.Fields{"name": rc.Name, "timeout": rc.Timeout.Duration}).Debug(
+		"restarting a container")
+	err := cli.ContainerRestart(ctx, rc.Name, stopTimeout(rc.Timeout))
+	return lifecycleResult(err, map[string]interface{}{"name": rc.Name, "type": "RestartContainer"})
+}
+
+// KillContainer sends a signal to the main process of a container, SIGKILL by default
+func (ds dockerService) KillContainer(ctx context.Context, cli entity.DockerCli,
+	kc entity.KillContainer) entity.Result {
+
+	signal := kc.Signal
+	if len(signal) == 0 {
+		signal = defaultKillSignal
+	}
+	ds.withFields(cli, logrus.Fields{"name": kc.Name, "signal": signal}).Debug("killing a container")
+	err := cli.ContainerKill(ctx, kc.Name, signal)
+	return lifecycleResult(err, map[string]interface{}{
+		"name":   kc.Name,
+		"signal": signal,
+		"type":   "KillContainer",
+	})
+}
+
+// PauseContainer freezes all of the processes of a container
+func (ds dockerService) PauseContainer(ctx context.Context, cli entity.DockerCli, name string) entity.Result {
+	ds.withFields(cli, logrus.Fields{"name": name}).Debug("pausing a container")
+	err := cli.ContainerPause(ctx, name)
+	return lifecycleResult(err, map[string]interface{}{"name": name, "type": "PauseContainer"})
+}
+
+// UnpauseContainer resumes the processes of a paused container
+func (ds dockerService) UnpauseContainer(ctx context.Context, cli entity.DockerCli, name string) entity.Result {
+	ds.withFields(cli, logrus.Fields{"name": name}).Debug("unpausing a container")
+	err := cli.ContainerUnpause(ctx, name)
+	return lifecycleResult(err, map[string]interface{}{"name": name, "type": "UnpauseContainer"})
+}
@@ -0,0 +1,90 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	entityMock "github.com/whiteblock/genesis/mocks/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/config"
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerService_StopContainer(t *testing.T) {
+	cli := new(entityMock.Client)
+	cli.On("ContainerStop", mock.Anything, "node0", (*time.Duration)(nil)).Return(nil).Once()
+	cli.On("ContainerStop", mock.Anything, "node1", mock.Anything).Return(nil).Run(
+		func(args mock.Arguments) {
+			timeout := args.Get(2).(*time.Duration)
+			require.NotNil(t, timeout)
+			assert.Equal(t, 5*time.Second, *timeout)
+		}).Once()
+
+	ds := NewDockerService(nil, config.Docker{}, nil, logrus.New())
+
+	res := ds.StopContainer(nil, entity.DockerCli{Client: cli}, entity.StopContainer{Name: "node0"})
+	require.NoError(t, res.Error)
+	assert.Equal(t, "node0", res.Meta["name"])
+
+	sc := entity.StopContainer{Name: "node1"}
+	sc.Timeout.Duration = 5 * time.Second
+	res = ds.StopContainer(nil, entity.DockerCli{Client: cli}, sc)
+	require.NoError(t, res.Error)
+	cli.AssertExpectations(t)
+}
+
+func TestDockerService_RestartContainer(t *testing.T) {
+	cli := new(entityMock.Client)
+	cli.On("ContainerRestart", mock.Anything, "node0", (*time.Duration)(nil)).Return(
+		fmt.Errorf("No such container: node0")).Once()
+
+	ds := NewDockerService(nil, config.Docker{}, nil, logrus.New())
+
+	res := ds.RestartContainer(nil, entity.DockerCli{Client: cli}, entity.RestartContainer{Name: "node0"})
+	assert.Error(t, res.Error)
+	assert.False(t, res.IsFatal())
+	assert.Equal(t, "RestartContainer", res.Meta["type"])
+	cli.AssertExpectations(t)
+}
+
+func TestDockerService_KillContainer(t *testing.T) {
+	cli := new(entityMock.Client)
+	cli.On("ContainerKill", mock.Anything, "node0", "SIGKILL").Return(nil).Once()
+	cli.On("ContainerKill", mock.Anything, "node1", "SIGTERM").Return(nil).Once()
+
+	ds := NewDockerService(nil, config.Docker{}, nil, logrus.New())
+
+	res := ds.KillContainer(nil, entity.DockerCli{Client: cli}, entity.KillContainer{Name: "node0"})
+	require.NoError(t, res.Error)
+	assert.Equal(t, "SIGKILL", res.Meta["signal"])
+
+	res = ds.KillContainer(nil, entity.DockerCli{Client: cli},
+		entity.KillContainer{Name: "node1", Signal: "SIGTERM"})
+	require.NoError(t, res.Error)
+	cli.AssertExpectations(t)
+}
+
+func TestDockerService_PauseContainer(t *testing.T) {
+	cli := new(entityMock.Client)
+	cli.On("ContainerPause", mock.Anything, "node0").Return(nil).Once()
+	cli.On("ContainerUnpause", mock.Anything, "node0").Return(nil).Once()
+
+	ds := NewDockerService(nil, config.Docker{}, nil, logrus.New())
+
+	res := ds.PauseContainer(nil, entity.DockerCli{Client: cli}, "node0")
+	require.NoError(t, res.Error)
+	res = ds.UnpauseContainer(nil, entity.DockerCli{Client: cli}, "node0")
+	require.NoError(t, res.Error)
+	cli.AssertExpectations(t)
+}
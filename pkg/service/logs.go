@@ -0,0 +1,172 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/sirupsen/logrus"
+	"github.com/whiteblock/definition/command"
+)
+
+// containerLogs fetches the logs of a container, separated into stdout and stderr
+func (ds dockerService) containerLogs(ctx context.Context, cli entity.DockerCli, name string,
+	cl entity.CollectLogs) (stdout *bytes.Buffer, stderr *bytes.Buffer, err error) {
+
+	info, err := cli.ContainerInspect(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	rdr, err := cli.ContainerLogs(ctx, name, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+		Since:      cl.Since,
+		Tail:       cl.Tail,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rdr.Close()
+
+	stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+	if info.Config != nil && info.Config.Tty {
+		_, err = io.Copy(stdout, rdr)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, rdr)
+	}
+	return stdout, stderr, err
+}
+
+// testContainers gets the names of the containers of the test, other than the sidecars. Only
+// the running ones are included unless all is set.
+func (ds dockerService) testContainers(ctx context.Context, cli entity.DockerCli,
+	testID string, all bool) ([]string, error) {
+
+	cntrs, err := cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     all,
+		Filters: filters.NewArgs(filters.Arg("label", command.TestIDKey+"="+testID)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := []string{}
+	for _, cntr := range cntrs {
+		if _, isSidecar := cntr.Labels[entity.SidecarLabel]; !isSidecar {
+			out = append(out, containerName(cntr))
+		}
+	}
+	return out, nil
+}
+
+func writeTarFile(tw *tar.Writer, name string, modTime time.Time, buf *bytes.Buffer) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(buf.Len()),
+		ModTime: modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, buf)
+	return err
+}
+
+// writeLogsArchive writes a tar.gz containing the stdout and stderr of each of the containers,
+// returning the containers whose logs could not be fetched
+func (ds dockerService) writeLogsArchive(ctx context.Context, cli entity.DockerCli,
+	cl entity.CollectLogs, names []string, out io.Writer) (map[string]string, error) {
+
+	failed := map[string]string{}
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		stdout, stderr, err := ds.containerLogs(ctx, cli, name, cl)
+		if err != nil {
+			ds.withFields(cli, logrus.Fields{"container": name, "error": err}).Warn(
+				"failed to fetch the logs of a container")
+			failed[name] = err.Error()
+			continue
+		}
+		now := time.Now()
+		err = writeTarFile(tw, name+"/stdout.log", now, stdout)
+		if err != nil {
+			return nil, err
+		}
+		err = writeTarFile(tw, name+"/stderr.log", now, stderr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := tw.Close()
+	if err != nil {
+		return nil, err
+	}
+	return failed, gz.Close()
+}
+
+// CollectLogs archives the logs of the containers of a test and stores the archive as an artifact
+func (ds dockerService) CollectLogs(ctx context.Context, cli entity.DockerCli,
+	cl entity.CollectLogs) entity.Result {
+
+	testID := testIDOf(cli)
+	if len(testID) == 0 {
+		return entity.NewFatalResult("unable to determine which test to collect the logs of")
+	}
+	names := cl.Containers
+	if len(names) == 0 {
+		var err error
+		names, err = ds.testContainers(ctx, cli, testID, true)
+		if err != nil {
+			return entity.NewErrorResult(err)
+		}
+	}
+	name := cl.Name
+	if len(name) == 0 {
+		name = fmt.Sprintf("logs-%d.tar.gz", time.Now().Unix())
+	}
+
+	pr, pw := io.Pipe()
+	failedChan := make(chan map[string]string, 1)
+	go func() {
+		failed, err := ds.writeLogsArchive(ctx, cli, cl, names, pw)
+		pw.CloseWithError(err)
+		failedChan <- failed
+	}()
+	location, err := ds.remote.PutArtifact(testID, name, pr)
+	pr.CloseWithError(err)
+	failed := <-failedChan
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(map[string]interface{}{
+			"type": "CollectLogs",
+		})
+	}
+	ds.withFields(cli, logrus.Fields{
+		"location":   location,
+		"containers": len(names),
+		"failed":     len(failed),
+	}).Info("collected the logs of a test")
+	return entity.NewSuccessResult().InjectMeta(map[string]interface{}{
+		"artifact":   name,
+		"location":   location,
+		"containers": names,
+		"failed":     failed,
+		"type":       "CollectLogs",
+	})
+}
@@ -0,0 +1,101 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	entityMock "github.com/whiteblock/genesis/mocks/pkg/entity"
+	fileMock "github.com/whiteblock/genesis/mocks/pkg/file"
+	"github.com/whiteblock/genesis/pkg/config"
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/whiteblock/definition/command"
+)
+
+// artifactSink stores artifacts with put, without the mock recording the reader while it is
+// still being written to
+type artifactSink struct {
+	fileMock.RemoteSources
+	put   func(testID, name string, rdr io.Reader) (string, error)
+	calls int
+}
+
+func (as *artifactSink) PutArtifact(testID, name string, rdr io.Reader) (string, error) {
+	as.calls++
+	return as.put(testID, name, rdr)
+}
+
+func TestDockerService_CollectLogs(t *testing.T) {
+	var logs bytes.Buffer
+	_, err := stdcopy.NewStdWriter(&logs, stdcopy.Stdout).Write([]byte("out\n"))
+	require.NoError(t, err)
+	_, err = stdcopy.NewStdWriter(&logs, stdcopy.Stderr).Write([]byte("err\n"))
+	require.NoError(t, err)
+
+	cli := new(entityMock.Client)
+	cli.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{
+		{Names: []string{"/node0"}},
+		{Names: []string{"/node1"}},
+		{Names: []string{"/node0-netem"}, Labels: map[string]string{entity.SidecarLabel: NetemSidecar}},
+	}, nil).Once()
+	cli.On("ContainerInspect", mock.Anything, "node0").Return(types.ContainerJSON{
+		Config: &container.Config{}}, nil).Once()
+	cli.On("ContainerInspect", mock.Anything, "node1").Return(types.ContainerJSON{},
+		fmt.Errorf("gone")).Once()
+	cli.On("ContainerLogs", mock.Anything, "node0", mock.Anything).Return(
+		ioutil.NopCloser(&logs), nil).Run(func(args mock.Arguments) {
+		opts := args.Get(2).(types.ContainerLogsOptions)
+		assert.True(t, opts.Timestamps)
+		assert.Equal(t, "5", opts.Tail)
+	}).Once()
+
+	files := map[string]string{}
+	remote := &artifactSink{put: func(testID, name string, rdr io.Reader) (string, error) {
+		assert.Equal(t, "test", testID)
+		assert.Equal(t, "logs.tar.gz", name)
+		gz, err := gzip.NewReader(rdr)
+		require.NoError(t, err)
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			assert.False(t, hdr.ModTime.IsZero())
+			data, err := ioutil.ReadAll(tr)
+			require.NoError(t, err)
+			files[hdr.Name] = string(data)
+		}
+		return "/logs.tar.gz", nil
+	}}
+
+	ds := NewDockerService(nil, config.Docker{}, remote, logrus.New())
+	res := ds.CollectLogs(nil, entity.DockerCli{Client: cli, Labels: map[string]string{
+		command.TestIDKey: "test"}}, entity.CollectLogs{Tail: "5", Name: "logs.tar.gz"})
+
+	assert.NoError(t, res.Error)
+	assert.Equal(t, map[string]string{"node0/stdout.log": "out\n", "node0/stderr.log": "err\n"}, files)
+	assert.Equal(t, []string{"node0", "node1"}, res.Meta["containers"])
+	assert.Equal(t, map[string]string{"node1": "gone"}, res.Meta["failed"])
+	cli.AssertExpectations(t)
+	assert.Equal(t, 1, remote.calls)
+}
@@ -0,0 +1,160 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/sirupsen/logrus"
+	"github.com/whiteblock/definition/command"
+)
+
+// NetemImage is the image of the sidecars which run tc
+const NetemImage = "gaiadocker/iproute2:latest"
+
+// deviceScript finds the interface of the container which is on one of the subnets and stores it in $dev.
+// The subnets are tried in order, so that the interface of a dual-stack network is found by either of them.
+func deviceScript(subnets ...string) string {
+	out := ""
+	for i, subnet := range subnets {
+		if i > 0 {
+			out += "[ -n \"$dev\" ] || "
+		}
+		out += fmt.Sprintf("dev=$(ip -o addr show to %s | sed -n 's/.*\\(eth[0-9]*\\).*/\\1/p' | head -n 1)\n",
+			subnet)
+	}
+	return out + fmt.Sprintf("[ -n \"$dev\" ] || { echo \"no interface is on %s\" >&2; exit 3; }\n",
+		strings.Join(subnets, " or "))
+}
+
+// subnetsOf gets the subnets of a network, so that the interface of a container on it can be found
+func subnetsOf(net types.NetworkResource) ([]string, error) {
+	out := []string{}
+	for _, conf := range net.IPAM.Config {
+		if len(conf.Subnet) > 0 {
+			out = append(out, conf.Subnet)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("network \"%s\" does not have a subnet", net.Name)
+	}
+	return out, nil
+}
+
+// runNetSidecar runs the script in a sidecar which shares the network namespace of the target container,
+// and waits for it to finish. Unlike the emulation sidecar, it is removed once it is done, and what it
+// printed is returned.
+func (ds dockerService) runNetSidecar(ctx context.Context, cli entity.DockerCli, kind string,
+	image string, target string, script string) (entity.ExecOutput, error) {
+
+	err := ds.repo.EnsureImagePulled(ctx, cli, image, command.Credentials{})
+	if err != nil {
+		return entity.ExecOutput{}, err
+	}
+
+	ds.withFields(cli, logrus.Fields{"container": target, "kind": kind}).Trace("running a network sidecar")
+	resp, err := cli.ContainerCreate(ctx, &container.Config{
+		Image:      image,
+		Entrypoint: strslice.StrSlice([]string{"/bin/sh", "-c", script}),
+		Labels:     cli.SidecarLabels(kind),
+	}, &container.HostConfig{
+		NetworkMode: container.NetworkMode("container:" + target),
+		CapAdd:      strslice.StrSlice([]string{"NET_ADMIN"}),
+	}, &network.NetworkingConfig{}, "")
+	if err != nil {
+		return entity.ExecOutput{}, err
+	}
+	defer func() {
+		err := cli.ContainerRemove(context.Background(), resp.ID, types.ContainerRemoveOptions{Force: true})
+		if err != nil {
+			ds.withFields(cli, logrus.Fields{"id": resp.ID, "error": err}).Warn(
+				"failed to remove a network sidecar")
+		}
+	}()
+
+	err = cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{})
+	if err != nil {
+		return entity.ExecOutput{}, err
+	}
+
+	resChan, errChan := cli.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
+	var out entity.ExecOutput
+	select {
+	case res := <-resChan:
+		out.ExitCode = int(res.StatusCode)
+	case err := <-errChan:
+		return entity.ExecOutput{}, err
+	}
+
+	rdr, err := cli.ContainerLogs(ctx, resp.ID, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return entity.ExecOutput{}, err
+	}
+	defer rdr.Close()
+	var stdout, stderr bytes.Buffer
+	_, err = stdcopy.StdCopy(&stdout, &stderr, rdr)
+	if err != nil {
+		return entity.ExecOutput{}, err
+	}
+	out.Stdout = strings.TrimSpace(stdout.String())
+	out.Stderr = strings.TrimSpace(stderr.String())
+	return out, nil
+}
+
+// runNetScript is runNetSidecar for scripts which must succeed, a non-zero exit code becomes an error
+func (ds dockerService) runNetScript(ctx context.Context, cli entity.DockerCli, kind string,
+	image string, target string, script string) (entity.ExecOutput, error) {
+
+	out, err := ds.runNetSidecar(ctx, cli, kind, image, target, script)
+	if err != nil {
+		return out, err
+	}
+	if out.ExitCode != 0 {
+		return out, fmt.Errorf("%s sidecar for \"%s\" exited with %d: %s", kind, target, out.ExitCode, out.Stderr)
+	}
+	return out, nil
+}
+
+// runNetScripts runs each of the scripts in a sidecar of the container it is for, all at once. It returns
+// the outputs of the sidecars, and the errors of the ones which failed.
+func (ds dockerService) runNetScripts(ctx context.Context, cli entity.DockerCli, kind string, image string,
+	scripts map[string]string) (outputs map[string]entity.ExecOutput, failed map[string]string) {
+
+	type outcome struct {
+		target string
+		out    entity.ExecOutput
+		err    error
+	}
+	outcomes := make(chan outcome, len(scripts))
+	for target, script := range scripts {
+		go func(target, script string) {
+			out, err := ds.runNetScript(ctx, cli, kind, image, target, script)
+			outcomes <- outcome{target: target, out: out, err: err}
+		}(target, script)
+	}
+
+	outputs = map[string]entity.ExecOutput{}
+	failed = map[string]string{}
+	for range scripts {
+		oc := <-outcomes
+		outputs[oc.target] = oc.out
+		if oc.err != nil {
+			failed[oc.target] = oc.err.Error()
+		}
+	}
+	return outputs, failed
+}
@@ -0,0 +1,169 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"testing"
+
+	entityMock "github.com/whiteblock/genesis/mocks/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/config"
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/whiteblock/definition/command"
+)
+
+func TestDockerService_CreateNetwork_DualStack(t *testing.T) {
+	cli := new(entityMock.Client)
+	cli.On("NetworkCreate", mock.Anything, "testnet", mock.Anything).Return(
+		types.NetworkCreateResponse{}, nil).Run(func(args mock.Arguments) {
+
+		networkCreate := args.Get(2).(types.NetworkCreate)
+		assert.True(t, networkCreate.EnableIPv6)
+		require.NotNil(t, networkCreate.IPAM)
+		assert.Equal(t, []network.IPAMConfig{
+			{Subnet: "10.14.0.0/16", Gateway: "10.14.0.1"},
+			{Subnet: "fd00:14::/64", Gateway: "fd00:14::1"},
+		}, networkCreate.IPAM.Config)
+	}).Once()
+	cli.On("NetworkCreate", mock.Anything, "testnet6", mock.Anything).Return(
+		types.NetworkCreateResponse{}, nil).Run(func(args mock.Arguments) {
+
+		networkCreate := args.Get(2).(types.NetworkCreate)
+		assert.True(t, networkCreate.EnableIPv6)
+		assert.Equal(t, []network.IPAMConfig{{Subnet: "fd00:15::/64"}}, networkCreate.IPAM.Config)
+	}).Once()
+
+	ds := NewDockerService(nil, config.Docker{}, nil, logrus.New())
+	res := ds.CreateNetwork(nil, entity.DockerCli{Client: cli}, entity.Network{
+		Network: command.Network{Name: "testnet", Subnet: "10.14.0.0/16", Gateway: "10.14.0.1"},
+		Subnets: []entity.Subnet{{Subnet: "fd00:14::/64", Gateway: "fd00:14::1"}},
+	})
+	assert.NoError(t, res.Error)
+
+	res = ds.CreateNetwork(nil, entity.DockerCli{Client: cli}, entity.Network{
+		Network: command.Network{Name: "testnet6"},
+		Subnets: []entity.Subnet{{Subnet: "fd00:15::/64"}},
+	})
+	assert.NoError(t, res.Error)
+	cli.AssertExpectations(t)
+}
+
+func TestDockerService_CreateNetwork_Drivers(t *testing.T) {
+	cli := new(entityMock.Client)
+	cli.On("NetworkCreate", mock.Anything, "macvlan", mock.Anything).Return(
+		types.NetworkCreateResponse{}, nil).Run(func(args mock.Arguments) {
+
+		networkCreate := args.Get(2).(types.NetworkCreate)
+		assert.Equal(t, "macvlan", networkCreate.Driver)
+		assert.Equal(t, "local", networkCreate.Scope)
+		assert.True(t, networkCreate.Internal)
+		assert.Equal(t, map[string]string{"parent": "eth0.10"}, networkCreate.Options)
+		assert.Equal(t, []network.IPAMConfig{
+			{Subnet: "192.168.10.0/24", Gateway: "192.168.10.1", IPRange: "192.168.10.128/25"},
+		}, networkCreate.IPAM.Config)
+	}).Once()
+	cli.On("NetworkCreate", mock.Anything, "overlay", mock.Anything).Return(
+		types.NetworkCreateResponse{}, nil).Run(func(args mock.Arguments) {
+
+		networkCreate := args.Get(2).(types.NetworkCreate)
+		assert.Equal(t, "overlay", networkCreate.Driver)
+		assert.Equal(t, "swarm", networkCreate.Scope)
+		assert.False(t, networkCreate.Internal)
+		assert.Equal(t, map[string]string{
+			"encrypted":                     "",
+			"com.docker.network.driver.mtu": "1400",
+		}, networkCreate.Options)
+	}).Once()
+	cli.On("NetworkCreate", mock.Anything, "br0", mock.Anything).Return(
+		types.NetworkCreateResponse{}, nil).Run(func(args mock.Arguments) {
+
+		networkCreate := args.Get(2).(types.NetworkCreate)
+		assert.Equal(t, "bridge", networkCreate.Driver)
+		assert.Equal(t, map[string]string{
+			"com.docker.network.bridge.name":                 "br0",
+			"com.docker.network.driver.mtu":                  "9000",
+			"com.docker.network.bridge.enable_ip_masquerade": "false",
+		}, networkCreate.Options)
+	}).Once()
+
+	ds := NewDockerService(nil, config.Docker{}, nil, logrus.New())
+	res := ds.CreateNetwork(nil, entity.DockerCli{Client: cli}, entity.Network{
+		Network:  command.Network{Name: "macvlan", Subnet: "192.168.10.0/24", Gateway: "192.168.10.1"},
+		IPRange:  "192.168.10.128/25",
+		Driver:   entity.MacvlanDriver,
+		Internal: true,
+		Options:  map[string]string{"parent": "eth0.10"},
+	})
+	assert.NoError(t, res.Error)
+
+	res = ds.CreateNetwork(nil, entity.DockerCli{Client: cli}, entity.Network{
+		Network:   command.Network{Name: "overlay", Global: true},
+		MTU:       1400,
+		Encrypted: true,
+	})
+	assert.NoError(t, res.Error)
+
+	res = ds.CreateNetwork(nil, entity.DockerCli{Client: cli}, entity.Network{
+		Network: command.Network{Name: "br0"},
+		MTU:     9000,
+		Options: map[string]string{"com.docker.network.bridge.enable_ip_masquerade": "false"},
+	})
+	assert.NoError(t, res.Error)
+
+	res = ds.CreateNetwork(nil, entity.DockerCli{Client: cli}, entity.Network{
+		Network: command.Network{Name: "none"},
+		Driver:  entity.NoneDriver,
+	})
+	assert.NoError(t, res.Error)
+	assert.Equal(t, false, res.Meta["created"])
+	cli.AssertExpectations(t)
+
+	ds = NewDockerService(nil, config.Docker{LocalMode: true}, nil, logrus.New())
+	res = ds.CreateNetwork(nil, entity.DockerCli{Client: cli}, entity.Network{
+		Network: command.Network{Name: "overlay"},
+		Driver:  entity.OverlayDriver,
+	})
+	assert.True(t, res.IsFatal())
+	cli.AssertNumberOfCalls(t, "NetworkCreate", 3)
+}
+
+func TestDockerService_AttachNetwork_IPv6(t *testing.T) {
+	cli := new(entityMock.Client)
+	cli.On("NetworkConnect", mock.Anything, "testnet", "node0", mock.MatchedBy(
+		func(es *network.EndpointSettings) bool {
+			return es.IPAMConfig.IPv4Address == "10.14.0.2" && es.IPAMConfig.IPv6Address == "fd00:14::2"
+		})).Return(nil).Once()
+
+	ds := NewDockerService(nil, config.Docker{}, nil, logrus.New())
+	res := ds.AttachNetwork(nil, entity.DockerCli{Client: cli}, entity.ContainerNetwork{
+		ContainerNetwork: command.ContainerNetwork{Container: "node0", Network: "testnet", IP: "10.14.0.2"},
+		IPv6:             "fd00:14::2",
+	})
+	assert.NoError(t, res.Error)
+	cli.AssertExpectations(t)
+}
+
+func TestSubnetsOf(t *testing.T) {
+	subnets, err := subnetsOf(types.NetworkResource{Name: "net", IPAM: network.IPAM{Config: []network.IPAMConfig{
+		{Subnet: "10.1.0.0/16"}, {Subnet: "fd00:1::/64"},
+	}}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.1.0.0/16", "fd00:1::/64"}, subnets)
+
+	_, err = subnetsOf(types.NetworkResource{Name: "net"})
+	assert.Error(t, err)
+
+	script := deviceScript(subnets...)
+	assert.Contains(t, script, "dev=$(ip -o addr show to 10.1.0.0/16")
+	assert.Contains(t, script, "[ -n \"$dev\" ] || dev=$(ip -o addr show to fd00:1::/64")
+}
@@ -0,0 +1,156 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// partitionComment tags the iptables rules of a partition, it is followed by the name of the partition
+	partitionComment = "genesis-partition:"
+
+	// defaultPartition is the name of the partitions which are not given one
+	defaultPartition = "default"
+)
+
+// healScript removes the rules of the partition, or of every partition when the name is empty, from both
+// iptables and ip6tables, and prints how many were removed. The rules are found by their comment, so that
+// nothing else is touched.
+func healScript(name string) string {
+	match := "--comment " + partitionComment
+	if len(name) > 0 {
+		match += name + " "
+	}
+	return "removed=0\n" +
+		"for cmd in iptables ip6tables; do\n" +
+		"rules=$($cmd-save -t filter 2>/dev/null | grep -e '" + match + "' | sed 's/^-A /-D /')\n" +
+		"echo \"$rules\" | while read -r rule; do [ -z \"$rule\" ] || $cmd $rule; done\n" +
+		"removed=$((removed + $(echo \"$rules\" | grep -c . || true)))\n" +
+		"done\n" +
+		"echo $removed\n"
+}
+
+// iptablesFor gets the iptables command which the rules for the address go in
+func iptablesFor(ip string) string {
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+		return "ip6tables"
+	}
+	return "iptables"
+}
+
+// partitionScript replaces the rules of the partition with ones which drop the traffic from each of the sources
+func partitionScript(name string, sources []string) string {
+	script := "set -e\n" + healScript(name)
+	for _, source := range sources {
+		script += fmt.Sprintf("%s -I INPUT -s %s -m comment --comment %s%s -j DROP\n",
+			iptablesFor(source), source, partitionComment, name)
+	}
+	return script
+}
+
+// PartitionNetwork drops the traffic between the groups of containers, or when it is one way, only the
+// traffic from each group to the groups after it. The traffic is dropped as it comes into a container, so
+// that it is lost rather than refused.
+func (ds dockerService) PartitionNetwork(ctx context.Context, cli entity.DockerCli,
+	pn entity.PartitionNetwork) entity.Result {
+
+	name := pn.Name
+	if len(name) == 0 {
+		name = defaultPartition
+	}
+	meta := map[string]interface{}{
+		"name":    name,
+		"network": pn.Network,
+		"type":    "PartitionNetwork",
+	}
+
+	// every address of a container is dropped, so that a dual-stack network is partitioned over both
+	ips := make([][]string, len(pn.Groups))
+	for i, group := range pn.Groups {
+		ips[i] = []string{}
+		for _, cntr := range group {
+			addrs, err := ds.containerIPs(ctx, cli, cntr, pn.Network)
+			if err != nil {
+				return entity.NewErrorResult(err).InjectMeta(meta)
+			}
+			ips[i] = append(ips[i], addrs...)
+		}
+	}
+
+	scripts := map[string]string{}
+	dropped := map[string][]string{}
+	for to, group := range pn.Groups {
+		sources := []string{}
+		for from := range pn.Groups {
+			if from == to || (pn.OneWay && from > to) {
+				continue
+			}
+			sources = append(sources, ips[from]...)
+		}
+		for _, cntr := range group {
+			scripts[cntr] = partitionScript(name, sources)
+			dropped[cntr] = sources
+		}
+	}
+
+	ds.withFields(cli, logrus.Fields{"name": name, "groups": pn.Groups, "oneWay": pn.OneWay}).Info(
+		"partitioning the network")
+	_, failed := ds.runNetScripts(ctx, cli, PartitionSidecar, ds.conf.PartitionImage, scripts)
+	meta["dropped"] = dropped
+	meta["failed"] = failed
+	if len(failed) > 0 {
+		return entity.NewErrorResult(
+			fmt.Sprintf("failed to partition %d containers", len(failed))).InjectMeta(meta)
+	}
+	return entity.NewSuccessResult().InjectMeta(meta)
+}
+
+// HealNetwork removes the rules of a partition, or of all of them, from the containers
+func (ds dockerService) HealNetwork(ctx context.Context, cli entity.DockerCli,
+	hn entity.HealNetwork) entity.Result {
+
+	meta := map[string]interface{}{
+		"name": hn.Name,
+		"type": "HealNetwork",
+	}
+	containers := hn.Containers
+	if len(containers) == 0 {
+		var err error
+		containers, err = ds.testContainers(ctx, cli, testIDOf(cli), false)
+		if err != nil {
+			return entity.NewErrorResult(err).InjectMeta(meta)
+		}
+	}
+
+	scripts := map[string]string{}
+	for _, cntr := range containers {
+		scripts[cntr] = "set -e\n" + healScript(hn.Name)
+	}
+	outputs, failed := ds.runNetScripts(ctx, cli, PartitionSidecar, ds.conf.PartitionImage, scripts)
+	removed := map[string]int{}
+	for cntr, out := range outputs {
+		if _, isFailed := failed[cntr]; !isFailed {
+			removed[cntr], _ = strconv.Atoi(strings.TrimSpace(out.Stdout))
+		}
+	}
+	meta["removed"] = removed
+	meta["failed"] = failed
+	if len(failed) > 0 {
+		return entity.NewErrorResult(
+			fmt.Sprintf("failed to heal %d containers", len(failed))).InjectMeta(meta)
+	}
+	return entity.NewSuccessResult().InjectMeta(meta)
+}
@@ -0,0 +1,107 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	entityMock "github.com/whiteblock/genesis/mocks/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/config"
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/whiteblock/definition/command"
+)
+
+func TestPartitionScript(t *testing.T) {
+	script := partitionScript("split", []string{"10.1.0.3", "10.1.0.4"})
+	assert.True(t, strings.HasPrefix(script, "set -e\n"+healScript("split")))
+	assert.Contains(t, script, "grep -e '--comment genesis-partition:split '")
+	assert.Contains(t, script,
+		"iptables -I INPUT -s 10.1.0.3 -m comment --comment genesis-partition:split -j DROP\n")
+	assert.Contains(t, script,
+		"iptables -I INPUT -s 10.1.0.4 -m comment --comment genesis-partition:split -j DROP\n")
+
+	assert.Contains(t, healScript(""), "grep -e '--comment genesis-partition:'")
+	assert.Contains(t, healScript(""), "for cmd in iptables ip6tables; do")
+
+	script = partitionScript("split", []string{"10.1.0.3", "fd00:1::3"})
+	assert.Contains(t, script,
+		"\niptables -I INPUT -s 10.1.0.3 -m comment --comment genesis-partition:split -j DROP\n")
+	assert.Contains(t, script,
+		"\nip6tables -I INPUT -s fd00:1::3 -m comment --comment genesis-partition:split -j DROP\n")
+}
+
+func TestDockerService_PartitionNetwork(t *testing.T) {
+	ips := map[string]string{"node0": "10.1.0.2", "node1": "10.1.0.3", "node2": "10.1.0.4"}
+	scripts := []string{}
+	cli := new(entityMock.Client)
+	for name, ip := range ips {
+		ep := &network.EndpointSettings{IPAddress: ip}
+		if name == "node0" {
+			ep.GlobalIPv6Address = "fd00:1::2"
+		}
+		cli.On("ContainerInspect", mock.Anything, name).Return(types.ContainerJSON{
+			NetworkSettings: &types.NetworkSettings{Networks: map[string]*network.EndpointSettings{
+				"net": ep}}}, nil)
+		mockNetSidecar(t, cli, name, "0", 0, &scripts)
+	}
+
+	ds := NewDockerService(testNetRepo(), config.Docker{PartitionImage: "iptables"}, nil, logrus.New())
+	res := ds.PartitionNetwork(nil, entity.DockerCli{Client: cli}, entity.PartitionNetwork{
+		Network: "net",
+		Groups:  [][]string{{"node0"}, {"node1", "node2"}},
+		OneWay:  true,
+	})
+	require.NoError(t, res.Error)
+	assert.Equal(t, defaultPartition, res.Meta["name"])
+	assert.Equal(t, map[string][]string{
+		"node0": {},
+		"node1": {"10.1.0.2", "fd00:1::2"},
+		"node2": {"10.1.0.2", "fd00:1::2"},
+	}, res.Meta["dropped"])
+
+	require.Len(t, scripts, 3)
+	sort.Strings(scripts)
+	assert.NotContains(t, scripts[0], "-j DROP")
+	assert.Contains(t, scripts[1], "iptables -I INPUT -s 10.1.0.2 -m comment --comment genesis-partition:default -j DROP")
+	assert.Contains(t, scripts[1], "ip6tables -I INPUT -s fd00:1::2 -m comment --comment genesis-partition:default -j DROP")
+	cli.AssertExpectations(t)
+}
+
+func TestDockerService_HealNetwork(t *testing.T) {
+	scripts := []string{}
+	cli := new(entityMock.Client)
+	cli.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{
+		{Names: []string{"/node0"}},
+		{Names: []string{"/node1"}},
+		{Names: []string{"/node0-netem"}, Labels: map[string]string{entity.SidecarLabel: NetemSidecar}},
+	}, nil).Run(func(args mock.Arguments) {
+		assert.False(t, args.Get(1).(types.ContainerListOptions).All)
+	}).Once()
+	mockNetSidecar(t, cli, "node0", "2", 0, &scripts)
+	mockNetSidecar(t, cli, "node1", "", 1, &scripts)
+
+	ds := NewDockerService(testNetRepo(), config.Docker{}, nil, logrus.New())
+	res := ds.HealNetwork(nil, entity.DockerCli{Client: cli, Labels: map[string]string{
+		command.TestIDKey: "test"}}, entity.HealNetwork{Name: "split"})
+	assert.Error(t, res.Error)
+	assert.Equal(t, map[string]int{"node0": 2}, res.Meta["removed"])
+	assert.Contains(t, res.Meta["failed"], "node1")
+	for _, script := range scripts {
+		assert.Contains(t, script, healScript("split"))
+		assert.NotContains(t, script, "-j DROP")
+	}
+	cli.AssertExpectations(t)
+}
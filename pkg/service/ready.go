@@ -0,0 +1,218 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultReadyTimeout is how long to wait for a container to become ready when no timeout is given
+	DefaultReadyTimeout = time.Minute
+
+	// DefaultReadyInterval is how long to wait between checks when no interval is given
+	DefaultReadyInterval = time.Second
+)
+
+// readyCheck checks once whether a container is ready, giving the reason when it is not
+type readyCheck func(ctx context.Context) (ready bool, reason string)
+
+// containerAddress finds the IP address of the container, preferring the networks in name order and
+// IPv4 over IPv6, so that a container which is only on IPv6 networks has one as well
+func containerAddress(info types.ContainerJSON) string {
+	if info.NetworkSettings == nil {
+		return ""
+	}
+	names := make([]string, 0, len(info.NetworkSettings.Networks))
+	for name := range info.NetworkSettings.Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ep := info.NetworkSettings.Networks[name]; ep != nil && len(ep.IPAddress) > 0 {
+			return ep.IPAddress
+		}
+	}
+	if len(info.NetworkSettings.IPAddress) > 0 {
+		return info.NetworkSettings.IPAddress
+	}
+	for _, name := range names {
+		if ep := info.NetworkSettings.Networks[name]; ep != nil && len(ep.GlobalIPv6Address) > 0 {
+			return ep.GlobalIPv6Address
+		}
+	}
+	return info.NetworkSettings.GlobalIPv6Address
+}
+
+// shellQuote quotes the value for a shell script
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'"'"'`, -1) + "'"
+}
+
+// checkTimeout is how many seconds a single check may take, which is the interval rounded up
+func checkTimeout(interval time.Duration) int {
+	secs := int(math.Ceil(interval.Seconds()))
+	if secs < 1 {
+		return 1
+	}
+	return secs
+}
+
+func (ds dockerService) healthCheck(cli entity.DockerCli, wr entity.WaitForReady) readyCheck {
+	return func(ctx context.Context) (bool, string) {
+		info, err := cli.ContainerInspect(ctx, wr.Container)
+		if err != nil {
+			return false, err.Error()
+		}
+		if info.State == nil || info.State.Health == nil {
+			return false, "the container does not have a healthcheck"
+		}
+		return info.State.Health.Status == types.Healthy, "the container is " + info.State.Health.Status
+	}
+}
+
+// tcpCheck connects to the port from a sidecar in the network namespace of the container, since genesis
+// is usually unable to reach the addresses of the containers on a remote docker host
+func (ds dockerService) tcpCheck(cli entity.DockerCli, wr entity.WaitForReady, host string,
+	interval time.Duration) readyCheck {
+
+	script := fmt.Sprintf("nc -z -w %d %s %d\n", checkTimeout(interval), shellQuote(host), wr.Port)
+	return func(ctx context.Context) (bool, string) {
+		out, err := ds.runNetSidecar(ctx, cli, ReadySidecar, ds.conf.PartitionImage, wr.Container, script)
+		if err != nil {
+			return false, err.Error()
+		}
+		if out.ExitCode != 0 {
+			return false, fmt.Sprintf("port %d is not accepting connections %s", wr.Port, out.Stderr)
+		}
+		return true, ""
+	}
+}
+
+// httpCheck makes the request from a sidecar in the network namespace of the container, like tcpCheck
+func (ds dockerService) httpCheck(cli entity.DockerCli, wr entity.WaitForReady, host string,
+	interval time.Duration) readyCheck {
+
+	url := "http://" + net.JoinHostPort(host, strconv.Itoa(wr.Port)) + "/" + strings.TrimPrefix(wr.Path, "/")
+	script := fmt.Sprintf("curl -sg -o /dev/null -w '%%{http_code}' --max-time %d %s\n",
+		checkTimeout(interval), shellQuote(url))
+	return func(ctx context.Context) (bool, string) {
+		out, err := ds.runNetSidecar(ctx, cli, ReadySidecar, ds.conf.PartitionImage, wr.Container, script)
+		if err != nil {
+			return false, err.Error()
+		}
+		status, _ := strconv.Atoi(out.Stdout)
+		if status == 0 {
+			return false, fmt.Sprintf("unable to reach %s (exit code %d) %s", url, out.ExitCode, out.Stderr)
+		}
+		return status >= 200 && status < 300, "got status " + out.Stdout
+	}
+}
+
+func (ds dockerService) logCheck(cli entity.DockerCli, wr entity.WaitForReady) readyCheck {
+	pattern := regexp.MustCompile(wr.Pattern) // validated by the usecase
+	return func(ctx context.Context) (bool, string) {
+		stdout, stderr, err := ds.containerLogs(ctx, cli, wr.Container, entity.CollectLogs{})
+		if err != nil {
+			return false, err.Error()
+		}
+		if pattern.Match(stdout.Bytes()) || pattern.Match(stderr.Bytes()) {
+			return true, ""
+		}
+		return false, "the logs do not match " + wr.Pattern
+	}
+}
+
+// readyCheck creates the check for the payload
+func (ds dockerService) readyCheck(ctx context.Context, cli entity.DockerCli,
+	wr entity.WaitForReady, interval time.Duration) (readyCheck, error) {
+
+	switch wr.Check {
+	case entity.ReadyCheckHealth:
+		return ds.healthCheck(cli, wr), nil
+	case entity.ReadyCheckLog:
+		return ds.logCheck(cli, wr), nil
+	}
+	host := wr.Host
+	if len(host) == 0 {
+		info, err := cli.ContainerInspect(ctx, wr.Container)
+		if err != nil {
+			return nil, err
+		}
+		host = containerAddress(info)
+		if len(host) == 0 {
+			return nil, fmt.Errorf("container \"%s\" does not have an IP address", wr.Container)
+		}
+	}
+	if wr.Check == entity.ReadyCheckTCP {
+		return ds.tcpCheck(cli, wr, host, interval), nil
+	}
+	return ds.httpCheck(cli, wr, host, interval), nil
+}
+
+// WaitForReady repeatedly checks whether a container is ready, until it is or the timeout
+// is reached. Not becoming ready in time is an error rather than fatal, so that the command gets retried.
+func (ds dockerService) WaitForReady(ctx context.Context, cli entity.DockerCli,
+	wr entity.WaitForReady) entity.Result {
+
+	timeout := wr.Timeout.Duration
+	if timeout == 0 {
+		timeout = DefaultReadyTimeout
+	}
+	interval := wr.Interval.Duration
+	if interval == 0 {
+		interval = DefaultReadyInterval
+	}
+	meta := map[string]interface{}{
+		"container": wr.Container,
+		"check":     wr.Check,
+		"type":      "WaitForReady",
+	}
+
+	check, err := ds.readyCheck(ctx, cli, wr, interval)
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+
+	start := time.Now()
+	deadline := start.Add(timeout)
+	for attempt := 1; ; attempt++ {
+		ready, reason := check(ctx)
+		meta["attempts"] = attempt
+		if ready {
+			ds.withFields(cli, logrus.Fields{"container": wr.Container, "check": wr.Check}).Info(
+				"the container is ready")
+			meta["waited"] = time.Since(start).String()
+			return entity.NewSuccessResult().InjectMeta(meta)
+		}
+		ds.withFields(cli, logrus.Fields{"container": wr.Container, "check": wr.Check,
+			"reason": reason}).Debug("the container is not ready yet")
+
+		if time.Now().Add(interval).After(deadline) {
+			return entity.NewErrorResult(fmt.Sprintf("container \"%s\" did not become ready within %v: %s",
+				wr.Container, timeout, reason)).InjectMeta(meta)
+		}
+		select {
+		case <-ctx.Done():
+			return entity.NewErrorResult(ctx.Err()).InjectMeta(meta)
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,162 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	entityMock "github.com/whiteblock/genesis/mocks/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/config"
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/whiteblock/definition/command"
+)
+
+func readyDuration(t *testing.T, d string) command.Duration {
+	var out command.Duration
+	require.NoError(t, out.UnmarshalJSON([]byte(`"`+d+`"`)))
+	return out
+}
+
+func healthState(status string) types.ContainerJSON {
+	return types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{
+		State: &types.ContainerState{Health: &types.Health{Status: status}}}}
+}
+
+func TestDockerService_WaitForReady_Health(t *testing.T) {
+	cli := new(entityMock.Client)
+	cli.On("ContainerInspect", mock.Anything, "node0").Return(healthState(types.Starting), nil).Twice()
+	cli.On("ContainerInspect", mock.Anything, "node0").Return(healthState(types.Healthy), nil).Once()
+
+	ds := NewDockerService(nil, config.Docker{}, nil, logrus.New())
+	res := ds.WaitForReady(context.Background(), entity.DockerCli{Client: cli}, entity.WaitForReady{
+		Container: "node0",
+		Check:     entity.ReadyCheckHealth,
+		Interval:  readyDuration(t, "1ms"),
+	})
+	assert.NoError(t, res.Error)
+	assert.Equal(t, 3, res.Meta["attempts"])
+	cli.AssertExpectations(t)
+}
+
+func TestDockerService_WaitForReady_Timeout(t *testing.T) {
+	cli := new(entityMock.Client)
+	cli.On("ContainerInspect", mock.Anything, "node0").Return(healthState(types.Unhealthy), nil)
+
+	ds := NewDockerService(nil, config.Docker{}, nil, logrus.New())
+	res := ds.WaitForReady(context.Background(), entity.DockerCli{Client: cli}, entity.WaitForReady{
+		Container: "node0",
+		Check:     entity.ReadyCheckHealth,
+		Timeout:   readyDuration(t, "20ms"),
+		Interval:  readyDuration(t, "5ms"),
+	})
+	assert.Error(t, res.Error)
+	assert.False(t, res.IsFatal())
+	assert.Contains(t, res.Error.Error(), "unhealthy")
+}
+
+func TestDockerService_WaitForReady_TCP(t *testing.T) {
+	scripts := []string{}
+	cli := new(entityMock.Client)
+	cli.On("ContainerInspect", mock.Anything, "node0").Return(types.ContainerJSON{
+		NetworkSettings: &types.NetworkSettings{Networks: map[string]*network.EndpointSettings{
+			"b": {GlobalIPv6Address: "fd00:2::2"},
+			"a": {GlobalIPv6Address: "fd00:1::2"},
+		}}}, nil).Once()
+	mockNetSidecar(t, cli, "node0", "", 1, &scripts)
+	mockNetSidecar(t, cli, "node0", "", 0, &scripts)
+
+	ds := NewDockerService(testNetRepo(), config.Docker{PartitionImage: "netshoot"}, nil, logrus.New())
+	res := ds.WaitForReady(context.Background(), entity.DockerCli{Client: cli}, entity.WaitForReady{
+		Container: "node0",
+		Check:     entity.ReadyCheckTCP,
+		Port:      8545,
+		Interval:  readyDuration(t, "1ms"),
+	})
+	assert.NoError(t, res.Error)
+	assert.Equal(t, 2, res.Meta["attempts"])
+	require.Len(t, scripts, 2)
+	assert.Equal(t, "nc -z -w 1 'fd00:1::2' 8545\n", scripts[0])
+	cli.AssertExpectations(t)
+}
+
+func TestDockerService_WaitForReady_HTTP(t *testing.T) {
+	scripts := []string{}
+	cli := new(entityMock.Client)
+	mockNetSidecar(t, cli, "node0", "000", 7, &scripts)
+	mockNetSidecar(t, cli, "node0", "503", 0, &scripts)
+	mockNetSidecar(t, cli, "node0", "200", 0, &scripts)
+
+	ds := NewDockerService(testNetRepo(), config.Docker{PartitionImage: "netshoot"}, nil, logrus.New())
+	res := ds.WaitForReady(context.Background(), entity.DockerCli{Client: cli}, entity.WaitForReady{
+		Container: "node0",
+		Check:     entity.ReadyCheckHTTP,
+		Host:      "localhost",
+		Port:      8545,
+		Path:      "health",
+		Interval:  readyDuration(t, "1ms"),
+	})
+	assert.NoError(t, res.Error)
+	assert.Equal(t, 3, res.Meta["attempts"])
+	require.Len(t, scripts, 3)
+	assert.Equal(t, "curl -sg -o /dev/null -w '%{http_code}' --max-time 1 'http://localhost:8545/health'\n",
+		scripts[0])
+	cli.AssertExpectations(t)
+}
+
+func TestContainerAddress(t *testing.T) {
+	assert.Equal(t, "10.0.0.2", containerAddress(types.ContainerJSON{
+		NetworkSettings: &types.NetworkSettings{Networks: map[string]*network.EndpointSettings{
+			"a": {GlobalIPv6Address: "fd00:1::2"},
+			"b": {IPAddress: "10.0.0.2", GlobalIPv6Address: "fd00:2::2"},
+		}}}))
+	assert.Equal(t, "fd00:1::2", containerAddress(types.ContainerJSON{
+		NetworkSettings: &types.NetworkSettings{Networks: map[string]*network.EndpointSettings{
+			"a": {GlobalIPv6Address: "fd00:1::2"},
+		}}}))
+	assert.Empty(t, containerAddress(types.ContainerJSON{}))
+}
+
+func TestDockerService_WaitForReady_Log(t *testing.T) {
+	logs := func(line string) *bytes.Buffer {
+		var buf bytes.Buffer
+		_, err := stdcopy.NewStdWriter(&buf, stdcopy.Stderr).Write([]byte(line))
+		require.NoError(t, err)
+		return &buf
+	}
+	cli := new(entityMock.Client)
+	cli.On("ContainerInspect", mock.Anything, "node0").Return(types.ContainerJSON{
+		Config: &container.Config{}}, nil)
+	cli.On("ContainerLogs", mock.Anything, "node0", mock.Anything).Return(
+		ioutil.NopCloser(logs("starting\n")), nil).Once()
+	cli.On("ContainerLogs", mock.Anything, "node0", mock.Anything).Return(
+		ioutil.NopCloser(logs("starting\nimported new chain segment\n")), nil).Once()
+
+	ds := NewDockerService(nil, config.Docker{}, nil, logrus.New())
+	start := time.Now()
+	res := ds.WaitForReady(context.Background(), entity.DockerCli{Client: cli}, entity.WaitForReady{
+		Container: "node0",
+		Check:     entity.ReadyCheckLog,
+		Pattern:   "new chain segment",
+		Interval:  readyDuration(t, "10ms"),
+	})
+	assert.NoError(t, res.Error)
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+	cli.AssertExpectations(t)
+}
@@ -0,0 +1,325 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/whiteblock/genesis/pkg/config"
+	"github.com/whiteblock/genesis/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/repository"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/sirupsen/logrus"
+	"github.com/whiteblock/definition/command"
+)
+
+// localHost is the key for the local docker daemon in local mode
+const localHost = "local"
+
+// ReaperService finds and removes the resources left behind by tests which are no longer being run
+type ReaperService interface {
+	// Track records that the test is being run on the given docker host
+	Track(host, testID string)
+
+	// Finish records that the test has ended, making its resources eligible to be reaped
+	Finish(testID string)
+
+	// Reap removes the orphaned resources from every known docker host. If dryRun is set,
+	// it only reports what would be removed.
+	Reap(ctx context.Context, dryRun bool) entity.ReapReport
+
+	// Start periodically reaps until the context is done
+	Start(ctx context.Context)
+}
+
+type testActivity struct {
+	lastSeen time.Time
+	finished bool
+}
+
+type reaperService struct {
+	service DockerService
+	execs   repository.ExecutionRepository
+	conf    config.Reaper
+	log     logrus.Ext1FieldLogger
+
+	mu *sync.Mutex
+	// hosts maps each known docker host to a test which used it, for its credentials
+	hosts map[string]string
+	tests map[string]*testActivity
+}
+
+// NewReaperService creates a new ReaperService. The stored executions are consulted about the
+// tests which have not been tracked, such as those from before a restart, execs may be nil.
+func NewReaperService(
+	service DockerService,
+	execs repository.ExecutionRepository,
+	conf config.Reaper,
+	log logrus.Ext1FieldLogger) ReaperService {
+
+	return &reaperService{
+		service: service,
+		execs:   execs,
+		conf:    conf,
+		log:     log,
+		mu:      &sync.Mutex{},
+		hosts:   map[string]string{},
+		tests:   map[string]*testActivity{},
+	}
+}
+
+// Track records that the test is being run on the given docker host
+func (rs *reaperService) Track(host, testID string) {
+	if len(testID) == 0 {
+		return
+	}
+	if rs.conf.LocalMode {
+		host = localHost
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if len(host) > 0 {
+		rs.hosts[host] = testID
+	}
+	rs.tests[testID] = &testActivity{lastSeen: time.Now()}
+}
+
+// Finish records that the test has ended, making its resources eligible to be reaped
+func (rs *reaperService) Finish(testID string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.tests[testID] = &testActivity{lastSeen: time.Now(), finished: true}
+}
+
+func (rs *reaperService) knownHosts() map[string]string {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	out := map[string]string{}
+	for host, testID := range rs.hosts {
+		out[host] = testID
+	}
+	if _, ok := out[localHost]; rs.conf.LocalMode && !ok {
+		out[localHost] = ""
+	}
+	return out
+}
+
+// activity gets what is known about the test, from what has been tracked or, failing that, from
+// the stored executions of the test, which outlive restarts
+func (rs *reaperService) activity(testID string) (activity testActivity, tracked bool, err error) {
+	rs.mu.Lock()
+	known, ok := rs.tests[testID]
+	rs.mu.Unlock()
+	if ok {
+		return *known, true, nil
+	}
+	if rs.execs == nil {
+		return
+	}
+	execs, err := rs.execs.List()
+	if err != nil {
+		return
+	}
+	activity.finished = true
+	for _, exec := range execs {
+		if exec.Instructions.ID != testID {
+			continue
+		}
+		tracked = true
+		if exec.IsRunning() {
+			activity.finished = false
+		}
+		if exec.Updated.After(activity.lastSeen) {
+			activity.lastSeen = exec.Updated
+		}
+	}
+	return
+}
+
+// reason determines why the resources of a test should be reaped, if they should be
+func (rs *reaperService) reason(testID string, created time.Time) (string, bool) {
+	now := time.Now()
+	if rs.conf.TTL > 0 && now.Sub(created) > rs.conf.TTL {
+		return entity.ReapExpired, true
+	}
+	activity, tracked, err := rs.activity(testID)
+	if err != nil {
+		rs.log.WithFields(logrus.Fields{"test": testID, "error": err}).Warn(
+			"unable to tell whether a test is still being run, leaving it alone")
+		return "", false
+	}
+	if !tracked {
+		return entity.ReapUntracked, rs.conf.Untracked && now.Sub(created) > rs.conf.GracePeriod
+	}
+	if activity.finished {
+		return entity.ReapFinished, now.Sub(activity.lastSeen) > rs.conf.GracePeriod
+	}
+	return "", false
+}
+
+// scan groups the labelled resources on the docker host by the test they belong to
+func (rs *reaperService) scan(ctx context.Context, cli entity.Client,
+	host string) (map[string]*entity.ReapCandidate, error) {
+
+	found := map[string]*entity.ReapCandidate{}
+	get := func(labels map[string]string, created time.Time) *entity.ReapCandidate {
+		testID := labels[command.TestIDKey]
+		if _, ok := found[testID]; !ok {
+			found[testID] = &entity.ReapCandidate{Host: host, Test: testID, Created: created}
+		}
+		if !created.IsZero() && (found[testID].Created.IsZero() || created.Before(found[testID].Created)) {
+			found[testID].Created = created
+		}
+		return found[testID]
+	}
+	filter := filters.NewArgs(filters.Arg("label", command.TestIDKey))
+
+	cntrs, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: filter})
+	if err != nil {
+		return nil, err
+	}
+	for _, cntr := range cntrs {
+		candidate := get(cntr.Labels, time.Unix(cntr.Created, 0))
+		candidate.Containers = append(candidate.Containers, containerName(cntr))
+	}
+
+	nets, err := cli.NetworkList(ctx, types.NetworkListOptions{Filters: filter})
+	if err != nil {
+		return nil, err
+	}
+	for _, net := range nets {
+		candidate := get(net.Labels, net.Created)
+		candidate.Networks = append(candidate.Networks, net.Name)
+	}
+
+	vols, err := cli.VolumeList(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, vol := range vols.Volumes {
+		created, _ := time.Parse(time.RFC3339, vol.CreatedAt)
+		candidate := get(vol.Labels, created)
+		candidate.Volumes = append(candidate.Volumes, vol.Name)
+	}
+	delete(found, "")
+	return found, nil
+}
+
+func (rs *reaperService) reapHost(ctx context.Context, host string, testID string,
+	dryRun bool) ([]entity.ReapCandidate, error) {
+
+	cli, err := rs.service.CreateClient2(host, testID)
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	found, err := rs.scan(ctx, cli, host)
+	if err != nil {
+		return nil, err
+	}
+	out := []entity.ReapCandidate{}
+	for _, candidate := range found {
+		reason, ok := rs.reason(candidate.Test, candidate.Created)
+		if !ok {
+			continue
+		}
+		candidate.Reason = reason
+		if !dryRun {
+			res := rs.service.Teardown(ctx, entity.DockerCli{
+				Client: cli,
+				Labels: map[string]string{command.TestIDKey: candidate.Test},
+				TestID: candidate.Test,
+			}, entity.Teardown{TestID: candidate.Test})
+			candidate.Removed = res.IsSuccess()
+			candidate.Failed, _ = res.Meta["failed"].(map[string]string)
+			if len(candidate.Failed) == 0 {
+				candidate.Failed = nil
+			}
+		}
+		out = append(out, *candidate)
+	}
+	return out, nil
+}
+
+// Reap removes the orphaned resources from every known docker host
+func (rs *reaperService) Reap(ctx context.Context, dryRun bool) entity.ReapReport {
+	report := entity.ReapReport{
+		DryRun:     dryRun || rs.conf.DryRun,
+		Time:       time.Now(),
+		Candidates: []entity.ReapCandidate{},
+		Errors:     map[string]string{},
+	}
+	for host, testID := range rs.knownHosts() {
+		candidates, err := rs.reapHost(ctx, host, testID, report.DryRun)
+		if err != nil {
+			rs.log.WithFields(logrus.Fields{"host": host, "error": err}).Warn(
+				"unable to look for orphaned resources")
+			report.Errors[host] = err.Error()
+			continue
+		}
+		report.Candidates = append(report.Candidates, candidates...)
+	}
+	sort.Slice(report.Candidates, func(i, j int) bool {
+		return report.Candidates[i].Created.Before(report.Candidates[j].Created)
+	})
+
+	if len(report.Errors) == 0 {
+		rs.forget(report.Candidates)
+	}
+	return report
+}
+
+// forget stops tracking the finished tests which have nothing left to reap, so that they do not build up
+func (rs *reaperService) forget(candidates []entity.ReapCandidate) {
+	remaining := map[string]bool{}
+	for _, candidate := range candidates {
+		if !candidate.Removed {
+			remaining[candidate.Test] = true
+		}
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	for testID, activity := range rs.tests {
+		if activity.finished && !remaining[testID] && time.Since(activity.lastSeen) > rs.conf.GracePeriod {
+			delete(rs.tests, testID)
+		}
+	}
+}
+
+// Start periodically reaps until the context is done
+func (rs *reaperService) Start(ctx context.Context) {
+	if rs.conf.Interval <= 0 {
+		rs.log.Info("the periodic removal of orphaned resources is disabled")
+		return
+	}
+	ticker := time.NewTicker(rs.conf.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		report := rs.Reap(ctx, false)
+		for _, candidate := range report.Candidates {
+			rs.log.WithFields(logrus.Fields{
+				"test":    candidate.Test,
+				"host":    candidate.Host,
+				"reason":  candidate.Reason,
+				"dryRun":  report.DryRun,
+				"removed": candidate.Removed,
+				"failed":  candidate.Failed,
+			}).Info("found orphaned resources")
+		}
+	}
+}
@@ -0,0 +1,186 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	entityMock "github.com/whiteblock/genesis/mocks/pkg/entity"
+	serviceMock "github.com/whiteblock/genesis/mocks/pkg/service"
+	"github.com/whiteblock/genesis/pkg/config"
+	"github.com/whiteblock/genesis/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/repository"
+
+	"github.com/docker/docker/api/types"
+	dockerVolume "github.com/docker/docker/api/types/volume"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whiteblock/definition/command"
+)
+
+func reaperTestClient(old time.Time) *entityMock.Client {
+	cli := new(entityMock.Client)
+	cli.On("Close").Return(nil)
+	cli.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{
+		{ID: "1", Names: []string{"/old0"}, Created: old.Unix(),
+			Labels: map[string]string{command.TestIDKey: "old"}},
+		{ID: "2", Names: []string{"/running0"}, Created: old.Unix(),
+			Labels: map[string]string{command.TestIDKey: "running"}},
+		{ID: "3", Names: []string{"/new0"}, Created: time.Now().Unix(),
+			Labels: map[string]string{command.TestIDKey: "new"}},
+	}, nil)
+	cli.On("NetworkList", mock.Anything, mock.Anything).Return([]types.NetworkResource{
+		{Name: "oldnet", Created: old, Labels: map[string]string{command.TestIDKey: "old"}},
+	}, nil)
+	cli.On("VolumeList", mock.Anything, mock.Anything).Return(dockerVolume.VolumeListOKBody{
+		Volumes: []*types.Volume{{Name: "oldvol", Labels: map[string]string{command.TestIDKey: "old"}}},
+	}, nil)
+	return cli
+}
+
+func TestReaperService_Reap_DryRun(t *testing.T) {
+	cli := reaperTestClient(time.Now().Add(-time.Hour))
+	ds := new(serviceMock.DockerService)
+	ds.On("CreateClient2", "local", "running").Return(cli, nil).Once()
+
+	rs := NewReaperService(ds, nil, config.Reaper{LocalMode: true, GracePeriod: time.Minute,
+		Untracked: true}, logrus.New())
+	rs.Track("127.0.0.1", "running")
+	rs.Finish("finished")
+
+	report := rs.Reap(nil, true)
+	assert.True(t, report.DryRun)
+	assert.Empty(t, report.Errors)
+	if assert.Len(t, report.Candidates, 1) {
+		assert.Equal(t, "old", report.Candidates[0].Test)
+		assert.Equal(t, entity.ReapUntracked, report.Candidates[0].Reason)
+		assert.Equal(t, []string{"old0"}, report.Candidates[0].Containers)
+		assert.Equal(t, []string{"oldnet"}, report.Candidates[0].Networks)
+		assert.Equal(t, []string{"oldvol"}, report.Candidates[0].Volumes)
+		assert.False(t, report.Candidates[0].Removed)
+	}
+	ds.AssertExpectations(t)
+}
+
+func TestReaperService_Reap(t *testing.T) {
+	cli := reaperTestClient(time.Now().Add(-time.Hour))
+	ds := new(serviceMock.DockerService)
+	ds.On("CreateClient2", "local", "running").Return(cli, nil).Once()
+	ds.On("Teardown", mock.Anything, mock.Anything, entity.Teardown{TestID: "old"}).Return(
+		entity.NewSuccessResult()).Once()
+	ds.On("Teardown", mock.Anything, mock.Anything, entity.Teardown{TestID: "running"}).Return(
+		entity.NewErrorResult("oops").InjectMeta(map[string]interface{}{
+			"failed": map[string]string{"container/running0": "oops"},
+		})).Once()
+
+	rs := NewReaperService(ds, nil, config.Reaper{LocalMode: true, TTL: 30 * time.Minute,
+		GracePeriod: time.Minute}, logrus.New())
+	rs.Track("127.0.0.1", "running")
+
+	report := rs.Reap(nil, false)
+	assert.False(t, report.DryRun)
+	assert.Len(t, report.Candidates, 2)
+	for _, candidate := range report.Candidates {
+		assert.Equal(t, entity.ReapExpired, candidate.Reason)
+		if candidate.Test == "old" {
+			assert.True(t, candidate.Removed)
+		} else {
+			assert.False(t, candidate.Removed)
+			assert.Equal(t, map[string]string{"container/running0": "oops"}, candidate.Failed)
+		}
+	}
+	ds.AssertExpectations(t)
+}
+
+func TestReaperService_Reap_Finished(t *testing.T) {
+	cli := reaperTestClient(time.Now().Add(-time.Hour))
+	ds := new(serviceMock.DockerService)
+	ds.On("CreateClient2", "local", "").Return(cli, nil).Once()
+
+	rs := NewReaperService(ds, nil, config.Reaper{LocalMode: true, DryRun: true, Untracked: true},
+		logrus.New())
+	rs.Finish("running")
+
+	report := rs.Reap(nil, false)
+	assert.True(t, report.DryRun)
+	tests := []string{}
+	for _, candidate := range report.Candidates {
+		tests = append(tests, candidate.Test)
+	}
+	assert.ElementsMatch(t, []string{"old", "running", "new"}, tests)
+	ds.AssertExpectations(t)
+}
+
+func TestReaperService_Reap_Untracked(t *testing.T) {
+	cli := reaperTestClient(time.Now().Add(-time.Hour))
+	ds := new(serviceMock.DockerService)
+	ds.On("CreateClient2", "local", "running").Return(cli, nil).Once()
+
+	rs := NewReaperService(ds, nil, config.Reaper{LocalMode: true, GracePeriod: time.Minute}, logrus.New())
+	rs.Track("127.0.0.1", "running")
+
+	report := rs.Reap(nil, false)
+	assert.Empty(t, report.Errors)
+	assert.Empty(t, report.Candidates)
+	ds.AssertExpectations(t)
+}
+
+func TestReaperService_Reap_Executions(t *testing.T) {
+	cli := reaperTestClient(time.Now().Add(-time.Hour))
+	ds := new(serviceMock.DockerService)
+	ds.On("CreateClient2", "local", "").Return(cli, nil).Twice()
+
+	execs := repository.NewMemoryExecutionRepository()
+	exec := entity.NewExecution("1", command.Instructions{ID: "old"})
+	assert.NoError(t, execs.Put(exec))
+
+	rs := NewReaperService(ds, execs, config.Reaper{LocalMode: true, DryRun: true, Untracked: true,
+		GracePeriod: time.Minute}, logrus.New())
+
+	report := rs.Reap(nil, true)
+	tests := []string{}
+	for _, candidate := range report.Candidates {
+		tests = append(tests, candidate.Test)
+	}
+	assert.ElementsMatch(t, []string{"running"}, tests)
+
+	exec.State = entity.ExecutionFinished
+	exec.Updated = time.Now().Add(-time.Hour)
+	assert.NoError(t, execs.Put(exec))
+
+	report = rs.Reap(nil, true)
+	reasons := map[string]string{}
+	for _, candidate := range report.Candidates {
+		reasons[candidate.Test] = candidate.Reason
+	}
+	assert.Equal(t, map[string]string{"old": entity.ReapFinished, "running": entity.ReapUntracked}, reasons)
+	ds.AssertExpectations(t)
+}
+
+func TestReaperService_Reap_Forget(t *testing.T) {
+	cli := reaperTestClient(time.Now().Add(-time.Hour))
+	ds := new(serviceMock.DockerService)
+	ds.On("CreateClient2", "local", "").Return(cli, nil).Once()
+	ds.On("Teardown", mock.Anything, mock.Anything, entity.Teardown{TestID: "running"}).Return(
+		entity.NewErrorResult("oops")).Once()
+
+	rs := NewReaperService(ds, nil, config.Reaper{LocalMode: true}, logrus.New())
+	rs.Finish("running")
+	rs.Finish("gone")
+
+	report := rs.Reap(nil, false)
+	assert.Empty(t, report.Errors)
+	if assert.Len(t, report.Candidates, 1) {
+		assert.False(t, report.Candidates[0].Removed)
+	}
+	tests := rs.(*reaperService).tests
+	assert.Contains(t, tests, "running")
+	assert.NotContains(t, tests, "gone")
+	ds.AssertExpectations(t)
+}
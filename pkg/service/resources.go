@@ -0,0 +1,69 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"context"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/sirupsen/logrus"
+)
+
+// UpdateContainerResources changes the resource limits of a running container, leaving the ones
+// which are not given alone
+func (ds dockerService) UpdateContainerResources(ctx context.Context, cli entity.DockerCli,
+	ucr entity.UpdateContainerResources) entity.Result {
+
+	meta := map[string]interface{}{"name": ucr.Name, "type": "UpdateContainerResources"}
+	nanoCPUs, err := ucr.GetNanoCPUs()
+	if err != nil {
+		return entity.NewFatalResult(err).InjectMeta(meta)
+	}
+	memory, err := ucr.GetMemory()
+	if err != nil {
+		return entity.NewFatalResult(err).InjectMeta(meta)
+	}
+	memorySwap, err := ucr.GetMemorySwap()
+	if err != nil {
+		return entity.NewFatalResult(err).InjectMeta(meta)
+	}
+	resources := container.Resources{
+		NanoCPUs:   nanoCPUs,
+		CpusetCpus: ucr.GetCPUSet(),
+		Memory:     memory,
+		MemorySwap: memorySwap,
+		PidsLimit:  ucr.PidsLimit,
+	}
+	ds.withFields(cli, logrus.Fields{"name": ucr.Name, "resources": resources}).Debug(
+		"updating the resources of a container")
+
+	body, err := cli.ContainerUpdate(ctx, ucr.Name, container.UpdateConfig{Resources: resources})
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+	if nanoCPUs > 0 {
+		meta["nanoCPUs"] = nanoCPUs
+	}
+	if len(resources.CpusetCpus) > 0 {
+		meta["cpuset"] = resources.CpusetCpus
+	}
+	if memory > 0 {
+		meta["memory"] = memory
+	}
+	if memorySwap != 0 {
+		meta["memorySwap"] = memorySwap
+	}
+	if ucr.PidsLimit != nil {
+		meta["pidsLimit"] = *ucr.PidsLimit
+	}
+	if len(body.Warnings) > 0 {
+		meta["warnings"] = body.Warnings
+	}
+	return entity.NewSuccessResult().InjectMeta(meta)
+}
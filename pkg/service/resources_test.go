@@ -0,0 +1,66 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	entityMock "github.com/whiteblock/genesis/mocks/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/config"
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerService_UpdateContainerResources(t *testing.T) {
+	pids := int64(64)
+	cli := new(entityMock.Client)
+	cli.On("ContainerUpdate", mock.Anything, "node0", container.UpdateConfig{
+		Resources: container.Resources{
+			NanoCPUs:   500000000,
+			CpusetCpus: "0,3",
+			Memory:     512 * 1024 * 1024,
+			MemorySwap: -1,
+			PidsLimit:  &pids,
+		},
+	}).Return(container.ContainerUpdateOKBody{Warnings: []string{"no swap limit support"}}, nil).Once()
+	cli.On("ContainerUpdate", mock.Anything, "node1", container.UpdateConfig{
+		Resources: container.Resources{Memory: 1024 * 1024 * 1024},
+	}).Return(container.ContainerUpdateOKBody{}, fmt.Errorf("No such container: node1")).Once()
+
+	ds := NewDockerService(nil, config.Docker{}, nil, logrus.New())
+
+	res := ds.UpdateContainerResources(nil, entity.DockerCli{Client: cli}, entity.UpdateContainerResources{
+		Name:       "node0",
+		Cpus:       "0.5",
+		BoundCPUs:  []int{3, 0},
+		Memory:     "512MB",
+		MemorySwap: "-1",
+		PidsLimit:  &pids,
+	})
+	require.NoError(t, res.Error)
+	assert.Equal(t, int64(500000000), res.Meta["nanoCPUs"])
+	assert.Equal(t, "0,3", res.Meta["cpuset"])
+	assert.Equal(t, int64(-1), res.Meta["memorySwap"])
+	assert.Equal(t, int64(64), res.Meta["pidsLimit"])
+	assert.Equal(t, []string{"no swap limit support"}, res.Meta["warnings"])
+
+	res = ds.UpdateContainerResources(nil, entity.DockerCli{Client: cli},
+		entity.UpdateContainerResources{Name: "node1", Memory: "1g"})
+	assert.Error(t, res.Error)
+	assert.False(t, res.IsFatal())
+
+	res = ds.UpdateContainerResources(nil, entity.DockerCli{Client: cli},
+		entity.UpdateContainerResources{Name: "node2", Cpus: "all"})
+	assert.True(t, res.IsFatal())
+	cli.AssertExpectations(t)
+}
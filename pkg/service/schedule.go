@@ -0,0 +1,253 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/file"
+	"github.com/whiteblock/genesis/pkg/validator"
+
+	"github.com/sirupsen/logrus"
+	"github.com/whiteblock/definition/command"
+)
+
+// The states of an emulation schedule which are reported in its events
+const (
+	// ScheduleApplied means that an entry of the schedule was applied
+	ScheduleApplied = "applied"
+	// ScheduleFailed means that an entry of the schedule could not be applied
+	ScheduleFailed = "failed"
+	// ScheduleFinished means that every entry of the schedule has been applied
+	ScheduleFinished = "finished"
+	// ScheduleCancelled means that the schedule was stopped before it finished
+	ScheduleCancelled = "cancelled"
+)
+
+// ScheduleService runs emulation schedules in the background
+type ScheduleService interface {
+	// Start begins applying the entries of the schedule at their offsets, in the background. A
+	// schedule of the test with the same name is replaced. Each transition is published as an event.
+	Start(cli entity.DockerCli, host string, es entity.EmulationSchedule) entity.Result
+
+	// Cancel stops all of the schedules of the test, returning the names of the ones which were running
+	Cancel(testID string) []string
+}
+
+type scheduleService struct {
+	service DockerService
+	events  EventService
+	remote  file.RemoteSources
+	log     logrus.Ext1FieldLogger
+	jobs    jobRegistry
+}
+
+// NewScheduleService creates a new ScheduleService
+func NewScheduleService(
+	service DockerService,
+	events EventService,
+	remote file.RemoteSources,
+	log logrus.Ext1FieldLogger) ScheduleService {
+
+	return &scheduleService{
+		service: service,
+		events:  events,
+		remote:  remote,
+		log:     log,
+		jobs:    newJobRegistry(),
+	}
+}
+
+// parseTrace reads the entries of a schedule from a CSV trace. The first row names the columns, which
+// are offset and the fields of the netem parameters, such as "offset,delay,loss,rate". Empty cells are
+// left unset and lines starting with # are ignored.
+func parseTrace(rdr io.Reader) ([]entity.ScheduleEntry, error) {
+	r := csv.NewReader(rdr)
+	r.Comment = '#'
+	r.TrimLeadingSpace = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("the trace has no entries")
+	}
+	header := rows[0]
+	out := make([]entity.ScheduleEntry, len(rows)-1)
+	for i, row := range rows[1:] {
+		fields := map[string]interface{}{}
+		for j, cell := range row {
+			cell = strings.TrimSpace(cell)
+			if len(cell) == 0 {
+				continue
+			}
+			if num, err := strconv.ParseFloat(cell, 64); err == nil {
+				fields[strings.TrimSpace(header[j])] = num
+			} else if b, err := strconv.ParseBool(cell); err == nil {
+				fields[strings.TrimSpace(header[j])] = b
+			} else {
+				fields[strings.TrimSpace(header[j])] = cell
+			}
+		}
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&out[i])
+		if err != nil {
+			return nil, fmt.Errorf("line %d of the trace: %v", i+2, err)
+		}
+	}
+	return out, nil
+}
+
+// entries gets the entries of the schedule, reading them from the trace file if it has one
+func (ss *scheduleService) entries(cli entity.DockerCli, es entity.EmulationSchedule) ([]entity.ScheduleEntry,
+	entity.Result) {
+
+	entries := es.Entries
+	if len(es.TraceFile) > 0 {
+		rdr, err := ss.remote.GetFile(cli.Labels[command.DefinitionIDKey], command.File{ID: es.TraceFile})
+		if err != nil {
+			return nil, entity.NewErrorResult(err)
+		}
+		defer rdr.Close()
+		entries, err = parseTrace(rdr)
+		if err != nil {
+			return nil, entity.NewFatalResult(err)
+		}
+	}
+	err := validator.ScheduleEntries(entries)
+	if err != nil {
+		return nil, entity.NewFatalResult(err)
+	}
+	out := make([]entity.ScheduleEntry, len(entries))
+	copy(out, entries)
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Offset.Duration < out[j].Offset.Duration
+	})
+	return out, entity.NewSuccessResult()
+}
+
+// Start begins applying the entries of the schedule at their offsets, in the background
+func (ss *scheduleService) Start(cli entity.DockerCli, host string, es entity.EmulationSchedule) entity.Result {
+	name := es.ScheduleName()
+	meta := map[string]interface{}{
+		"schedule":  name,
+		"container": es.Container,
+		"network":   es.Network,
+		"type":      "EmulationSchedule",
+	}
+	entries, res := ss.entries(cli, es)
+	if !res.IsSuccess() {
+		return res.InjectMeta(meta)
+	}
+
+	testID := testIDOf(cli)
+	labels := map[string]string{}
+	for key, value := range cli.Labels {
+		labels[key] = value
+	}
+	replaced := ss.jobs.start(testID, name, func(ctx context.Context) {
+		ss.run(ctx, host, entity.DockerCli{Labels: labels, TestID: testID}, name, es, entries)
+	})
+
+	meta["entries"] = len(entries)
+	meta["duration"] = entries[len(entries)-1].Offset.Duration.String()
+	meta["replaced"] = replaced
+	return entity.NewSuccessResult().InjectMeta(meta)
+}
+
+// run applies each of the entries once its offset has passed, until they have all been applied
+// or the schedule is cancelled
+func (ss *scheduleService) run(ctx context.Context, host string, cli entity.DockerCli, name string,
+	es entity.EmulationSchedule, entries []entity.ScheduleEntry) {
+
+	log := ss.log.WithFields(logrus.Fields{"test": cli.TestID, "schedule": name})
+
+	client, err := ss.service.CreateClient2(host, cli.TestID)
+	if err != nil {
+		log.WithField("error", err).Error("failed to create a client for an emulation schedule")
+		res := entity.NewErrorResult(err)
+		ss.publish(cli.TestID, name, ScheduleFailed, &res, nil)
+		return
+	}
+	defer client.Close()
+	cli.Client = client
+
+	start := time.Now()
+	applied := 0
+	for i, entry := range entries {
+		timer := time.NewTimer(time.Until(start.Add(entry.Offset.Duration)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			ss.publish(cli.TestID, name, ScheduleCancelled, nil, map[string]interface{}{"applied": applied})
+			return
+		case <-timer.C:
+		}
+
+		res := ss.service.UpdateEmulation(ctx, cli, entity.Emulation{
+			Container: es.Container,
+			Network:   es.Network,
+			Netem:     entry.Netem,
+		})
+		state := ScheduleApplied
+		if res.IsSuccess() {
+			applied++
+		} else {
+			state = ScheduleFailed
+			log.WithFields(logrus.Fields{"entry": i, "error": res.Error}).Warn(
+				"failed to apply an entry of an emulation schedule")
+		}
+		ss.publish(cli.TestID, name, state, &res, map[string]interface{}{
+			"entry":  i,
+			"offset": entry.Offset.Duration.String(),
+		})
+	}
+
+	if ctx.Err() != nil {
+		ss.publish(cli.TestID, name, ScheduleCancelled, nil, map[string]interface{}{"applied": applied})
+		return
+	}
+	log.WithField("applied", applied).Info("finished an emulation schedule")
+	ss.publish(cli.TestID, name, ScheduleFinished, nil, map[string]interface{}{"applied": applied})
+}
+
+func (ss *scheduleService) publish(testID string, name string, state string, res *entity.Result,
+	data map[string]interface{}) {
+
+	ev := entity.NewEvent(entity.ScheduleEvent, testID)
+	ev.Result = res
+	ev.Data = map[string]interface{}{"schedule": name, "state": state}
+	for key, value := range data {
+		ev.Data[key] = value
+	}
+	ss.events.Publish(ev)
+}
+
+// Cancel stops all of the schedules of the test, and waits for them to stop
+func (ss *scheduleService) Cancel(testID string) []string {
+	names := ss.jobs.cancel(testID)
+	if len(names) > 0 {
+		ss.log.WithFields(logrus.Fields{"test": testID, "schedules": names}).Info(
+			"cancelled the emulation schedules")
+	}
+	return names
+}
@@ -0,0 +1,175 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	entityMock "github.com/whiteblock/genesis/mocks/pkg/entity"
+	fileMock "github.com/whiteblock/genesis/mocks/pkg/file"
+	serviceMock "github.com/whiteblock/genesis/mocks/pkg/service"
+	"github.com/whiteblock/genesis/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/netem"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/whiteblock/definition/command"
+)
+
+func scheduleEntry(offset time.Duration, nm netem.Netem) entity.ScheduleEntry {
+	entry := entity.ScheduleEntry{Netem: nm}
+	entry.Offset.Duration = offset
+	return entry
+}
+
+func TestParseTrace(t *testing.T) {
+	entries, err := parseTrace(strings.NewReader("# a trace\n" +
+		"offset, delay, loss, rate\n" +
+		"0, 100000, , 10mbit\n" +
+		"30s, 200000, 1.5, \n"))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, time.Duration(0), entries[0].Offset.Duration)
+	assert.Equal(t, netem.Netem{Delay: 100000, Rate: "10mbit"}, entries[0].Netem)
+	assert.Equal(t, 30*time.Second, entries[1].Offset.Duration)
+	assert.Equal(t, netem.Netem{Delay: 200000, Loss: 1.5}, entries[1].Netem)
+
+	_, err = parseTrace(strings.NewReader("offset,latency\n0,100\n"))
+	assert.Error(t, err)
+
+	_, err = parseTrace(strings.NewReader("offset,delay\n"))
+	assert.Error(t, err)
+}
+
+func TestScheduleService_Start(t *testing.T) {
+	cli := new(entityMock.Client)
+	cli.On("Close").Return(nil).Once()
+	ds := new(serviceMock.DockerService)
+	ds.On("CreateClient2", "127.0.0.1", "test").Return(cli, nil).Once()
+	ds.On("UpdateEmulation", mock.Anything, mock.Anything, entity.Emulation{
+		Container: "node0", Network: "net", Netem: netem.Netem{Delay: 100},
+	}).Return(entity.NewSuccessResult()).Once()
+	ds.On("UpdateEmulation", mock.Anything, mock.Anything, entity.Emulation{
+		Container: "node0", Network: "net", Netem: netem.Netem{Loss: 5},
+	}).Return(entity.NewErrorResult("failed")).Once()
+
+	events := NewEventService(logrus.New())
+	sub, unsubscribe := events.Subscribe("test")
+	defer unsubscribe()
+
+	ss := NewScheduleService(ds, events, nil, logrus.New())
+	res := ss.Start(entity.DockerCli{TestID: "test"}, "127.0.0.1", entity.EmulationSchedule{
+		Container: "node0",
+		Network:   "net",
+		Entries: []entity.ScheduleEntry{
+			scheduleEntry(20*time.Millisecond, netem.Netem{Loss: 5}),
+			scheduleEntry(0, netem.Netem{Delay: 100}),
+		},
+	})
+	require.NoError(t, res.Error)
+	assert.Equal(t, "node0/net", res.Meta["schedule"])
+	assert.Equal(t, 2, res.Meta["entries"])
+
+	ev := nextEvent(t, sub, entity.ScheduleEvent)
+	assert.Equal(t, ScheduleApplied, ev.Data["state"])
+	assert.Equal(t, 0, ev.Data["entry"])
+	require.NotNil(t, ev.Result)
+	assert.True(t, ev.Result.IsSuccess())
+
+	ev = nextEvent(t, sub, entity.ScheduleEvent)
+	assert.Equal(t, ScheduleFailed, ev.Data["state"])
+	assert.Equal(t, "20ms", ev.Data["offset"])
+
+	ev = nextEvent(t, sub, entity.ScheduleEvent)
+	assert.Equal(t, ScheduleFinished, ev.Data["state"])
+	assert.Equal(t, 1, ev.Data["applied"])
+
+	assert.Empty(t, ss.Cancel("test"))
+	ds.AssertExpectations(t)
+	cli.AssertExpectations(t)
+}
+
+func TestScheduleService_Start_TraceFile(t *testing.T) {
+	remote := new(fileMock.RemoteSources)
+	remote.On("GetFile", "def", command.File{ID: "trace"}).Return(
+		ioutil.NopCloser(strings.NewReader("offset,delay\n0,100\n1h,200\n")), nil).Once()
+	cli := new(entityMock.Client)
+	cli.On("Close").Return(nil)
+	ds := new(serviceMock.DockerService)
+	ds.On("CreateClient2", mock.Anything, mock.Anything).Return(cli, nil)
+	ds.On("UpdateEmulation", mock.Anything, mock.Anything, mock.Anything).Return(entity.NewSuccessResult())
+
+	events := NewEventService(logrus.New())
+	sub, unsubscribe := events.Subscribe("test")
+	defer unsubscribe()
+
+	ss := NewScheduleService(ds, events, remote, logrus.New())
+	res := ss.Start(entity.DockerCli{
+		TestID: "test",
+		Labels: map[string]string{command.DefinitionIDKey: "def"},
+	}, "127.0.0.1", entity.EmulationSchedule{Name: "wan", Container: "node0", Network: "net", TraceFile: "trace"})
+	require.NoError(t, res.Error)
+	assert.Equal(t, "1h0m0s", res.Meta["duration"])
+
+	ev := nextEvent(t, sub, entity.ScheduleEvent)
+	assert.Equal(t, ScheduleApplied, ev.Data["state"])
+
+	assert.Equal(t, []string{"wan"}, ss.Cancel("test"))
+	ev = nextEvent(t, sub, entity.ScheduleEvent)
+	assert.Equal(t, ScheduleCancelled, ev.Data["state"])
+	assert.Equal(t, 1, ev.Data["applied"])
+	remote.AssertExpectations(t)
+}
+
+func TestScheduleService_Start_Replace(t *testing.T) {
+	cli := new(entityMock.Client)
+	cli.On("Close").Return(nil)
+	ds := new(serviceMock.DockerService)
+	ds.On("CreateClient2", mock.Anything, mock.Anything).Return(cli, nil)
+
+	events := NewEventService(logrus.New())
+	sub, unsubscribe := events.Subscribe("test")
+	defer unsubscribe()
+
+	ss := NewScheduleService(ds, events, nil, logrus.New())
+	es := entity.EmulationSchedule{
+		Container: "node0",
+		Network:   "net",
+		Entries:   []entity.ScheduleEntry{scheduleEntry(time.Hour, netem.Netem{Delay: 100})},
+	}
+	res := ss.Start(entity.DockerCli{TestID: "test"}, "127.0.0.1", es)
+	require.NoError(t, res.Error)
+	assert.Equal(t, false, res.Meta["replaced"])
+
+	res = ss.Start(entity.DockerCli{TestID: "test"}, "127.0.0.1", es)
+	require.NoError(t, res.Error)
+	assert.Equal(t, true, res.Meta["replaced"])
+
+	ev := nextEvent(t, sub, entity.ScheduleEvent)
+	assert.Equal(t, ScheduleCancelled, ev.Data["state"])
+
+	assert.Equal(t, []string{"node0/net"}, ss.Cancel("test"))
+	ev = nextEvent(t, sub, entity.ScheduleEvent)
+	assert.Equal(t, ScheduleCancelled, ev.Data["state"])
+	ds.AssertNotCalled(t, "UpdateEmulation", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestScheduleService_Start_Invalid(t *testing.T) {
+	ss := NewScheduleService(nil, nil, nil, logrus.New())
+	res := ss.Start(entity.DockerCli{TestID: "test"}, "127.0.0.1", entity.EmulationSchedule{
+		Container: "node0",
+		Network:   "net",
+		Entries:   []entity.ScheduleEntry{scheduleEntry(0, netem.Netem{Loss: 200})},
+	})
+	assert.True(t, res.IsFatal())
+	assert.Empty(t, ss.Cancel("test"))
+}
@@ -0,0 +1,319 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/whiteblock/genesis/pkg/config"
+	"github.com/whiteblock/genesis/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/file"
+	"github.com/whiteblock/genesis/pkg/repository"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+// statsCollector is the name of the background job which collects the stats of a test
+const statsCollector = "stats"
+
+// StatsService collects the resource usage of the containers of tests in the background
+type StatsService interface {
+	// Start begins sampling the resource usage of the running containers of the test, in the
+	// background. The collector of the test is replaced if it already has one.
+	Start(cli entity.DockerCli, host string, cs entity.CollectStats) entity.Result
+
+	// Samples fetches the samples of the given containers of the test, or of all of them when none are given
+	Samples(testID string, containers []string) ([]entity.StatsSample, error)
+
+	// Finish stops collecting the stats of the test, and archives its samples as an artifact
+	Finish(testID string) entity.Result
+}
+
+type statsService struct {
+	service DockerService
+	repo    repository.StatsRepository
+	remote  file.RemoteSources
+	conf    config.Stats
+	log     logrus.Ext1FieldLogger
+	jobs    jobRegistry
+}
+
+// NewStatsService creates a new StatsService
+func NewStatsService(
+	service DockerService,
+	repo repository.StatsRepository,
+	remote file.RemoteSources,
+	conf config.Stats,
+	log logrus.Ext1FieldLogger) StatsService {
+
+	return &statsService{
+		service: service,
+		repo:    repo,
+		remote:  remote,
+		conf:    conf,
+		log:     log,
+		jobs:    newJobRegistry(),
+	}
+}
+
+// statsStream is a subscription to the stats of a container, which keeps the latest of them
+type statsStream struct {
+	mu     *sync.Mutex
+	latest *types.StatsJSON
+	body   io.Closer
+	done   chan struct{}
+}
+
+// subscribe starts streaming the stats of the container
+func subscribe(ctx context.Context, cli entity.DockerCli, name string) (*statsStream, error) {
+	resp, err := cli.ContainerStats(ctx, name, true)
+	if err != nil {
+		return nil, err
+	}
+	stream := &statsStream{mu: &sync.Mutex{}, body: resp.Body, done: make(chan struct{})}
+	go func() {
+		defer close(stream.done)
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var stats types.StatsJSON
+			if dec.Decode(&stats) != nil {
+				return
+			}
+			stream.mu.Lock()
+			stream.latest = &stats
+			stream.mu.Unlock()
+		}
+	}()
+	return stream, nil
+}
+
+func (stream *statsStream) get() *types.StatsJSON {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	return stream.latest
+}
+
+// isDone checks whether the stream has ended, which it does when the container stops
+func (stream *statsStream) isDone() bool {
+	select {
+	case <-stream.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (stream *statsStream) close() {
+	stream.body.Close()
+	<-stream.done
+}
+
+// Start begins sampling the resource usage of the running containers of the test, in the background
+func (ss *statsService) Start(cli entity.DockerCli, host string, cs entity.CollectStats) entity.Result {
+	interval := cs.Interval.Duration
+	if interval <= 0 {
+		interval = ss.conf.Interval
+	}
+	testID := testIDOf(cli)
+	labels := map[string]string{}
+	for key, value := range cli.Labels {
+		labels[key] = value
+	}
+	replaced := ss.jobs.start(testID, statsCollector, func(ctx context.Context) {
+		ss.run(ctx, host, entity.DockerCli{Labels: labels, TestID: testID}, cs.Selector, interval)
+	})
+
+	return entity.NewSuccessResult().InjectMeta(map[string]interface{}{
+		"interval": interval.String(),
+		"replaced": replaced,
+		"type":     "CollectStats",
+	})
+}
+
+// run samples the containers every interval, until the collector is cancelled
+func (ss *statsService) run(ctx context.Context, host string, cli entity.DockerCli,
+	selector map[string]string, interval time.Duration) {
+
+	log := ss.log.WithField("test", cli.TestID)
+
+	client, err := ss.service.CreateClient2(host, cli.TestID)
+	if err != nil {
+		log.WithField("error", err).Error("failed to create a client for the stats collector")
+		return
+	}
+	defer client.Close()
+	cli.Client = client
+
+	streams := map[string]*statsStream{}
+	defer func() {
+		for _, stream := range streams {
+			stream.close()
+		}
+	}()
+	recorded := map[string]time.Time{}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		ss.sample(ctx, log, cli, selector, streams, recorded)
+	}
+}
+
+// sample stores the latest stats of each of the running containers which has new ones. Containers
+// which have started since the last sample are subscribed to, and those which have stopped are dropped.
+func (ss *statsService) sample(ctx context.Context, log logrus.Ext1FieldLogger, cli entity.DockerCli,
+	selector map[string]string, streams map[string]*statsStream, recorded map[string]time.Time) {
+
+	names, err := runningContainers(ctx, cli, selector)
+	if err != nil {
+		log.WithField("error", err).Warn("failed to list the containers to collect the stats of")
+		return
+	}
+	running := map[string]bool{}
+	samples := []entity.StatsSample{}
+	for _, name := range names {
+		running[name] = true
+		var stats *types.StatsJSON
+		stream := streams[name]
+		if stream != nil {
+			// an ended stream still holds the last stats it received
+			stats = stream.get()
+		}
+		if stream == nil || stream.isDone() {
+			if stream != nil {
+				stream.close()
+			}
+			delete(streams, name)
+			stream, err = subscribe(ctx, cli, name)
+			if err != nil {
+				log.WithFields(logrus.Fields{"container": name, "error": err}).Warn(
+					"failed to subscribe to the stats of a container")
+			} else {
+				streams[name] = stream
+			}
+		}
+		if stats == nil || !stats.Read.After(recorded[name]) {
+			continue
+		}
+		recorded[name] = stats.Read
+		samples = append(samples, entity.NewStatsSample(name, *stats))
+	}
+	for name, stream := range streams {
+		if !running[name] {
+			stream.close()
+			delete(streams, name)
+		}
+	}
+	if len(samples) == 0 {
+		return
+	}
+	err = ss.repo.Append(cli.TestID, samples)
+	if err != nil {
+		log.WithField("error", err).Error("failed to store the stats samples")
+	}
+}
+
+// Samples fetches the samples of the given containers of the test, or of all of them when none are given
+func (ss *statsService) Samples(testID string, containers []string) ([]entity.StatsSample, error) {
+	return ss.repo.Get(testID, containers...)
+}
+
+// writeStatsArchive writes a tar.gz containing a CSV of the samples of each of the containers
+func (ss *statsService) writeStatsArchive(testID string, containers []string, out io.Writer) (int, error) {
+	count := 0
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	for _, container := range containers {
+		samples, err := ss.repo.Get(testID, container)
+		if err != nil {
+			return count, err
+		}
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		err = w.Write(entity.StatsCSVHeader)
+		if err != nil {
+			return count, err
+		}
+		for _, sample := range samples {
+			err = w.Write(sample.CSVRecord())
+			if err != nil {
+				return count, err
+			}
+		}
+		w.Flush()
+		err = writeTarFile(tw, container+".csv", time.Now(), &buf)
+		if err != nil {
+			return count, err
+		}
+		count += len(samples)
+	}
+	err := tw.Close()
+	if err != nil {
+		return count, err
+	}
+	return count, gz.Close()
+}
+
+// Finish stops collecting the stats of the test, and archives its samples as an artifact. The
+// samples are removed once they have been archived.
+func (ss *statsService) Finish(testID string) entity.Result {
+	meta := map[string]interface{}{
+		"cancelled": len(ss.jobs.cancel(testID)) > 0,
+		"type":      "Stats",
+	}
+	containers, err := ss.repo.Containers(testID)
+	if errors.Is(err, repository.ErrStatsNotFound) {
+		return entity.NewSuccessResult().InjectMeta(meta)
+	}
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+
+	name := fmt.Sprintf("stats-%d.tar.gz", time.Now().Unix())
+	pr, pw := io.Pipe()
+	countChan := make(chan int, 1)
+	go func() {
+		count, err := ss.writeStatsArchive(testID, containers, pw)
+		pw.CloseWithError(err)
+		countChan <- count
+	}()
+	location, err := ss.remote.PutArtifact(testID, name, pr)
+	pr.CloseWithError(err)
+	count := <-countChan
+	if err != nil {
+		return entity.NewErrorResult(err).InjectMeta(meta)
+	}
+
+	err = ss.repo.Delete(testID)
+	if err != nil {
+		ss.log.WithFields(logrus.Fields{"test": testID, "error": err}).Warn(
+			"failed to remove the stats samples once they were archived")
+	}
+	ss.log.WithFields(logrus.Fields{"test": testID, "location": location, "samples": count}).Info(
+		"archived the stats of a test")
+	meta["artifact"] = name
+	meta["location"] = location
+	meta["containers"] = containers
+	meta["samples"] = count
+	return entity.NewSuccessResult().InjectMeta(meta)
+}
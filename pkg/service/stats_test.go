@@ -0,0 +1,124 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	serviceMock "github.com/whiteblock/genesis/mocks/pkg/service"
+	"github.com/whiteblock/genesis/pkg/config"
+	"github.com/whiteblock/genesis/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/repository"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/whiteblock/definition/command"
+)
+
+func TestStatsService(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genesis")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	repo := repository.NewFileStatsRepository(dir, logrus.New())
+
+	read := time.Now().UTC()
+	cli := chaosClient("node0", "node1")
+	cli.On("ContainerStats", mock.Anything, mock.Anything, true).Return(
+		func(ctx context.Context, name string, stream bool) types.ContainerStats {
+			var stats types.StatsJSON
+			stats.Read = read
+			stats.MemoryStats.Usage = 1024
+			data, err := json.Marshal(stats)
+			require.NoError(t, err)
+			return types.ContainerStats{Body: ioutil.NopCloser(bytes.NewReader(data))}
+		}, nil)
+
+	ds := new(serviceMock.DockerService)
+	ds.On("CreateClient2", "127.0.0.1", "test").Return(cli, nil).Once()
+
+	files := map[string][][]string{}
+	remote := &artifactSink{put: func(testID, name string, rdr io.Reader) (string, error) {
+		assert.Equal(t, "test", testID)
+		gz, err := gzip.NewReader(rdr)
+		require.NoError(t, err)
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			records, err := csv.NewReader(tr).ReadAll()
+			require.NoError(t, err)
+			files[hdr.Name] = records
+		}
+		return "/stats.tar.gz", nil
+	}}
+
+	ss := NewStatsService(ds, repo, remote, config.Stats{Interval: time.Hour}, logrus.New())
+	var interval command.Duration
+	interval.Duration = 10 * time.Millisecond
+	res := ss.Start(entity.DockerCli{TestID: "test"}, "127.0.0.1", entity.CollectStats{
+		Interval: interval,
+		Selector: map[string]string{"role": "validator"},
+	})
+	require.NoError(t, res.Error)
+	assert.Equal(t, "10ms", res.Meta["interval"])
+
+	// the same stats are only stored once, however many times they are sampled
+	var samples []entity.StatsSample
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		samples, err = ss.Samples("test", nil)
+		if err == nil && len(samples) == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Len(t, samples, 2)
+	time.Sleep(50 * time.Millisecond)
+	samples, err = ss.Samples("test", []string{"node1"})
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, uint64(1024), samples[0].MemoryUsage)
+
+	res = ss.Finish("test")
+	require.NoError(t, res.Error)
+	assert.Equal(t, true, res.Meta["cancelled"])
+	assert.Equal(t, []string{"node0", "node1"}, res.Meta["containers"])
+	assert.Equal(t, 2, res.Meta["samples"])
+	assert.Equal(t, "/stats.tar.gz", res.Meta["location"])
+	require.Contains(t, files, "node0.csv")
+	require.Contains(t, files, "node1.csv")
+	require.Len(t, files["node0.csv"], 2)
+	assert.Equal(t, entity.StatsCSVHeader, files["node0.csv"][0])
+	assert.Equal(t, "node0", files["node0.csv"][1][1])
+
+	_, err = ss.Samples("test", nil)
+	assert.Equal(t, repository.ErrStatsNotFound, err)
+
+	res = ss.Finish("test")
+	require.NoError(t, res.Error)
+	assert.Equal(t, false, res.Meta["cancelled"])
+	assert.NotContains(t, res.Meta, "artifact")
+
+	cli.AssertCalled(t, "Close")
+	ds.AssertExpectations(t)
+	assert.Equal(t, 1, remote.calls)
+}
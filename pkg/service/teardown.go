@@ -0,0 +1,183 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/sirupsen/logrus"
+	"github.com/whiteblock/definition/command"
+)
+
+// teardownReport tracks what happened to each of the resources of a test during its teardown
+type teardownReport struct {
+	host    string
+	removed []string
+	failed  map[string]string
+}
+
+func newTeardownReport(host string) *teardownReport {
+	return &teardownReport{host: host, removed: []string{}, failed: map[string]string{}}
+}
+
+// record notes the outcome of removing a resource, errors saying that it is already gone are not failures
+func (tr *teardownReport) record(kind, name string, err error, gone ...string) {
+	resource := kind + "/" + name
+	if len(tr.host) > 0 {
+		resource = tr.host + "/" + resource
+	}
+	if err == nil {
+		tr.removed = append(tr.removed, resource)
+		return
+	}
+	for _, msg := range gone {
+		if strings.Contains(strings.ToLower(err.Error()), msg) {
+			return
+		}
+	}
+	tr.failed[resource] = err.Error()
+}
+
+func (tr *teardownReport) merge(other *teardownReport) {
+	tr.removed = append(tr.removed, other.removed...)
+	for resource, err := range other.failed {
+		tr.failed[resource] = err
+	}
+}
+
+// testIDOf gets the id of the test which the client is acting on behalf of
+func testIDOf(cli entity.DockerCli) string {
+	if testID := cli.Labels[command.TestIDKey]; len(testID) > 0 {
+		return testID
+	}
+	return cli.TestID
+}
+
+func containerName(cntr types.Container) string {
+	if len(cntr.Names) == 0 {
+		return cntr.ID
+	}
+	return strings.TrimPrefix(cntr.Names[0], "/")
+}
+
+func (ds dockerService) teardownContainers(ctx context.Context, cli entity.Client,
+	report *teardownReport, cntrs []types.Container) {
+	for _, cntr := range cntrs {
+		err := cli.ContainerRemove(ctx, cntr.ID, types.ContainerRemoveOptions{Force: true})
+		report.record("container", containerName(cntr), err, "no such container", "is already in progress")
+	}
+}
+
+// teardownHost removes the resources of the test from a single docker host. The containers
+// go first, as they hold on to the networks and volumes, except for the gluster sidecars which
+// go last, since the volumes may still depend on them.
+func (ds dockerService) teardownHost(ctx context.Context, cli entity.Client,
+	host string, testID string) *teardownReport {
+
+	report := newTeardownReport(host)
+	filter := filters.NewArgs(filters.Arg("label", command.TestIDKey+"="+testID))
+
+	cntrs, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: filter})
+	if err != nil {
+		report.record("containers", "list", err)
+	}
+	workloads := []types.Container{}
+	gluster := []types.Container{}
+	for _, cntr := range cntrs {
+		if cntr.Labels[entity.SidecarLabel] == GlusterSidecar {
+			gluster = append(gluster, cntr)
+		} else {
+			workloads = append(workloads, cntr)
+		}
+	}
+	ds.teardownContainers(ctx, cli, report, workloads)
+
+	nets, err := cli.NetworkList(ctx, types.NetworkListOptions{Filters: filter})
+	if err != nil {
+		report.record("networks", "list", err)
+	}
+	for _, net := range nets {
+		report.record("network", net.Name, cli.NetworkRemove(ctx, net.ID), "not found", "no such network")
+	}
+
+	vols, err := cli.VolumeList(ctx, filter)
+	if err != nil {
+		report.record("volumes", "list", err)
+	}
+	for _, vol := range vols.Volumes {
+		report.record("volume", vol.Name, cli.VolumeRemove(ctx, vol.Name, true), "no such volume")
+	}
+
+	ds.teardownContainers(ctx, cli, report, gluster)
+	return report
+}
+
+// Teardown removes everything which was created for a test from the docker hosts. Every
+// resource is attempted, and the ones which could not be removed are reported in the meta.
+func (ds dockerService) Teardown(ctx context.Context, cli entity.DockerCli,
+	td entity.Teardown) entity.Result {
+
+	testID := td.TestID
+	if len(testID) == 0 {
+		testID = testIDOf(cli)
+	}
+	if len(testID) == 0 {
+		return entity.NewFatalResult("unable to determine which test to tear down")
+	}
+
+	report := newTeardownReport("")
+	clients := []entity.Client{cli.Client}
+	hosts := []string{""}
+	if len(td.Hosts) > 0 {
+		clients = []entity.Client{}
+		hosts = []string{}
+		for _, host := range td.Hosts {
+			client, err := ds.CreateClient2(host, testID)
+			if err != nil {
+				// the hosts which can be reached are still torn down
+				ds.withFields(cli, logrus.Fields{"test": testID, "host": host, "error": err}).Warn(
+					"unable to connect to a host to tear down a test on")
+				report.failed[host] = err.Error()
+				continue
+			}
+			defer client.Close()
+			clients = append(clients, client)
+			hosts = append(hosts, host)
+		}
+	}
+
+	ds.withFields(cli, logrus.Fields{"test": testID, "hosts": td.Hosts}).Info("tearing down a test")
+	reports := make(chan *teardownReport, len(clients))
+	for i := range clients {
+		go func(i int) {
+			reports <- ds.teardownHost(ctx, clients[i], hosts[i], testID)
+		}(i)
+	}
+
+	for range clients {
+		report.merge(<-reports)
+	}
+	meta := map[string]interface{}{
+		"test":    testID,
+		"removed": report.removed,
+		"failed":  report.failed,
+		"type":    "Teardown",
+	}
+	if len(report.failed) > 0 {
+		ds.withFields(cli, logrus.Fields{"test": testID, "failed": report.failed}).Warn(
+			"unable to remove some of the resources of a test")
+		return entity.NewErrorResult(
+			fmt.Sprintf("failed to remove %d resources", len(report.failed))).InjectMeta(meta)
+	}
+	return entity.NewSuccessResult().InjectMeta(meta)
+}
@@ -0,0 +1,118 @@
+/**
+ * Copyright 2019 Whiteblock Inc. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	entityMock "github.com/whiteblock/genesis/mocks/pkg/entity"
+	repoMock "github.com/whiteblock/genesis/mocks/pkg/repository"
+	"github.com/whiteblock/genesis/pkg/config"
+	"github.com/whiteblock/genesis/pkg/entity"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	dockerVolume "github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/whiteblock/definition/command"
+)
+
+func TestDockerService_Teardown(t *testing.T) {
+	removed := []string{}
+	cli := new(entityMock.Client)
+	cli.On("ContainerList", mock.Anything, mock.Anything).Return([]types.Container{
+		{ID: "1", Names: []string{"/gluster-container"}, Labels: map[string]string{
+			entity.SidecarLabel: GlusterSidecar}},
+		{ID: "2", Names: []string{"/node0"}},
+		{ID: "3", Names: []string{"/node0-netem"}, Labels: map[string]string{
+			entity.SidecarLabel: NetemSidecar}},
+	}, nil).Run(func(args mock.Arguments) {
+		opts := args.Get(1).(types.ContainerListOptions)
+		assert.True(t, opts.All)
+		assert.Equal(t, []string{command.TestIDKey + "=test"}, opts.Filters.Get("label"))
+	}).Once()
+	cli.On("ContainerRemove", mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(
+		func(args mock.Arguments) {
+			removed = append(removed, "container/"+args.String(1))
+		}).Times(3)
+	cli.On("NetworkList", mock.Anything, mock.Anything).Return([]types.NetworkResource{
+		{ID: "n1", Name: "net1"}, {ID: "n2", Name: "net2"}}, nil).Once()
+	cli.On("NetworkRemove", mock.Anything, "n1").Return(nil).Run(func(args mock.Arguments) {
+		removed = append(removed, "network/n1")
+	}).Once()
+	cli.On("NetworkRemove", mock.Anything, "n2").Return(fmt.Errorf("has active endpoints")).Once()
+	cli.On("VolumeList", mock.Anything, mock.Anything).Return(dockerVolume.VolumeListOKBody{
+		Volumes: []*types.Volume{{Name: "vol1"}}}, nil).Run(func(args mock.Arguments) {
+		assert.Equal(t, []string{command.TestIDKey + "=test"}, args.Get(1).(filters.Args).Get("label"))
+	}).Once()
+	cli.On("VolumeRemove", mock.Anything, "vol1", true).Return(
+		fmt.Errorf("Error: No such volume: vol1")).Run(func(args mock.Arguments) {
+		removed = append(removed, "volume/vol1")
+	}).Once()
+
+	ds := NewDockerService(nil, config.Docker{}, nil, logrus.New())
+	res := ds.Teardown(nil, entity.DockerCli{Client: cli, Labels: map[string]string{
+		command.TestIDKey: "test"}}, entity.Teardown{})
+
+	assert.Error(t, res.Error)
+	assert.False(t, res.IsFatal())
+	assert.Equal(t, []string{"container/2", "container/3", "network/n1", "volume/vol1",
+		"container/1"}, removed)
+	assert.Equal(t, map[string]string{"network/net2": "has active endpoints"}, res.Meta["failed"])
+	assert.Equal(t, []string{"container/node0", "container/node0-netem", "network/net1",
+		"container/gluster-container"}, res.Meta["removed"])
+	cli.AssertExpectations(t)
+}
+
+func TestDockerService_Teardown_NoTest(t *testing.T) {
+	ds := NewDockerService(nil, config.Docker{}, nil, logrus.New())
+	res := ds.Teardown(nil, entity.DockerCli{}, entity.Teardown{})
+	assert.Error(t, res.Error)
+	assert.True(t, res.IsFatal())
+}
+
+func TestDockerService_Teardown_UnreachableHost(t *testing.T) {
+	// the credentials of the test are looked up by the id of the test being torn down
+	dir, err := ioutil.TempDir("/tmp", "teardown")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"ca.cert", "client.cert", "client.key"} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte("test"), 0600))
+	}
+	testID := filepath.Base(dir)
+
+	repo := new(repoMock.DockerRepository)
+	repo.On("WithTLSClientConfig", mock.Anything, mock.Anything, mock.Anything).Return(
+		client.Opt(func(*client.Client) error { return fmt.Errorf("bad cert") })).Once()
+	repo.On("WithTLSClientConfig", mock.Anything, mock.Anything, mock.Anything).Return(
+		client.Opt(func(*client.Client) error { return nil })).Once()
+
+	ds := NewDockerService(repo, config.Docker{DaemonPort: "1"}, nil, logrus.New())
+	res := ds.Teardown(context.Background(), entity.DockerCli{}, entity.Teardown{TestID: testID,
+		Hosts: []string{"127.0.0.2", "127.0.0.1"}})
+
+	assert.Error(t, res.Error)
+	assert.False(t, res.IsFatal())
+	failed := res.Meta["failed"].(map[string]string)
+	assert.Equal(t, "bad cert", failed["127.0.0.2"])
+	// the reachable host is still torn down
+	attempted := false
+	for resource := range failed {
+		attempted = attempted || strings.HasPrefix(resource, "127.0.0.1/")
+	}
+	assert.True(t, attempted)
+	repo.AssertExpectations(t)
+}
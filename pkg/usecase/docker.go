@@ -29,6 +29,8 @@ type DockerUseCase interface {
 	Run(ctx context.Context, cmd command.Command) entity.Result
 	// Execute executes the command with the given context
 	Execute(ctx context.Context, cmd command.Command) entity.Result
+	// Finish stops the background jobs of the test and archives its stats
+	Finish(testID string) entity.Result
 }
 
 var (
@@ -47,6 +49,9 @@ var (
 	// ErrEmptyFieldNetwork missing network field
 	ErrEmptyFieldNetwork = entity.NewFatalResult("empty field \"network\"")
 
+	// ErrEmptyFieldCmd missing cmd field
+	ErrEmptyFieldCmd = entity.NewFatalResult("empty field \"cmd\"")
+
 	// ErrInvalidTargetIP target IP is not a dest IP or is malformed
 	ErrInvalidTargetIP = entity.NewFatalResult("invalid target ip")
 
@@ -55,15 +60,21 @@ var (
 )
 
 type dockerUseCase struct {
-	service service.DockerService
-	log     logrus.Ext1FieldLogger
+	service   service.DockerService
+	schedules service.ScheduleService
+	chaos     service.ChaosService
+	stats     service.StatsService
+	log       logrus.Ext1FieldLogger
 }
 
 //NewDockerUseCase creates a DockerUseCase arguments given the proper dep injections
 func NewDockerUseCase(
 	service service.DockerService,
+	schedules service.ScheduleService,
+	chaos service.ChaosService,
+	stats service.StatsService,
 	log logrus.Ext1FieldLogger) DockerUseCase {
-	return &dockerUseCase{service: service, log: log}
+	return &dockerUseCase{service: service, schedules: schedules, chaos: chaos, stats: stats, log: log}
 }
 
 func (duc dockerUseCase) withFields(cmd command.Command, fields logrus.Fields) *logrus.Entry {
@@ -159,6 +170,18 @@ func (duc dockerUseCase) Execute(ctx context.Context, cmd command.Command) entit
 		return duc.startContainerShim(ctx, cli, cmd)
 	case command.Removecontainer:
 		return duc.removeContainerShim(ctx, cli, cmd)
+	case entity.StopContainerOrder:
+		return duc.stopContainerShim(ctx, cli, cmd)
+	case entity.RestartContainerOrder:
+		return duc.restartContainerShim(ctx, cli, cmd)
+	case entity.KillContainerOrder:
+		return duc.killContainerShim(ctx, cli, cmd)
+	case entity.PauseContainerOrder:
+		return duc.pauseContainerShim(ctx, cli, cmd)
+	case entity.UnpauseContainerOrder:
+		return duc.unpauseContainerShim(ctx, cli, cmd)
+	case entity.UpdateContainerResourcesOrder:
+		return duc.updateContainerResourcesShim(ctx, cli, cmd)
 	case command.Createnetwork:
 		return duc.createNetworkShim(ctx, cli, cmd)
 	case command.Attachnetwork:
@@ -189,6 +212,34 @@ func (duc dockerUseCase) Execute(ctx context.Context, cmd command.Command) entit
 		return duc.pauseExecutionShim(ctx, cli, cmd)
 	case command.Resumeexecution:
 		return duc.resumeExecutionShim(ctx, cli, cmd)
+	case entity.TeardownOrder, entity.DestroyTestnetOrder:
+		return duc.teardownShim(ctx, cli, cmd)
+	case entity.CollectLogsOrder:
+		return duc.collectLogsShim(ctx, cli, cmd)
+	case entity.CopyFromContainerOrder:
+		return duc.copyFromContainerShim(ctx, cli, cmd)
+	case entity.ExecInContainerOrder:
+		return duc.execInContainerShim(ctx, cli, cmd)
+	case entity.WaitForReadyOrder:
+		return duc.waitForReadyShim(ctx, cli, cmd)
+	case entity.UpdateEmulationOrder:
+		return duc.updateEmulationShim(ctx, cli, cmd)
+	case entity.ClearEmulationOrder:
+		return duc.clearEmulationShim(ctx, cli, cmd)
+	case entity.LinkEmulationOrder:
+		return duc.linkEmulationShim(ctx, cli, cmd)
+	case entity.PartitionNetworkOrder:
+		return duc.partitionNetworkShim(ctx, cli, cmd)
+	case entity.HealNetworkOrder:
+		return duc.healNetworkShim(ctx, cli, cmd)
+	case entity.EmulationScheduleOrder:
+		return duc.emulationScheduleShim(ctx, cli, cmd)
+	case entity.GetEmulationOrder:
+		return duc.getEmulationShim(ctx, cli, cmd)
+	case entity.ChaosOrder:
+		return duc.chaosShim(ctx, cli, cmd)
+	case entity.CollectStatsOrder:
+		return duc.collectStatsShim(ctx, cli, cmd)
 	}
 	return ErrUnknownCommandType.InjectMeta(map[string]interface{}{"type": cmd.Order.Type})
 }
@@ -215,7 +266,7 @@ func (duc dockerUseCase) injectLabels(cli entity.Client, cmd command.Command) en
 func (duc dockerUseCase) createContainerShim(ctx context.Context, cli entity.Client,
 	cmd command.Command) entity.Result {
 
-	var container command.Container
+	var container entity.Container
 	err := cmd.ParseOrderPayloadInto(&container)
 	if err != nil {
 		return entity.NewFatalResult(err)
@@ -264,13 +315,105 @@ func (duc dockerUseCase) removeContainerShim(ctx context.Context, cli entity.Cli
 	return duc.service.RemoveContainer(ctx, duc.injectLabels(cli, cmd), payload.Name)
 }
 
+func (duc dockerUseCase) stopContainerShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload entity.StopContainer
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	err = validator.StopContainer(payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	return duc.service.StopContainer(ctx, duc.injectLabels(cli, cmd), payload)
+}
+
+func (duc dockerUseCase) restartContainerShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload entity.RestartContainer
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	err = validator.RestartContainer(payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	return duc.service.RestartContainer(ctx, duc.injectLabels(cli, cmd), payload)
+}
+
+func (duc dockerUseCase) killContainerShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload entity.KillContainer
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	err = validator.KillContainer(payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	return duc.service.KillContainer(ctx, duc.injectLabels(cli, cmd), payload)
+}
+
+func (duc dockerUseCase) pauseContainerShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload command.SimpleName
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	if payload.Name == "" {
+		return ErrEmptyFieldName
+	}
+	return duc.service.PauseContainer(ctx, duc.injectLabels(cli, cmd), payload.Name)
+}
+
+func (duc dockerUseCase) unpauseContainerShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload command.SimpleName
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	if payload.Name == "" {
+		return ErrEmptyFieldName
+	}
+	return duc.service.UnpauseContainer(ctx, duc.injectLabels(cli, cmd), payload.Name)
+}
+
+func (duc dockerUseCase) updateContainerResourcesShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload entity.UpdateContainerResources
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	err = validator.UpdateContainerResources(payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	return duc.service.UpdateContainerResources(ctx, duc.injectLabels(cli, cmd), payload)
+}
+
 func (duc dockerUseCase) createNetworkShim(ctx context.Context, cli entity.Client,
 	cmd command.Command) entity.Result {
-	var net command.Network
+	var net entity.Network
 	err := cmd.ParseOrderPayloadInto(&net)
 	if err != nil {
 		return entity.NewFatalResult(err)
 	}
+	err = validator.Network(net)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
 	docker := duc.injectLabels(cli, cmd)
 	err = mergo.Map(&docker.Labels, net.Labels)
 	if err != nil {
@@ -281,7 +424,7 @@ func (duc dockerUseCase) createNetworkShim(ctx context.Context, cli entity.Clien
 
 func (duc dockerUseCase) attachNetworkShim(ctx context.Context, cli entity.Client,
 	cmd command.Command) entity.Result {
-	var payload command.ContainerNetwork
+	var payload entity.ContainerNetwork
 	err := cmd.ParseOrderPayloadInto(&payload)
 	if err != nil {
 		return entity.NewErrorResult(err)
@@ -292,6 +435,10 @@ func (duc dockerUseCase) attachNetworkShim(ctx context.Context, cli entity.Clien
 	if len(payload.Network) == 0 {
 		return ErrEmptyFieldNetwork
 	}
+	err = validator.ContainerNetwork(payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
 	return duc.service.AttachNetwork(ctx, duc.injectLabels(cli, cmd), payload)
 }
 
@@ -373,7 +520,7 @@ func (duc dockerUseCase) putFileInContainerShim(ctx context.Context, cli entity.
 func (duc dockerUseCase) emulationShim(ctx context.Context, cli entity.Client,
 	cmd command.Command) entity.Result {
 
-	var payload command.Netconf
+	var payload entity.Emulation
 	err := cmd.ParseOrderPayloadInto(&payload)
 	if err != nil {
 		return entity.NewFatalResult(err)
@@ -449,3 +596,240 @@ func (duc dockerUseCase) resumeExecutionShim(ctx context.Context, cli entity.Cli
 	}
 	return duc.service.RemoveContainer(ctx, duc.injectLabels(cli, cmd), payload.Tasks...)
 }
+
+func (duc dockerUseCase) teardownShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload entity.Teardown
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	dcli := duc.injectLabels(cli, cmd)
+
+	testID := payload.TestID
+	if len(testID) == 0 {
+		testID = dcli.Labels[command.TestIDKey]
+	}
+	if len(testID) == 0 {
+		testID = dcli.TestID
+	}
+	// the stats are archived before the containers are removed
+	finished := duc.Finish(testID)
+	return duc.service.Teardown(ctx, dcli, payload).InjectMeta(finished.Meta)
+}
+
+// Finish stops the background jobs of the test and archives its stats. Failing to archive
+// the stats is only reported in the meta, as it should not stop the test from finishing.
+func (duc dockerUseCase) Finish(testID string) entity.Result {
+	meta := map[string]interface{}{
+		"cancelledSchedules": duc.schedules.Cancel(testID),
+		"cancelledChaos":     duc.chaos.Cancel(testID),
+	}
+	stats := duc.stats.Finish(testID)
+	meta["stats"] = stats.Meta
+	if !stats.IsSuccess() {
+		duc.log.WithFields(logrus.Fields{"test": testID, "error": stats.Error}).Warn(
+			"failed to archive the stats of a test")
+		meta["statsError"] = stats.Error.Error()
+	}
+	return entity.NewSuccessResult().InjectMeta(meta)
+}
+
+func (duc dockerUseCase) collectLogsShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload entity.CollectLogs
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	return duc.service.CollectLogs(ctx, duc.injectLabels(cli, cmd), payload)
+}
+
+func (duc dockerUseCase) copyFromContainerShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload entity.CopyFromContainer
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	err = validator.CopyFromContainer(payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	return duc.service.CopyFromContainer(ctx, duc.injectLabels(cli, cmd), payload)
+}
+
+func (duc dockerUseCase) execInContainerShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload entity.ExecInContainer
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	if len(payload.Container) == 0 {
+		return ErrEmptyFieldContainer
+	}
+	if len(payload.Cmd) == 0 {
+		return ErrEmptyFieldCmd
+	}
+	return duc.service.ExecInContainer(ctx, duc.injectLabels(cli, cmd), payload)
+}
+
+func (duc dockerUseCase) waitForReadyShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload entity.WaitForReady
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	err = validator.WaitForReady(payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	return duc.service.WaitForReady(ctx, duc.injectLabels(cli, cmd), payload)
+}
+
+func (duc dockerUseCase) updateEmulationShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload entity.Emulation
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	if len(payload.Container) == 0 {
+		return ErrEmptyFieldContainer
+	}
+	if len(payload.Network) == 0 {
+		return ErrEmptyFieldNetwork
+	}
+	return duc.service.UpdateEmulation(ctx, duc.injectLabels(cli, cmd), payload)
+}
+
+func (duc dockerUseCase) clearEmulationShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload entity.ClearEmulation
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	if len(payload.Container) == 0 {
+		return ErrEmptyFieldContainer
+	}
+	if len(payload.Network) == 0 {
+		return ErrEmptyFieldNetwork
+	}
+	return duc.service.ClearEmulation(ctx, duc.injectLabels(cli, cmd), payload)
+}
+
+func (duc dockerUseCase) linkEmulationShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload entity.LinkEmulation
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	if len(payload.Network) == 0 {
+		return ErrEmptyFieldNetwork
+	}
+	return duc.service.LinkEmulation(ctx, duc.injectLabels(cli, cmd), payload)
+}
+
+func (duc dockerUseCase) partitionNetworkShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload entity.PartitionNetwork
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	err = validator.PartitionNetwork(payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	return duc.service.PartitionNetwork(ctx, duc.injectLabels(cli, cmd), payload)
+}
+
+func (duc dockerUseCase) healNetworkShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload entity.HealNetwork
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	err = validator.HealNetwork(payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	return duc.service.HealNetwork(ctx, duc.injectLabels(cli, cmd), payload)
+}
+
+func (duc dockerUseCase) emulationScheduleShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload entity.EmulationSchedule
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	err = validator.EmulationSchedule(payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	return duc.schedules.Start(duc.injectLabels(cli, cmd), cmd.Target.IP, payload)
+}
+
+func (duc dockerUseCase) chaosShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload entity.Chaos
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	err = validator.Chaos(payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	return duc.chaos.Start(duc.injectLabels(cli, cmd), cmd.Target.IP, payload)
+}
+
+func (duc dockerUseCase) getEmulationShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload entity.GetEmulation
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	if len(payload.Container) == 0 {
+		return ErrEmptyFieldContainer
+	}
+	if len(payload.Network) == 0 {
+		return ErrEmptyFieldNetwork
+	}
+	return duc.service.GetEmulation(ctx, duc.injectLabels(cli, cmd), payload)
+}
+
+func (duc dockerUseCase) collectStatsShim(ctx context.Context, cli entity.Client,
+	cmd command.Command) entity.Result {
+
+	var payload entity.CollectStats
+	err := cmd.ParseOrderPayloadInto(&payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	err = validator.CollectStats(payload)
+	if err != nil {
+		return entity.NewFatalResult(err)
+	}
+	return duc.stats.Start(duc.injectLabels(cli, cmd), cmd.Target.IP, payload)
+}
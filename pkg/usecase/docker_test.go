@@ -10,6 +10,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	mockService "github.com/whiteblock/genesis/mocks/pkg/service"
 	"github.com/whiteblock/genesis/pkg/entity"
@@ -30,7 +31,7 @@ var (
 )
 
 func TestNewDockerUseCase(t *testing.T) {
-	duc := NewDockerUseCase(nil, logrus.New())
+	duc := NewDockerUseCase(nil, nil, nil, nil, logrus.New())
 	assert.NotNil(t, duc)
 }
 
@@ -38,7 +39,7 @@ func TestDockerUseCase_validationCheck_success(t *testing.T) {
 	cmd := command.Command{
 		Target: testTarget,
 	}
-	duc := NewDockerUseCase(nil, logrus.New())
+	duc := NewDockerUseCase(nil, nil, nil, nil, logrus.New())
 	_, ok := duc.(*dockerUseCase).validationCheck(cmd)
 	assert.True(t, ok)
 }
@@ -48,7 +49,7 @@ func TestDockerUseCase_validationCheck_failure_special_ip(t *testing.T) {
 		Target: command.Target{IP: "0.0.0.0"},
 	}
 
-	duc := NewDockerUseCase(nil, logrus.New())
+	duc := NewDockerUseCase(nil, nil, nil, nil, logrus.New())
 	res, ok := duc.(*dockerUseCase).validationCheck(cmd)
 	assert.False(t, ok)
 	assert.Error(t, res.Error)
@@ -56,7 +57,7 @@ func TestDockerUseCase_validationCheck_failure_special_ip(t *testing.T) {
 
 func TestDockerUseCase_validationCheck_failure_no_ip(t *testing.T) {
 	cmd := command.Command{}
-	duc := NewDockerUseCase(nil, logrus.New())
+	duc := NewDockerUseCase(nil, nil, nil, nil, logrus.New())
 	res, ok := duc.(*dockerUseCase).validationCheck(cmd)
 	assert.False(t, ok)
 	assert.Error(t, res.Error)
@@ -66,7 +67,7 @@ func TestDockerUseCase_Execute_Failure_CreateClient(t *testing.T) {
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("err")).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{Target: testTarget})
 	assert.Error(t, res.Error)
@@ -74,7 +75,7 @@ func TestDockerUseCase_Execute_Failure_CreateClient(t *testing.T) {
 }
 
 func TestDockerUseCase_Run_Failure_Invalid_IP(t *testing.T) {
-	usecase := NewDockerUseCase(nil, logrus.New())
+	usecase := NewDockerUseCase(nil, nil, nil, nil, logrus.New())
 
 	res := usecase.Run(context.TODO(), command.Command{Target: command.Target{IP: "0.0.0.0"}})
 	assert.Error(t, res.Error)
@@ -86,7 +87,7 @@ func TestDockerUseCase_Execute_CreateContainer_Success(t *testing.T) {
 	service.On("CreateContainer", mock.Anything, mock.Anything, mock.Anything).Return(
 		entity.Result{Type: entity.SuccessType}).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -109,7 +110,7 @@ func TestDockerUseCase_Execute_CreateContainer_Failure(t *testing.T) {
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -129,7 +130,7 @@ func TestDockerUseCase_Execute_StartContainer_Success(t *testing.T) {
 	service.On("StartContainer", mock.Anything, mock.Anything, mock.Anything).Return(
 		entity.Result{Type: entity.SuccessType}).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -147,7 +148,7 @@ func TestDockerUseCase_Execute_StartContainer_Failure_ExtraField(t *testing.T) {
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -165,7 +166,7 @@ func TestDockerUseCase_Execute_StartContainer_Failure_EmptyName(t *testing.T) {
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -183,7 +184,7 @@ func TestDockerUseCase_Execute_RemoveContainer_Failure_EmptyName(t *testing.T) {
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -202,7 +203,7 @@ func TestDockerUseCase_Execute_RemoveContainer_Failure_ExtraField(t *testing.T)
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -220,7 +221,7 @@ func TestDockerUseCase_Execute_AttachNetwork_Failure_EmptyContainerName(t *testi
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -241,7 +242,7 @@ func TestDockerUseCase_Execute_AttachNetwork_Failure_EmptyNetworkName(t *testing
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -263,7 +264,7 @@ func TestDockerUseCase_Execute_AttachNetwork_Failure_ExtraField(t *testing.T) {
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -288,7 +289,7 @@ func TestDockerUseCase_Execute_DetachNetwork(t *testing.T) {
 	service.On("DetachNetwork", mock.Anything, mock.Anything, mock.Anything,
 		mock.Anything, mock.Anything).Return(entity.NewSuccessResult()).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -307,7 +308,7 @@ func TestDockerUseCase_Execute_DetachNetwork_Failure(t *testing.T) {
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -328,7 +329,7 @@ func TestDockerUseCase_RemoveNetwork_Success(t *testing.T) {
 	service.On("RemoveNetwork", mock.Anything, mock.Anything, mock.Anything).Return(
 		entity.NewSuccessResult()).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -349,7 +350,7 @@ func TestDockerUseCase_RemoveNetwork_Failure_EmptyName(t *testing.T) {
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -370,7 +371,7 @@ func TestDockerUseCase_RemoveNetwork_Failure_ExtraField(t *testing.T) {
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -393,7 +394,7 @@ func TestDockerUseCase_Execute_RemoveVolume(t *testing.T) {
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil)
 	service.On("RemoveVolume", mock.Anything, mock.Anything, mock.Anything).Return(entity.Result{Type: entity.SuccessType})
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -413,7 +414,7 @@ func TestDockerUseCase_Execute_PutFileInContainer(t *testing.T) {
 	service.On("PlaceFileInContainer", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
 		mock.Anything).Return(entity.Result{Type: entity.SuccessType}).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -435,7 +436,7 @@ func TestDockerUseCase_Execute_CreateContainer(t *testing.T) {
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil)
 	service.On("CreateContainer", mock.Anything, mock.Anything, mock.Anything).Return(entity.Result{Type: entity.SuccessType})
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -456,12 +457,99 @@ func TestDockerUseCase_Execute_CreateContainer(t *testing.T) {
 
 }
 
+func TestDockerUseCase_Execute_CreateContainer_Options(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Twice()
+	service.On("CreateContainer", mock.Anything, mock.Anything, mock.Anything).Return(
+		entity.Result{Type: entity.SuccessType}).Run(func(args mock.Arguments) {
+		cntr := args.Get(2).(entity.Container)
+		assert.Equal(t, "foo", cntr.Name)
+		assert.Equal(t, "/data", cntr.Workdir)
+		assert.Equal(t, map[string]entity.Ulimit{"nofile": {Soft: 1024, Hard: 4096}}, cntr.Ulimits)
+		assert.Equal(t, []string{"NET_ADMIN"}, cntr.CapAdd)
+		assert.Equal(t, "unless-stopped", cntr.RestartPolicy)
+		require.NotNil(t, cntr.Healthcheck)
+		assert.Equal(t, 10*time.Second, cntr.Healthcheck.Interval.Duration)
+	}).Once()
+
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
+
+	payload := map[string]interface{}{
+		"name":          "foo",
+		"image":         "bar",
+		"cpus":          "2.0",
+		"memory":        "2GB",
+		"workdir":       "/data",
+		"ulimits":       map[string]interface{}{"nofile": map[string]int{"soft": 1024, "hard": 4096}},
+		"capAdd":        []string{"NET_ADMIN"},
+		"restartPolicy": "unless-stopped",
+		"healthcheck":   map[string]interface{}{"test": []string{"CMD", "true"}, "interval": "10s"},
+	}
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order:  command.Order{Type: command.Createcontainer, Payload: payload},
+	})
+	assert.NoError(t, res.Error)
+
+	payload["restartPolicy"] = "sometimes"
+	res = usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order:  command.Order{Type: command.Createcontainer, Payload: payload},
+	})
+	assert.True(t, res.IsFatal())
+	service.AssertExpectations(t)
+}
+
+func TestDockerUseCase_Execute_CreateContainer_Networks(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Twice()
+	service.On("CreateContainer", mock.Anything, mock.Anything, mock.Anything).Return(
+		entity.Result{Type: entity.SuccessType}).Run(func(args mock.Arguments) {
+		cntr := args.Get(2).(entity.Container)
+		assert.Equal(t, []entity.NetworkAttachment{
+			{Name: "net0", Aliases: []string{"node"}},
+			{Name: "net1", IP: "10.1.0.2", MacAddress: "02:42:0a:01:00:02"},
+		}, cntr.Networks)
+	}).Once()
+
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
+
+	payload := map[string]interface{}{
+		"name":    "foo",
+		"image":   "bar",
+		"cpus":    "2.0",
+		"memory":  "2GB",
+		"network": "net0",
+		"networks": []map[string]interface{}{
+			{"name": "net0", "aliases": []string{"node"}},
+			{"name": "net1", "ip": "10.1.0.2", "macAddress": "02:42:0a:01:00:02"},
+		},
+	}
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order:  command.Order{Type: command.Createcontainer, Payload: payload},
+	})
+	assert.NoError(t, res.Error)
+
+	payload["networks"] = []map[string]interface{}{{"name": "net1"}, {"name": "net1"}}
+	res = usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order:  command.Order{Type: command.Createcontainer, Payload: payload},
+	})
+	assert.True(t, res.IsFatal())
+	service.AssertExpectations(t)
+}
+
 func TestDockerUseCase_Execute_StartContainer(t *testing.T) {
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 	service.On("StartContainer", mock.Anything, mock.Anything, mock.Anything).Return(entity.Result{Type: entity.SuccessType}).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -490,7 +578,7 @@ func TestDockerUseCase_Execute_RemoveContainer_Success(t *testing.T) {
 
 		}).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -510,7 +598,7 @@ func TestDockerUseCase_Execute_RemoveContainer_Failure(t *testing.T) {
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -524,6 +612,42 @@ func TestDockerUseCase_Execute_RemoveContainer_Failure(t *testing.T) {
 	service.AssertExpectations(t)
 }
 
+func TestDockerUseCase_Execute_CreateNetwork_DualStack(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Twice()
+	service.On("CreateNetwork", mock.Anything, mock.Anything, mock.Anything).Return(
+		entity.Result{Type: entity.SuccessType}).Run(func(args mock.Arguments) {
+		net := args.Get(2).(entity.Network)
+		assert.Equal(t, "testnet", net.Name)
+		assert.True(t, net.IsIPv6())
+		assert.Equal(t, []entity.Subnet{{Subnet: "fd00:1::/64", Gateway: "fd00:1::1"}}, net.Subnets)
+	}).Once()
+
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
+
+	payload := map[string]interface{}{
+		"name":    "testnet",
+		"subnet":  "10.1.0.0/16",
+		"gateway": "10.1.0.1",
+		"subnets": []map[string]string{{"subnet": "fd00:1::/64", "gateway": "fd00:1::1"}},
+	}
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order:  command.Order{Type: command.Createnetwork, Payload: payload},
+	})
+	assert.NoError(t, res.Error)
+
+	payload["subnets"] = []map[string]string{{"subnet": "fd00:1::/64", "gateway": "fd00:2::1"}}
+	res = usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order:  command.Order{Type: command.Createnetwork, Payload: payload},
+	})
+	assert.True(t, res.IsFatal())
+	service.AssertExpectations(t)
+}
+
 func TestDockerUseCase_Execute_AttachNetwork_Success(t *testing.T) {
 	testCmd := command.Command{
 		ID:     "TEST",
@@ -543,10 +667,11 @@ func TestDockerUseCase_Execute_AttachNetwork_Success(t *testing.T) {
 			require.Len(t, args, 3)
 			assert.NotNil(t, args.Get(0))
 			assert.NotNil(t, args.Get(1))
-			assert.Equal(t, testCmd.Order.Payload, args.Get(2))
+			assert.Equal(t, entity.ContainerNetwork{ContainerNetwork: testCmd.Order.Payload.(command.ContainerNetwork)},
+				args.Get(2))
 		}).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), testCmd)
 	assert.NoError(t, res.Error)
@@ -557,7 +682,7 @@ func TestDockerUseCase_Execute_AttachNetwork_Failure(t *testing.T) {
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -576,7 +701,7 @@ func TestDockerUseCase_Execute_CreateVolume(t *testing.T) {
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil)
 	service.On("CreateVolume", mock.Anything, mock.Anything, mock.Anything).Return(entity.Result{Type: entity.SuccessType})
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -605,7 +730,7 @@ func TestDockerUseCase_Execute_RemoveVolume_Success(t *testing.T) {
 
 		}).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -623,7 +748,7 @@ func TestDockerUseCase_Execute_RemoveVolume_Failure(t *testing.T) {
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -663,7 +788,7 @@ func TestDockerUseCase_Execute_PutFileInContainer_Success(t *testing.T) {
 			assert.Equal(t, mockFile["id"], file.ID)
 		}).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -715,7 +840,7 @@ func TestDockerUseCase_Execute_Emulation(t *testing.T) {
 	service.On("Emulation", mock.Anything, mock.Anything, mock.Anything).Return(
 		entity.Result{Type: entity.SuccessType}).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -737,11 +862,46 @@ func TestDockerUseCase_Execute_Emulation(t *testing.T) {
 	service.AssertExpectations(t)
 }
 
+func TestDockerUseCase_Execute_Emulation_Netem(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
+	service.On("Emulation", mock.Anything, mock.Anything, mock.Anything).Return(
+		entity.Result{Type: entity.SuccessType}).Run(func(args mock.Arguments) {
+		emu := args.Get(2).(entity.Emulation)
+		assert.Equal(t, "node0", emu.Container)
+		assert.Equal(t, 100000, emu.Delay)
+		assert.Equal(t, 10000, emu.Jitter)
+		assert.Equal(t, "normal", emu.Distribution)
+		require.NotNil(t, emu.LossGEModel)
+		assert.Equal(t, float64(5), emu.LossGEModel.P)
+	}).Once()
+
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
+
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type: command.Emulation,
+			Payload: map[string]interface{}{
+				"container":    "node0",
+				"network":      "net",
+				"delay":        100000,
+				"jitter":       10000,
+				"distribution": "normal",
+				"lossGEModel":  map[string]interface{}{"p": 5},
+			},
+		},
+	})
+	assert.NoError(t, res.Error)
+	service.AssertExpectations(t)
+}
+
 func TestDockerUseCase_Execute_Emulation_Failure(t *testing.T) {
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -761,7 +921,7 @@ func TestDockerUseCase_Execute_UnknownType_Failure(t *testing.T) {
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		ID:     "TEST",
@@ -779,7 +939,7 @@ func TestDockerUseCase_Execute_CreateContainer_Failure_ExtraField(t *testing.T)
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		Target: testTarget,
@@ -798,7 +958,7 @@ func TestDockerUseCase_Execute_CreateVolume_Failure_ExtraField(t *testing.T) {
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		Target: testTarget,
@@ -818,7 +978,7 @@ func TestDockerUseCase_Execute_PutFileInContainer_NoName_Fail(t *testing.T) {
 	service := new(mockService.DockerService)
 	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil)
 
-	usecase := NewDockerUseCase(service, logrus.New())
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
 
 	res := usecase.Execute(context.TODO(), command.Command{
 		Target: testTarget,
@@ -837,3 +997,617 @@ func TestDockerUseCase_Execute_PutFileInContainer_NoName_Fail(t *testing.T) {
 	assert.Error(t, res.Error)
 	service.AssertExpectations(t)
 }
+
+func TestDockerUseCase_Execute_Teardown(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Twice()
+	service.On("Teardown", mock.Anything, mock.Anything, mock.Anything).Return(
+		entity.Result{Type: entity.SuccessType}).Run(
+		func(args mock.Arguments) {
+			require.Len(t, args, 3)
+			td, ok := args.Get(2).(entity.Teardown)
+			require.True(t, ok)
+			assert.Equal(t, []string{"127.0.0.2"}, td.Hosts)
+		}).Twice()
+	schedules := new(mockService.ScheduleService)
+	schedules.On("Cancel", "test1").Return([]string{"node0/net"}).Twice()
+	chaos := new(mockService.ChaosService)
+	chaos.On("Cancel", "test1").Return([]string{"chaos"}).Twice()
+	stats := new(mockService.StatsService)
+	stats.On("Finish", "test1").Return(entity.NewSuccessResult().InjectMeta(map[string]interface{}{
+		"artifact": "stats-1.tar.gz",
+	})).Once()
+	stats.On("Finish", "test1").Return(entity.NewErrorResult("disk full")).Once()
+
+	usecase := NewDockerUseCase(service, schedules, chaos, stats, logrus.New())
+
+	for _, orderType := range []command.OrderType{entity.TeardownOrder, "destroyTestnet"} {
+		res := usecase.Execute(context.TODO(), command.Command{
+			ID:     "TEST",
+			Target: testTarget,
+			Meta:   map[string]string{command.TestIDKey: "test1"},
+			Order: command.Order{
+				Type:    orderType,
+				Payload: entity.Teardown{Hosts: []string{"127.0.0.2"}},
+			},
+		})
+		assert.NoError(t, res.Error)
+		assert.Equal(t, []string{"node0/net"}, res.Meta["cancelledSchedules"])
+		assert.Equal(t, []string{"chaos"}, res.Meta["cancelledChaos"])
+		if orderType == entity.TeardownOrder {
+			assert.Equal(t, "stats-1.tar.gz", res.Meta["stats"].(map[string]interface{})["artifact"])
+		} else {
+			assert.Equal(t, "disk full", res.Meta["statsError"])
+		}
+	}
+	service.AssertExpectations(t)
+	schedules.AssertExpectations(t)
+	chaos.AssertExpectations(t)
+	stats.AssertExpectations(t)
+}
+
+func TestDockerUseCase_Finish(t *testing.T) {
+	schedules := new(mockService.ScheduleService)
+	schedules.On("Cancel", "test1").Return([]string{}).Once()
+	chaos := new(mockService.ChaosService)
+	chaos.On("Cancel", "test1").Return([]string{"chaos"}).Once()
+	stats := new(mockService.StatsService)
+	stats.On("Finish", "test1").Return(entity.NewErrorResult("disk full")).Once()
+
+	usecase := NewDockerUseCase(nil, schedules, chaos, stats, logrus.New())
+
+	res := usecase.Finish("test1")
+	assert.NoError(t, res.Error)
+	assert.Equal(t, []string{"chaos"}, res.Meta["cancelledChaos"])
+	assert.Equal(t, "disk full", res.Meta["statsError"])
+	schedules.AssertExpectations(t)
+	chaos.AssertExpectations(t)
+	stats.AssertExpectations(t)
+}
+
+func TestDockerUseCase_Execute_Teardown_Failure_ExtraField(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
+
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
+
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    entity.TeardownOrder,
+			Payload: map[string]interface{}{"invalid": "field"},
+		},
+	})
+	assert.Error(t, res.Error)
+	assert.True(t, res.IsFatal())
+	service.AssertExpectations(t)
+}
+
+func TestDockerUseCase_Execute_CollectLogs(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
+	service.On("CollectLogs", mock.Anything, mock.Anything, entity.CollectLogs{Tail: "10"}).Return(
+		entity.Result{Type: entity.SuccessType}).Once()
+
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
+
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    "collectLogs",
+			Payload: map[string]interface{}{"tail": "10"},
+		},
+	})
+	assert.NoError(t, res.Error)
+	service.AssertExpectations(t)
+}
+
+func TestDockerUseCase_Execute_CopyFromContainer(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Twice()
+	service.On("CopyFromContainer", mock.Anything, mock.Anything, entity.CopyFromContainer{
+		Containers: []string{"node0"},
+		Path:       "/data/keys",
+	}).Return(entity.Result{Type: entity.SuccessType}).Once()
+
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
+
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    "copyFromContainer",
+			Payload: map[string]interface{}{"containers": []string{"node0"}, "path": "/data/keys"},
+		},
+	})
+	assert.NoError(t, res.Error)
+
+	res = usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    "copyFromContainer",
+			Payload: map[string]interface{}{"path": "data"},
+		},
+	})
+	assert.Error(t, res.Error)
+	assert.True(t, res.IsFatal())
+	service.AssertExpectations(t)
+}
+
+func TestDockerUseCase_Execute_ExecInContainer(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Twice()
+	service.On("ExecInContainer", mock.Anything, mock.Anything, entity.ExecInContainer{
+		Container:        "node0",
+		Cmd:              []string{"curl", "localhost:8545"},
+		Env:              map[string]string{"FOO": "bar"},
+		WorkingDir:       "/tmp",
+		ExpectedExitCode: 7,
+	}).Return(entity.Result{Type: entity.SuccessType}).Once()
+
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
+
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type: "execInContainer",
+			Payload: map[string]interface{}{
+				"container":        "node0",
+				"cmd":              []string{"curl", "localhost:8545"},
+				"env":              map[string]string{"FOO": "bar"},
+				"workdir":          "/tmp",
+				"expectedExitCode": 7,
+			},
+		},
+	})
+	assert.NoError(t, res.Error)
+
+	res = usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    "execInContainer",
+			Payload: map[string]interface{}{"container": "node0"},
+		},
+	})
+	assert.Equal(t, ErrEmptyFieldCmd, res)
+	service.AssertExpectations(t)
+}
+
+func TestDockerUseCase_Execute_WaitForReady(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Twice()
+	service.On("WaitForReady", mock.Anything, mock.Anything, mock.Anything).Return(
+		entity.Result{Type: entity.SuccessType}).Run(func(args mock.Arguments) {
+		wr := args.Get(2).(entity.WaitForReady)
+		assert.Equal(t, "node0", wr.Container)
+		assert.Equal(t, entity.ReadyCheckTCP, wr.Check)
+		assert.Equal(t, 8545, wr.Port)
+		assert.Equal(t, 2*time.Minute, wr.Timeout.Duration)
+	}).Once()
+
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
+
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type: "waitForReady",
+			Payload: map[string]interface{}{
+				"container": "node0",
+				"check":     "tcp",
+				"port":      8545,
+				"timeout":   "2m",
+			},
+		},
+	})
+	assert.NoError(t, res.Error)
+
+	res = usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    "waitForReady",
+			Payload: map[string]interface{}{"container": "node0", "check": "tcp"},
+		},
+	})
+	assert.True(t, res.IsFatal())
+	service.AssertExpectations(t)
+}
+
+func TestDockerUseCase_Execute_UpdateEmulation(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Twice()
+	service.On("UpdateEmulation", mock.Anything, mock.Anything, mock.Anything).Return(
+		entity.Result{Type: entity.SuccessType}).Run(func(args mock.Arguments) {
+		emu := args.Get(2).(entity.Emulation)
+		assert.Equal(t, "node0", emu.Container)
+		assert.Equal(t, "net", emu.Network)
+		assert.Equal(t, 5000, emu.Delay)
+	}).Once()
+
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
+
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    "updateEmulation",
+			Payload: map[string]interface{}{"container": "node0", "network": "net", "delay": 5000},
+		},
+	})
+	assert.NoError(t, res.Error)
+
+	res = usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    "updateEmulation",
+			Payload: map[string]interface{}{"container": "node0", "delay": 5000},
+		},
+	})
+	assert.Equal(t, ErrEmptyFieldNetwork, res)
+	service.AssertExpectations(t)
+}
+
+func TestDockerUseCase_Execute_ClearEmulation(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
+	service.On("ClearEmulation", mock.Anything, mock.Anything,
+		entity.ClearEmulation{Container: "node0", Network: "net"}).Return(
+		entity.Result{Type: entity.SuccessType}).Once()
+
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
+
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    "clearEmulation",
+			Payload: map[string]interface{}{"container": "node0", "network": "net"},
+		},
+	})
+	assert.NoError(t, res.Error)
+	service.AssertExpectations(t)
+}
+
+func TestDockerUseCase_Execute_LinkEmulation(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
+	service.On("LinkEmulation", mock.Anything, mock.Anything, mock.Anything).Return(
+		entity.Result{Type: entity.SuccessType}).Run(func(args mock.Arguments) {
+		le := args.Get(2).(entity.LinkEmulation)
+		assert.Equal(t, "net", le.Network)
+		require.Len(t, le.Links, 1)
+		assert.Equal(t, "node0", le.Links[0].Source)
+		assert.Equal(t, "node1", le.Links[0].Destination)
+		assert.True(t, le.Links[0].Bidirectional)
+		assert.Equal(t, 200000, le.Links[0].Delay)
+	}).Once()
+
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
+
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type: "linkEmulation",
+			Payload: map[string]interface{}{
+				"network": "net",
+				"links": []map[string]interface{}{{
+					"source":        "node0",
+					"destination":   "node1",
+					"bidirectional": true,
+					"delay":         200000,
+				}},
+			},
+		},
+	})
+	assert.NoError(t, res.Error)
+	service.AssertExpectations(t)
+}
+
+func TestDockerUseCase_Execute_PartitionNetwork(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Twice()
+	service.On("PartitionNetwork", mock.Anything, mock.Anything, entity.PartitionNetwork{
+		Network: "net",
+		Groups:  [][]string{{"node0"}, {"node1", "node2"}},
+		OneWay:  true,
+	}).Return(entity.Result{Type: entity.SuccessType}).Once()
+
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
+
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type: "partitionNetwork",
+			Payload: map[string]interface{}{
+				"network": "net",
+				"groups":  [][]string{{"node0"}, {"node1", "node2"}},
+				"oneWay":  true,
+			},
+		},
+	})
+	assert.NoError(t, res.Error)
+
+	res = usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    "partitionNetwork",
+			Payload: map[string]interface{}{"network": "net", "groups": [][]string{{"node0"}}},
+		},
+	})
+	assert.True(t, res.IsFatal())
+	service.AssertExpectations(t)
+}
+
+func TestDockerUseCase_Execute_HealNetwork(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Once()
+	service.On("HealNetwork", mock.Anything, mock.Anything, entity.HealNetwork{Name: "split"}).Return(
+		entity.Result{Type: entity.SuccessType}).Once()
+
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
+
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    "healNetwork",
+			Payload: map[string]interface{}{"name": "split"},
+		},
+	})
+	assert.NoError(t, res.Error)
+	service.AssertExpectations(t)
+}
+
+func TestDockerUseCase_Execute_EmulationSchedule(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Twice()
+	schedules := new(mockService.ScheduleService)
+	schedules.On("Start", mock.Anything, "127.0.0.1", mock.Anything).Return(
+		entity.Result{Type: entity.SuccessType}).Run(
+		func(args mock.Arguments) {
+			es, ok := args.Get(2).(entity.EmulationSchedule)
+			require.True(t, ok)
+			assert.Equal(t, "node0", es.Container)
+			require.Len(t, es.Entries, 2)
+			assert.Equal(t, 30*time.Second, es.Entries[1].Offset.Duration)
+			assert.Equal(t, 200000, es.Entries[1].Delay)
+		}).Once()
+
+	usecase := NewDockerUseCase(service, schedules, nil, nil, logrus.New())
+
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type: "emulationSchedule",
+			Payload: map[string]interface{}{
+				"container": "node0",
+				"network":   "net",
+				"entries": []map[string]interface{}{
+					{"offset": 0, "delay": 100000},
+					{"offset": "30s", "delay": 200000, "loss": 1},
+				},
+			},
+		},
+	})
+	assert.NoError(t, res.Error)
+
+	res = usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    "emulationSchedule",
+			Payload: map[string]interface{}{"container": "node0", "network": "net"},
+		},
+	})
+	assert.True(t, res.IsFatal())
+	service.AssertExpectations(t)
+	schedules.AssertExpectations(t)
+}
+
+func TestDockerUseCase_Execute_Chaos(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Twice()
+	chaos := new(mockService.ChaosService)
+	chaos.On("Start", mock.Anything, "127.0.0.1", mock.Anything).Return(
+		entity.Result{Type: entity.SuccessType}).Run(
+		func(args mock.Arguments) {
+			c, ok := args.Get(2).(entity.Chaos)
+			require.True(t, ok)
+			assert.Equal(t, map[string]string{"role": "validator"}, c.Selector)
+			assert.Equal(t, []string{"kill", "pause"}, c.Faults)
+			assert.Equal(t, 30*time.Second, c.Interval.Duration)
+			assert.Equal(t, int64(7), c.Seed)
+		}).Once()
+
+	usecase := NewDockerUseCase(service, nil, chaos, nil, logrus.New())
+
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type: "chaos",
+			Payload: map[string]interface{}{
+				"selector": map[string]string{"role": "validator"},
+				"faults":   []string{"kill", "pause"},
+				"fraction": 0.25,
+				"interval": "30s",
+				"seed":     7,
+			},
+		},
+	})
+	assert.NoError(t, res.Error)
+
+	res = usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    "chaos",
+			Payload: map[string]interface{}{"faults": []string{"partition"}, "fraction": 0.5, "interval": "30s"},
+		},
+	})
+	assert.True(t, res.IsFatal())
+	service.AssertExpectations(t)
+	chaos.AssertExpectations(t)
+}
+
+func TestDockerUseCase_Execute_GetEmulation(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Twice()
+	service.On("GetEmulation", mock.Anything, mock.Anything, entity.GetEmulation{
+		Container: "node0", Network: "net"}).Return(entity.Result{Type: entity.SuccessType}).Once()
+
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
+
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    "getEmulation",
+			Payload: map[string]interface{}{"container": "node0", "network": "net"},
+		},
+	})
+	assert.NoError(t, res.Error)
+
+	res = usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    "getEmulation",
+			Payload: map[string]interface{}{"container": "node0"},
+		},
+	})
+	assert.True(t, res.IsFatal())
+	service.AssertExpectations(t)
+}
+
+func TestDockerUseCase_Execute_ContainerLifecycle(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Times(7)
+	service.On("StopContainer", mock.Anything, mock.Anything, mock.Anything).Return(
+		entity.Result{Type: entity.SuccessType}).Run(func(args mock.Arguments) {
+		sc := args.Get(2).(entity.StopContainer)
+		assert.Equal(t, "node0", sc.Name)
+		assert.Equal(t, 30*time.Second, sc.Timeout.Duration)
+	}).Once()
+	service.On("RestartContainer", mock.Anything, mock.Anything, entity.RestartContainer{Name: "node0"}).Return(
+		entity.Result{Type: entity.SuccessType}).Once()
+	service.On("KillContainer", mock.Anything, mock.Anything,
+		entity.KillContainer{Name: "node0", Signal: "SIGKILL"}).Return(entity.Result{Type: entity.SuccessType}).Once()
+	service.On("PauseContainer", mock.Anything, mock.Anything, "node0").Return(
+		entity.Result{Type: entity.SuccessType}).Once()
+	service.On("UnpauseContainer", mock.Anything, mock.Anything, "node0").Return(
+		entity.Result{Type: entity.SuccessType}).Once()
+
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
+
+	payloads := map[command.OrderType]map[string]interface{}{
+		"stopContainer":    {"name": "node0", "timeout": "30s"},
+		"restartContainer": {"name": "node0"},
+		"killContainer":    {"name": "node0", "signal": "SIGKILL"},
+		"pauseContainer":   {"name": "node0"},
+		"unpauseContainer": {"name": "node0"},
+	}
+	for orderType, payload := range payloads {
+		res := usecase.Execute(context.TODO(), command.Command{
+			ID:     "TEST",
+			Target: testTarget,
+			Order:  command.Order{Type: orderType, Payload: payload},
+		})
+		assert.NoError(t, res.Error, orderType)
+	}
+
+	for _, order := range []command.Order{
+		{Type: "killContainer", Payload: map[string]interface{}{"name": "node0", "signal": "$(reboot)"}},
+		{Type: "pauseContainer", Payload: map[string]interface{}{}},
+	} {
+		res := usecase.Execute(context.TODO(), command.Command{ID: "TEST", Target: testTarget, Order: order})
+		assert.True(t, res.IsFatal(), order)
+	}
+	service.AssertExpectations(t)
+}
+
+func TestDockerUseCase_Execute_UpdateContainerResources(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Twice()
+	service.On("UpdateContainerResources", mock.Anything, mock.Anything, mock.Anything).Return(
+		entity.Result{Type: entity.SuccessType}).Run(func(args mock.Arguments) {
+		ucr := args.Get(2).(entity.UpdateContainerResources)
+		assert.Equal(t, "node0", ucr.Name)
+		assert.Equal(t, "0.25", ucr.Cpus)
+		assert.Equal(t, "256MB", ucr.Memory)
+		require.NotNil(t, ucr.PidsLimit)
+		assert.Equal(t, int64(50), *ucr.PidsLimit)
+	}).Once()
+
+	usecase := NewDockerUseCase(service, nil, nil, nil, logrus.New())
+
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    "updateContainerResources",
+			Payload: map[string]interface{}{"name": "node0", "cpus": "0.25", "memory": "256MB", "pidsLimit": 50},
+		},
+	})
+	assert.NoError(t, res.Error)
+
+	res = usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    "updateContainerResources",
+			Payload: map[string]interface{}{"name": "node0"},
+		},
+	})
+	assert.True(t, res.IsFatal())
+	service.AssertExpectations(t)
+}
+
+func TestDockerUseCase_Execute_CollectStats(t *testing.T) {
+	service := new(mockService.DockerService)
+	service.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil).Twice()
+	stats := new(mockService.StatsService)
+	stats.On("Start", mock.Anything, "127.0.0.1", mock.Anything).Return(
+		entity.Result{Type: entity.SuccessType}).Run(
+		func(args mock.Arguments) {
+			cs, ok := args.Get(2).(entity.CollectStats)
+			require.True(t, ok)
+			assert.Equal(t, 5*time.Second, cs.Interval.Duration)
+			assert.Equal(t, map[string]string{"role": "validator"}, cs.Selector)
+		}).Once()
+
+	usecase := NewDockerUseCase(service, nil, nil, stats, logrus.New())
+
+	res := usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    "collectStats",
+			Payload: map[string]interface{}{"interval": "5s", "selector": map[string]string{"role": "validator"}},
+		},
+	})
+	assert.NoError(t, res.Error)
+
+	res = usecase.Execute(context.TODO(), command.Command{
+		ID:     "TEST",
+		Target: testTarget,
+		Order: command.Order{
+			Type:    "collectStats",
+			Payload: map[string]interface{}{"interval": "-5s"},
+		},
+	})
+	assert.True(t, res.IsFatal())
+	service.AssertExpectations(t)
+	stats.AssertExpectations(t)
+}
@@ -8,7 +8,14 @@ package validator
 
 import (
 	"errors"
+	"fmt"
+	"net"
+	"path"
+	"regexp"
 	"strconv"
+	"strings"
+
+	"github.com/whiteblock/genesis/pkg/entity"
 
 	"github.com/whiteblock/definition/command"
 )
@@ -19,10 +26,36 @@ var (
 
 	// ErrMissingImage means missing image field
 	ErrMissingImage = errors.New(`missing field "image"`)
+
+	// ErrMissingContainer means missing container field
+	ErrMissingContainer = errors.New(`missing field "container"`)
+
+	// ErrInvalidPort means the port is not a valid port number
+	ErrInvalidPort = errors.New(`field "port" must be between 1 and 65535`)
+
+	// ErrMissingPattern means missing pattern field
+	ErrMissingPattern = errors.New(`missing field "pattern"`)
+
+	// ErrMissingNetwork means missing network field
+	ErrMissingNetwork = errors.New(`missing field "network"`)
+
+	// ErrInvalidPartitionName means the name of a partition contains characters which are not allowed
+	ErrInvalidPartitionName = errors.New(`field "name" may only contain letters, digits, '.', '_' and '-'`)
+)
+
+var (
+	ulimitNames = map[string]bool{
+		"core": true, "cpu": true, "data": true, "fsize": true, "locks": true, "memlock": true,
+		"msgqueue": true, "nice": true, "nofile": true, "nproc": true, "rss": true, "rtprio": true,
+		"rttime": true, "sigpending": true, "stack": true,
+	}
+	sysctlExp     = regexp.MustCompile(`^[a-zA-Z0-9_]+([./][a-zA-Z0-9_-]+)*$`)
+	capabilityExp = regexp.MustCompile(`^(?i)(ALL|(CAP_)?[A-Z_]+)$`)
+	healthchecks  = map[string]bool{"NONE": true, "CMD": true, "CMD-SHELL": true}
 )
 
 // Container validates a container command payload
-func Container(cntr command.Container) error {
+func Container(cntr entity.Container) error {
 	if len(cntr.Name) == 0 {
 		return ErrMissingName
 	}
@@ -40,5 +73,466 @@ func Container(cntr command.Container) error {
 	if len(cntr.Image) == 0 {
 		return ErrMissingImage
 	}
+	return containerOptions(cntr)
+}
+
+// containerOptions validates the options of a container which are not a part of the definition
+func containerOptions(cntr entity.Container) error {
+	if len(cntr.Workdir) > 0 && !path.IsAbs(cntr.Workdir) {
+		return fmt.Errorf(`the workdir "%s" is not an absolute path`, cntr.Workdir)
+	}
+	for name, limit := range cntr.Ulimits {
+		if !ulimitNames[name] {
+			return fmt.Errorf(`unknown ulimit "%s"`, name)
+		}
+		if limit.Soft < -1 || limit.Hard < -1 {
+			return fmt.Errorf(`the limits of ulimit "%s" must be -1 for unlimited, or positive`, name)
+		}
+		if limit.Hard != -1 && (limit.Soft == -1 || limit.Soft > limit.Hard) {
+			return fmt.Errorf(`the soft limit of ulimit "%s" must not be more than its hard limit`, name)
+		}
+	}
+	for key := range cntr.Sysctls {
+		if !sysctlExp.MatchString(key) {
+			return fmt.Errorf(`invalid sysctl "%s"`, key)
+		}
+	}
+	for _, capability := range append(append([]string{}, cntr.CapAdd...), cntr.CapDrop...) {
+		if !capabilityExp.MatchString(capability) {
+			return fmt.Errorf(`invalid capability "%s"`, capability)
+		}
+	}
+	for target := range cntr.Tmpfs {
+		if !path.IsAbs(target) {
+			return fmt.Errorf(`the tmpfs mount "%s" is not an absolute path`, target)
+		}
+	}
+	shmSize, err := cntr.GetShmSize()
+	if err != nil || shmSize < 0 || (len(cntr.ShmSize) > 0 && shmSize == 0) {
+		return fmt.Errorf(`invalid shm size "%s"`, cntr.ShmSize)
+	}
+	for _, host := range cntr.ExtraHosts {
+		parts := strings.SplitN(host, ":", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || net.ParseIP(parts[1]) == nil {
+			return fmt.Errorf(`the extra host "%s" must be host:ip`, host)
+		}
+	}
+	for _, server := range cntr.DNS {
+		if net.ParseIP(server) == nil {
+			return fmt.Errorf(`the DNS server "%s" is not an IP address`, server)
+		}
+	}
+	_, err = cntr.GetRestartPolicy()
+	if err != nil {
+		return err
+	}
+	if cntr.Healthcheck != nil {
+		err = healthcheck(*cntr.Healthcheck)
+		if err != nil {
+			return err
+		}
+	}
+	return networkAttachments(cntr)
+}
+
+func isIPv4(addr string) bool {
+	ip := net.ParseIP(addr)
+	return ip != nil && ip.To4() != nil
+}
+
+func isIPv6(addr string) bool {
+	ip := net.ParseIP(addr)
+	return ip != nil && ip.To4() == nil
+}
+
+func networkAttachments(cntr entity.Container) error {
+	if len(cntr.IPv6) > 0 && !isIPv6(cntr.IPv6) {
+		return fmt.Errorf(`invalid IPv6 address "%s"`, cntr.IPv6)
+	}
+	seen := map[string]bool{}
+	for _, attachment := range cntr.Networks {
+		if len(attachment.Name) == 0 {
+			return errors.New("a network attachment has no name")
+		}
+		if seen[attachment.Name] {
+			return fmt.Errorf(`the network "%s" is attached more than once`, attachment.Name)
+		}
+		seen[attachment.Name] = true
+		if attachment.Name == cntr.Network && len(attachment.IP) > 0 && len(cntr.IP) > 0 &&
+			attachment.IP != cntr.IP {
+			return fmt.Errorf(`conflicting IP addresses for the network "%s"`, attachment.Name)
+		}
+		if attachment.Name == cntr.Network && len(attachment.IPv6) > 0 && len(cntr.IPv6) > 0 &&
+			attachment.IPv6 != cntr.IPv6 {
+			return fmt.Errorf(`conflicting IPv6 addresses for the network "%s"`, attachment.Name)
+		}
+		if len(attachment.IP) > 0 && !isIPv4(attachment.IP) {
+			return fmt.Errorf(`invalid IP address "%s" for the network "%s"`, attachment.IP, attachment.Name)
+		}
+		if len(attachment.IPv6) > 0 && !isIPv6(attachment.IPv6) {
+			return fmt.Errorf(`invalid IPv6 address "%s" for the network "%s"`, attachment.IPv6, attachment.Name)
+		}
+		if len(attachment.MacAddress) > 0 {
+			_, err := net.ParseMAC(attachment.MacAddress)
+			if err != nil {
+				return fmt.Errorf(`invalid MAC address "%s" for the network "%s"`,
+					attachment.MacAddress, attachment.Name)
+			}
+		}
+		for _, alias := range attachment.Aliases {
+			if len(alias) == 0 {
+				return fmt.Errorf(`the network "%s" has an empty alias`, attachment.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Network validates a create network command payload
+func Network(n entity.Network) error {
+	if len(n.Name) == 0 {
+		return ErrMissingName
+	}
+	err := networkDriver(n)
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for _, subnet := range n.GetSubnets() {
+		if len(subnet.Subnet) == 0 {
+			if len(subnet.Gateway) > 0 {
+				return fmt.Errorf(`the gateway "%s" has no subnet`, subnet.Gateway)
+			}
+			if len(subnet.IPRange) > 0 {
+				return fmt.Errorf(`the IP range "%s" has no subnet`, subnet.IPRange)
+			}
+			continue
+		}
+		_, cidr, err := net.ParseCIDR(subnet.Subnet)
+		if err != nil {
+			return fmt.Errorf(`invalid subnet "%s"`, subnet.Subnet)
+		}
+		if seen[cidr.String()] {
+			return fmt.Errorf(`the subnet "%s" is given more than once`, subnet.Subnet)
+		}
+		seen[cidr.String()] = true
+		if len(subnet.Gateway) > 0 {
+			gateway := net.ParseIP(subnet.Gateway)
+			if gateway == nil || !cidr.Contains(gateway) {
+				return fmt.Errorf(`the gateway "%s" is not in the subnet "%s"`, subnet.Gateway, subnet.Subnet)
+			}
+		}
+		if len(subnet.IPRange) > 0 {
+			_, ipRange, err := net.ParseCIDR(subnet.IPRange)
+			if err != nil {
+				return fmt.Errorf(`invalid IP range "%s"`, subnet.IPRange)
+			}
+			rangeOnes, rangeBits := ipRange.Mask.Size()
+			ones, bits := cidr.Mask.Size()
+			if rangeBits != bits || rangeOnes < ones || !cidr.Contains(ipRange.IP) {
+				return fmt.Errorf(`the IP range "%s" is not in the subnet "%s"`, subnet.IPRange, subnet.Subnet)
+			}
+		}
+	}
+	return nil
+}
+
+var networkDrivers = map[string]bool{
+	entity.BridgeDriver: true, entity.OverlayDriver: true, entity.MacvlanDriver: true,
+	entity.IpvlanDriver: true, entity.NoneDriver: true,
+}
+
+// networkDriver validates the driver of a network along with its options
+func networkDriver(n entity.Network) error {
+	if len(n.Driver) > 0 && !networkDrivers[n.Driver] {
+		return fmt.Errorf(`unknown network driver "%s"`, n.Driver)
+	}
+	if n.Driver == entity.NoneDriver {
+		if len(n.Subnet) > 0 || len(n.Gateway) > 0 || len(n.IPRange) > 0 || len(n.Subnets) > 0 ||
+			n.EnableIPv6 || n.MTU != 0 || n.Internal || n.Encrypted || len(n.Options) > 0 {
+			return errors.New("a network without a driver takes no other options")
+		}
+		return nil
+	}
+	if n.MTU != 0 {
+		if n.Driver == entity.MacvlanDriver || n.Driver == entity.IpvlanDriver {
+			return fmt.Errorf("the MTU of a %s network is that of its parent interface", n.Driver)
+		}
+		minMTU := 68
+		if n.IsIPv6() {
+			minMTU = 1280
+		}
+		if n.MTU < minMTU || n.MTU > 65535 {
+			return fmt.Errorf("the MTU must be between %d and 65535", minMTU)
+		}
+	}
+	if n.Encrypted && n.Driver != entity.OverlayDriver && (len(n.Driver) > 0 || !n.Global) {
+		return errors.New("only an overlay network can be encrypted")
+	}
+	for key := range n.Options {
+		if len(key) == 0 {
+			return errors.New("a driver option has no name")
+		}
+	}
+	return nil
+}
+
+// ContainerNetwork validates an attach network command payload
+func ContainerNetwork(cn entity.ContainerNetwork) error {
+	if len(cn.IP) > 0 && !isIPv4(cn.IP) {
+		return fmt.Errorf(`invalid IP address "%s"`, cn.IP)
+	}
+	if len(cn.IPv6) > 0 && !isIPv6(cn.IPv6) {
+		return fmt.Errorf(`invalid IPv6 address "%s"`, cn.IPv6)
+	}
+	return nil
+}
+
+func healthcheck(hc entity.Healthcheck) error {
+	if len(hc.Test) == 0 || !healthchecks[hc.Test[0]] {
+		return errors.New(`the test of the healthcheck must start with NONE, CMD or CMD-SHELL`)
+	}
+	if hc.Test[0] != "NONE" && len(hc.Test) < 2 {
+		return errors.New("the test of the healthcheck has no command")
+	}
+	for _, dur := range []command.Duration{hc.Interval, hc.Timeout, hc.StartPeriod} {
+		if dur.IsInfinite() || dur.Duration < 0 {
+			return errors.New("the times of the healthcheck must be finite, positive durations")
+		}
+	}
+	if hc.Retries < 0 {
+		return errors.New("the retries of the healthcheck must not be negative")
+	}
+	return nil
+}
+
+// WaitForReady validates a wait for ready command payload
+func WaitForReady(wr entity.WaitForReady) error {
+	if len(wr.Container) == 0 {
+		return ErrMissingContainer
+	}
+	if wr.Timeout.IsInfinite() || wr.Timeout.Duration < 0 {
+		return errors.New(`field "timeout" must be a finite, positive duration`)
+	}
+	if wr.Interval.IsInfinite() || wr.Interval.Duration < 0 {
+		return errors.New(`field "interval" must be a finite, positive duration`)
+	}
+
+	switch wr.Check {
+	case entity.ReadyCheckHealth:
+	case entity.ReadyCheckTCP, entity.ReadyCheckHTTP:
+		if wr.Port < 1 || wr.Port > 65535 {
+			return ErrInvalidPort
+		}
+	case entity.ReadyCheckLog:
+		if len(wr.Pattern) == 0 {
+			return ErrMissingPattern
+		}
+		_, err := regexp.Compile(wr.Pattern)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf(`unknown check "%s"`, wr.Check)
+	}
+	return nil
+}
+
+var partitionName = regexp.MustCompile(`^[a-zA-Z0-9_.-]*$`)
+
+// PartitionNetwork validates a partition network command payload
+func PartitionNetwork(pn entity.PartitionNetwork) error {
+	if !partitionName.MatchString(pn.Name) {
+		return ErrInvalidPartitionName
+	}
+	if len(pn.Network) == 0 {
+		return ErrMissingNetwork
+	}
+	if len(pn.Groups) < 2 {
+		return errors.New(`field "groups" needs at least two groups`)
+	}
+	seen := map[string]bool{}
+	for i, group := range pn.Groups {
+		if len(group) == 0 {
+			return fmt.Errorf("group %d is empty", i)
+		}
+		for _, cntr := range group {
+			if len(cntr) == 0 {
+				return fmt.Errorf("group %d has a container without a name", i)
+			}
+			if seen[cntr] {
+				return fmt.Errorf(`container "%s" is in more than one group`, cntr)
+			}
+			seen[cntr] = true
+		}
+	}
+	return nil
+}
+
+// HealNetwork validates a heal network command payload
+func HealNetwork(hn entity.HealNetwork) error {
+	if !partitionName.MatchString(hn.Name) {
+		return ErrInvalidPartitionName
+	}
+	return nil
+}
+
+// EmulationSchedule validates an emulation schedule command payload. The entries of a trace file
+// can only be validated once it has been read, with ScheduleEntries.
+func EmulationSchedule(es entity.EmulationSchedule) error {
+	if len(es.Container) == 0 {
+		return ErrMissingContainer
+	}
+	if len(es.Network) == 0 {
+		return ErrMissingNetwork
+	}
+	if len(es.TraceFile) > 0 {
+		if len(es.Entries) > 0 {
+			return errors.New(`only one of "entries" and "traceFile" may be given`)
+		}
+		return nil
+	}
+	return ScheduleEntries(es.Entries)
+}
+
+// ScheduleEntries validates the entries of an emulation schedule
+func ScheduleEntries(entries []entity.ScheduleEntry) error {
+	if len(entries) == 0 {
+		return errors.New("the schedule has no entries")
+	}
+	for i, entry := range entries {
+		if entry.Offset.IsInfinite() || entry.Offset.Duration < 0 {
+			return fmt.Errorf("entry %d: the offset must be a finite, positive duration", i)
+		}
+		err := entry.Validate()
+		if err != nil {
+			return fmt.Errorf("entry %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+var signalExp = regexp.MustCompile(`^([0-9]+|(SIG)?[A-Z][A-Z0-9]*([+-][0-9]+)?)$`)
+
+// StopContainer validates a stop container command payload
+func StopContainer(sc entity.StopContainer) error {
+	if len(sc.Name) == 0 {
+		return ErrMissingName
+	}
+	if sc.Timeout.IsInfinite() || sc.Timeout.Duration < 0 {
+		return errors.New(`field "timeout" must be a finite, positive duration`)
+	}
+	return nil
+}
+
+// RestartContainer validates a restart container command payload
+func RestartContainer(rc entity.RestartContainer) error {
+	return StopContainer(entity.StopContainer(rc))
+}
+
+// KillContainer validates a kill container command payload
+func KillContainer(kc entity.KillContainer) error {
+	if len(kc.Name) == 0 {
+		return ErrMissingName
+	}
+	if len(kc.Signal) > 0 && !signalExp.MatchString(kc.Signal) {
+		return fmt.Errorf(`invalid signal "%s"`, kc.Signal)
+	}
+	return nil
+}
+
+// UpdateContainerResources validates an update container resources command payload
+func UpdateContainerResources(ucr entity.UpdateContainerResources) error {
+	if len(ucr.Name) == 0 {
+		return ErrMissingName
+	}
+	if len(ucr.Cpus) == 0 && len(ucr.BoundCPUs) == 0 && len(ucr.Memory) == 0 &&
+		len(ucr.MemorySwap) == 0 && ucr.PidsLimit == nil {
+		return errors.New("no resources to update")
+	}
+	_, err := ucr.GetNanoCPUs()
+	if err != nil {
+		return fmt.Errorf(`invalid cpus "%s": %v`, ucr.Cpus, err)
+	}
+	for _, cpu := range ucr.BoundCPUs {
+		if cpu < 0 {
+			return fmt.Errorf("invalid bound cpu %d", cpu)
+		}
+	}
+	mem, err := ucr.GetMemory()
+	if err != nil || mem < 0 || (len(ucr.Memory) > 0 && mem == 0) {
+		return fmt.Errorf(`invalid memory "%s"`, ucr.Memory)
+	}
+	swap, err := ucr.GetMemorySwap()
+	if err != nil || swap < -1 || (len(ucr.MemorySwap) > 0 && swap == 0) {
+		return fmt.Errorf(`invalid memory swap "%s"`, ucr.MemorySwap)
+	}
+	if mem > 0 && swap > 0 && swap < mem {
+		return errors.New("the memory swap limit must not be less than the memory limit")
+	}
+	if ucr.PidsLimit != nil && *ucr.PidsLimit < -1 {
+		return errors.New(`field "pidsLimit" must be -1 for unlimited, or a positive number`)
+	}
+	return nil
+}
+
+// Chaos validates a chaos command payload
+func Chaos(c entity.Chaos) error {
+	if !partitionName.MatchString(c.Name) {
+		return ErrInvalidPartitionName
+	}
+	if len(c.Faults) == 0 {
+		return errors.New(`field "faults" needs at least one fault`)
+	}
+	for _, fault := range c.Faults {
+		switch fault {
+		case entity.KillFault, entity.PauseFault, entity.RestartFault:
+		case entity.PartitionFault:
+			if len(c.Network) == 0 {
+				return ErrMissingNetwork
+			}
+		default:
+			return fmt.Errorf(`unknown fault "%s"`, fault)
+		}
+	}
+	if c.Fraction <= 0 || c.Fraction > 1 {
+		return errors.New(`field "fraction" must be greater than 0 and at most 1`)
+	}
+	if c.Interval.IsInfinite() || c.Interval.Duration <= 0 {
+		return errors.New(`field "interval" must be a finite, positive duration`)
+	}
+	if c.Duration.IsInfinite() || c.Duration.Duration < 0 || c.Duration.Duration > c.Interval.Duration {
+		return errors.New(`field "duration" must be a positive duration, no longer than the interval`)
+	}
+	if c.Rounds < 0 {
+		return errors.New(`field "rounds" must not be negative`)
+	}
+	return nil
+}
+
+// CopyFromContainer validates a copy from container command payload
+func CopyFromContainer(cfc entity.CopyFromContainer) error {
+	if len(cfc.Path) == 0 {
+		return errors.New(`missing field "path"`)
+	}
+	if !path.IsAbs(cfc.Path) {
+		return errors.New(`field "path" must be an absolute path`)
+	}
+	if len(cfc.Name) > 0 && !partitionName.MatchString(cfc.Name) {
+		return errors.New(`field "name" may only contain letters, digits, '.', '_' and '-'`)
+	}
+	for _, cntr := range cfc.Containers {
+		if len(cntr) == 0 {
+			return errors.New(`field "containers" must not contain empty names`)
+		}
+	}
+	return nil
+}
+
+// CollectStats validates a collect stats command payload
+func CollectStats(cs entity.CollectStats) error {
+	if cs.Interval.IsInfinite() || cs.Interval.Duration < 0 {
+		return errors.New("the interval must be a finite, positive duration")
+	}
 	return nil
 }
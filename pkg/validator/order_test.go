@@ -8,8 +8,13 @@ package validator
 
 import (
 	"testing"
+	"time"
+
+	"github.com/whiteblock/genesis/pkg/entity"
+	"github.com/whiteblock/genesis/pkg/netem"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/whiteblock/definition/command"
 )
 
@@ -21,7 +26,7 @@ func TestOrderValidator_ValidateContainer_Success(t *testing.T) {
 		Memory:   "2GB",
 		Image:    "t",
 	}
-	assert.NoError(t, Container(testContainer))
+	assert.NoError(t, Container(entity.Container{Container: testContainer}))
 }
 
 func TestOrderValidator_ValidateContainer_BadName(t *testing.T) {
@@ -32,7 +37,7 @@ func TestOrderValidator_ValidateContainer_BadName(t *testing.T) {
 		Memory:   "2GB",
 		Image:    "t",
 	}
-	assert.Error(t, Container(testContainer))
+	assert.Error(t, Container(entity.Container{Container: testContainer}))
 }
 
 func TestOrderValidator_ValidateContainer_BadCPUs(t *testing.T) {
@@ -43,7 +48,7 @@ func TestOrderValidator_ValidateContainer_BadCPUs(t *testing.T) {
 		Memory:   "2GB",
 		Image:    "t",
 	}
-	assert.Error(t, Container(testContainer))
+	assert.Error(t, Container(entity.Container{Container: testContainer}))
 }
 
 func TestOrderValidator_ValidateContainer_BadMem(t *testing.T) {
@@ -54,7 +59,7 @@ func TestOrderValidator_ValidateContainer_BadMem(t *testing.T) {
 		Memory:   "fdwe2",
 		Image:    "t",
 	}
-	assert.Error(t, Container(testContainer))
+	assert.Error(t, Container(entity.Container{Container: testContainer}))
 }
 
 func TestOrderValidator_ValidateContainer_BadImage(t *testing.T) {
@@ -65,5 +70,330 @@ func TestOrderValidator_ValidateContainer_BadImage(t *testing.T) {
 		Memory:   "2GB",
 		Image:    "",
 	}
-	assert.Error(t, Container(testContainer))
+	assert.Error(t, Container(entity.Container{Container: testContainer}))
+}
+
+func TestOrderValidator_ValidateContainer_Options(t *testing.T) {
+	base := command.Container{Name: "t", Cpus: "1", Memory: "1GB", Image: "t"}
+	hc := &entity.Healthcheck{Test: []string{"CMD-SHELL", "curl -f localhost:8545 || exit 1"}, Retries: 3}
+	hc.Interval.Duration = 5 * time.Second
+	assert.NoError(t, Container(entity.Container{
+		Container:     base,
+		Workdir:       "/data",
+		Ulimits:       map[string]entity.Ulimit{"nofile": {Soft: 1024, Hard: 65536}, "memlock": {Soft: -1, Hard: -1}},
+		Sysctls:       map[string]string{"net.core.somaxconn": "1024", "net/ipv4/ip_forward": "1"},
+		CapAdd:        []string{"NET_ADMIN", "cap_sys_ptrace"},
+		CapDrop:       []string{"ALL"},
+		Tmpfs:         map[string]string{"/run": "size=64m"},
+		ShmSize:       "256MB",
+		ExtraHosts:    []string{"bootnode:10.0.0.2", "v6:fd00::2"},
+		DNS:           []string{"8.8.8.8"},
+		RestartPolicy: "on-failure:5",
+		Healthcheck:   hc,
+	}))
+	assert.NoError(t, Container(entity.Container{Container: base,
+		Healthcheck: &entity.Healthcheck{Test: []string{"NONE"}}}))
+
+	invalid := []entity.Container{
+		{Workdir: "data"},
+		{Ulimits: map[string]entity.Ulimit{"files": {Soft: 1, Hard: 1}}},
+		{Ulimits: map[string]entity.Ulimit{"nofile": {Soft: 2048, Hard: 1024}}},
+		{Ulimits: map[string]entity.Ulimit{"nofile": {Soft: -1, Hard: 1024}}},
+		{Sysctls: map[string]string{"net.core.somaxconn=1": "1"}},
+		{CapAdd: []string{"NET ADMIN"}},
+		{Tmpfs: map[string]string{"run": ""}},
+		{ShmSize: "huge"},
+		{ExtraHosts: []string{"bootnode"}},
+		{ExtraHosts: []string{"bootnode:nowhere"}},
+		{DNS: []string{"dns.google"}},
+		{RestartPolicy: "sometimes"},
+		{RestartPolicy: "always:3"},
+		{RestartPolicy: "on-failure:-1"},
+		{Healthcheck: &entity.Healthcheck{Test: []string{"curl"}}},
+		{Healthcheck: &entity.Healthcheck{Test: []string{"CMD"}}},
+		{Healthcheck: &entity.Healthcheck{Test: []string{"NONE"}, Retries: -1}},
+	}
+	for _, cntr := range invalid {
+		cntr.Container = base
+		assert.Error(t, Container(cntr), cntr)
+	}
+}
+
+func TestOrderValidator_ValidateContainer_Networks(t *testing.T) {
+	base := command.Container{Name: "t", Cpus: "1", Memory: "1GB", Image: "t", Network: "net0", IP: "10.0.0.2"}
+	assert.NoError(t, Container(entity.Container{Container: base, Networks: []entity.NetworkAttachment{
+		{Name: "net0", Aliases: []string{"node"}, MacAddress: "02:42:ac:11:00:02"},
+		{Name: "net1", IP: "10.1.0.2", IPv6: "fd00:1::2"},
+		{Name: "net2"},
+	}, IPv6: "fd00::2"}))
+
+	invalid := [][]entity.NetworkAttachment{
+		{{IP: "10.1.0.2"}},
+		{{Name: "net1"}, {Name: "net1"}},
+		{{Name: "net0", IP: "10.0.0.3"}},
+		{{Name: "net1", IP: "10.1.0"}},
+		{{Name: "net1", MacAddress: "02:42"}},
+		{{Name: "net1", Aliases: []string{""}}},
+		{{Name: "net1", IP: "fd00:1::2"}},
+		{{Name: "net1", IPv6: "10.1.0.2"}},
+		{{Name: "net0", IPv6: "fd00::3"}},
+	}
+	for _, networks := range invalid {
+		assert.Error(t, Container(entity.Container{Container: base, Networks: networks, IPv6: "fd00::2"}),
+			networks)
+	}
+	assert.Error(t, Container(entity.Container{Container: base, IPv6: "10.0.0.2"}))
+}
+
+func TestOrderValidator_Network(t *testing.T) {
+	valid := []entity.Network{
+		{Network: command.Network{Name: "net"}},
+		{Network: command.Network{Name: "net", Subnet: "10.0.0.0/24", Gateway: "10.0.0.1"}},
+		{Network: command.Network{Name: "net"}, EnableIPv6: true},
+		{Network: command.Network{Name: "net", Subnet: "10.0.0.0/24"}, Subnets: []entity.Subnet{
+			{Subnet: "fd00:1::/64", Gateway: "fd00:1::1"},
+		}},
+		{Network: command.Network{Name: "net"}, Subnets: []entity.Subnet{{Subnet: "fd00:1::/64"}}},
+		{Network: command.Network{Name: "net", Subnet: "10.0.0.0/16"}, IPRange: "10.0.1.0/24"},
+		{Network: command.Network{Name: "net"}, Subnets: []entity.Subnet{
+			{Subnet: "fd00:1::/64", IPRange: "fd00:1::/80"},
+		}},
+		{Network: command.Network{Name: "net"}, Driver: entity.BridgeDriver, MTU: 1400, Internal: true},
+		{Network: command.Network{Name: "net"}, Driver: entity.OverlayDriver, Encrypted: true},
+		{Network: command.Network{Name: "net", Global: true}, Encrypted: true},
+		{Network: command.Network{Name: "net"}, Driver: entity.MacvlanDriver,
+			Options: map[string]string{"parent": "eth0"}},
+		{Network: command.Network{Name: "net"}, Driver: entity.IpvlanDriver,
+			Options: map[string]string{"ipvlan_mode": "l2"}},
+		{Network: command.Network{Name: "net"}, Driver: entity.NoneDriver},
+	}
+	for _, n := range valid {
+		assert.NoError(t, Network(n), n)
+	}
+
+	invalid := []entity.Network{
+		{Network: command.Network{Subnet: "10.0.0.0/24"}},
+		{Network: command.Network{Name: "net", Subnet: "10.0.0.0"}},
+		{Network: command.Network{Name: "net", Gateway: "10.0.0.1"}},
+		{Network: command.Network{Name: "net", Subnet: "10.0.0.0/24", Gateway: "10.0.1.1"}},
+		{Network: command.Network{Name: "net", Subnet: "10.0.0.0/24"}, Subnets: []entity.Subnet{
+			{Subnet: "fd00:1::/64", Gateway: "10.0.0.1"},
+		}},
+		{Network: command.Network{Name: "net", Subnet: "10.0.0.0/24"}, Subnets: []entity.Subnet{
+			{Subnet: "10.0.0.0/24"},
+		}},
+		{Network: command.Network{Name: "net"}, Subnets: []entity.Subnet{{Gateway: "fd00:1::1"}}},
+		{Network: command.Network{Name: "net"}, IPRange: "10.0.1.0/24"},
+		{Network: command.Network{Name: "net", Subnet: "10.0.0.0/16"}, IPRange: "10.1.0.0/24"},
+		{Network: command.Network{Name: "net", Subnet: "10.0.0.0/16"}, IPRange: "10.0.0.0/8"},
+		{Network: command.Network{Name: "net", Subnet: "10.0.0.0/16"}, IPRange: "fd00:1::/80"},
+		{Network: command.Network{Name: "net", Subnet: "10.0.0.0/16"}, IPRange: "10.0.1.0"},
+		{Network: command.Network{Name: "net"}, Driver: "host"},
+		{Network: command.Network{Name: "net"}, MTU: 20},
+		{Network: command.Network{Name: "net"}, MTU: 1200, EnableIPv6: true},
+		{Network: command.Network{Name: "net"}, Driver: entity.MacvlanDriver, MTU: 1400},
+		{Network: command.Network{Name: "net"}, Driver: entity.BridgeDriver, Encrypted: true},
+		{Network: command.Network{Name: "net"}, Encrypted: true},
+		{Network: command.Network{Name: "net"}, Options: map[string]string{"": "x"}},
+		{Network: command.Network{Name: "net", Subnet: "10.0.0.0/16"}, Driver: entity.NoneDriver},
+		{Network: command.Network{Name: "net"}, Driver: entity.NoneDriver, Internal: true},
+	}
+	for _, n := range invalid {
+		assert.Error(t, Network(n), n)
+	}
+}
+
+func TestOrderValidator_ContainerNetwork(t *testing.T) {
+	cn := command.ContainerNetwork{Container: "node0", Network: "net"}
+	assert.NoError(t, ContainerNetwork(entity.ContainerNetwork{ContainerNetwork: cn}))
+	assert.NoError(t, ContainerNetwork(entity.ContainerNetwork{ContainerNetwork: cn, IPv6: "fd00::2"}))
+
+	cn.IP = "10.0.0.2"
+	assert.NoError(t, ContainerNetwork(entity.ContainerNetwork{ContainerNetwork: cn, IPv6: "fd00::2"}))
+	assert.Error(t, ContainerNetwork(entity.ContainerNetwork{ContainerNetwork: cn, IPv6: "10.0.0.3"}))
+
+	cn.IP = "fd00::2"
+	assert.Error(t, ContainerNetwork(entity.ContainerNetwork{ContainerNetwork: cn}))
+}
+
+func TestOrderValidator_WaitForReady(t *testing.T) {
+	var timeout command.Duration
+	require.NoError(t, timeout.UnmarshalJSON([]byte(`"30s"`)))
+
+	valid := []entity.WaitForReady{
+		{Container: "t", Check: entity.ReadyCheckHealth},
+		{Container: "t", Check: entity.ReadyCheckTCP, Port: 8545, Timeout: timeout},
+		{Container: "t", Check: entity.ReadyCheckHTTP, Port: 80, Path: "/health"},
+		{Container: "t", Check: entity.ReadyCheckLog, Pattern: "^started"},
+	}
+	for _, wr := range valid {
+		assert.NoError(t, WaitForReady(wr), wr)
+	}
+
+	invalid := []entity.WaitForReady{
+		{Check: entity.ReadyCheckHealth},
+		{Container: "t"},
+		{Container: "t", Check: "ping"},
+		{Container: "t", Check: entity.ReadyCheckTCP},
+		{Container: "t", Check: entity.ReadyCheckHTTP, Port: 70000},
+		{Container: "t", Check: entity.ReadyCheckLog},
+		{Container: "t", Check: entity.ReadyCheckLog, Pattern: "(unclosed"},
+	}
+	for _, wr := range invalid {
+		assert.Error(t, WaitForReady(wr), wr)
+	}
+}
+
+func TestOrderValidator_PartitionNetwork(t *testing.T) {
+	assert.NoError(t, PartitionNetwork(entity.PartitionNetwork{
+		Name:    "split-1",
+		Network: "net",
+		Groups:  [][]string{{"a"}, {"b", "c"}},
+	}))
+
+	invalid := []entity.PartitionNetwork{
+		{Network: "net", Groups: [][]string{{"a"}}},
+		{Groups: [][]string{{"a"}, {"b"}}},
+		{Network: "net", Groups: [][]string{{"a"}, {}}},
+		{Network: "net", Groups: [][]string{{"a"}, {"a", "b"}}},
+		{Network: "net", Groups: [][]string{{"a"}, {""}}},
+		{Name: "a b", Network: "net", Groups: [][]string{{"a"}, {"b"}}},
+		{Name: "a';reboot", Network: "net", Groups: [][]string{{"a"}, {"b"}}},
+	}
+	for _, pn := range invalid {
+		assert.Error(t, PartitionNetwork(pn), pn)
+	}
+
+	assert.NoError(t, HealNetwork(entity.HealNetwork{}))
+	assert.Error(t, HealNetwork(entity.HealNetwork{Name: "$(reboot)"}))
+}
+
+func TestOrderValidator_EmulationSchedule(t *testing.T) {
+	valid := entity.EmulationSchedule{
+		Container: "node0",
+		Network:   "net",
+		Entries:   []entity.ScheduleEntry{{Netem: netem.Netem{Delay: 100}}},
+	}
+	assert.NoError(t, EmulationSchedule(valid))
+	assert.NoError(t, EmulationSchedule(entity.EmulationSchedule{
+		Container: "node0", Network: "net", TraceFile: "trace"}))
+
+	invalid := []entity.EmulationSchedule{
+		{Network: "net", Entries: valid.Entries},
+		{Container: "node0", Entries: valid.Entries},
+		{Container: "node0", Network: "net"},
+		{Container: "node0", Network: "net", Entries: valid.Entries, TraceFile: "trace"},
+		{Container: "node0", Network: "net", Entries: []entity.ScheduleEntry{{Netem: netem.Netem{Loss: 200}}}},
+	}
+	negative := entity.ScheduleEntry{}
+	negative.Offset.Duration = -time.Second
+	invalid = append(invalid, entity.EmulationSchedule{Container: "node0", Network: "net",
+		Entries: []entity.ScheduleEntry{negative}})
+	for _, es := range invalid {
+		assert.Error(t, EmulationSchedule(es), es)
+	}
+}
+
+func TestOrderValidator_KillContainer(t *testing.T) {
+	for _, signal := range []string{"", "SIGKILL", "TERM", "9", "SIGRTMIN+3"} {
+		assert.NoError(t, KillContainer(entity.KillContainer{Name: "node0", Signal: signal}), signal)
+	}
+	assert.Error(t, KillContainer(entity.KillContainer{Signal: "SIGKILL"}))
+	assert.Error(t, KillContainer(entity.KillContainer{Name: "node0", Signal: "sigkill; reboot"}))
+}
+
+func TestOrderValidator_StopContainer(t *testing.T) {
+	assert.NoError(t, StopContainer(entity.StopContainer{Name: "node0"}))
+	assert.Error(t, StopContainer(entity.StopContainer{}))
+
+	rc := entity.RestartContainer{Name: "node0"}
+	rc.Timeout.Duration = -time.Second
+	assert.Error(t, RestartContainer(rc))
+}
+
+func TestOrderValidator_UpdateContainerResources(t *testing.T) {
+	pids := int64(100)
+	valid := []entity.UpdateContainerResources{
+		{Name: "node0", Cpus: "0.5"},
+		{Name: "node0", BoundCPUs: []int{0, 2}},
+		{Name: "node0", Memory: "512MB", MemorySwap: "-1"},
+		{Name: "node0", Memory: "256", MemorySwap: "1GB"},
+		{Name: "node0", PidsLimit: &pids},
+	}
+	for _, ucr := range valid {
+		assert.NoError(t, UpdateContainerResources(ucr), ucr)
+	}
+
+	negative := int64(-2)
+	invalid := []entity.UpdateContainerResources{
+		{Cpus: "1"},
+		{Name: "node0"},
+		{Name: "node0", Cpus: "fast"},
+		{Name: "node0", Cpus: "-1"},
+		{Name: "node0", BoundCPUs: []int{-1}},
+		{Name: "node0", Memory: "lots"},
+		{Name: "node0", Memory: "0"},
+		{Name: "node0", MemorySwap: "-2"},
+		{Name: "node0", Memory: "1GB", MemorySwap: "512MB"},
+		{Name: "node0", PidsLimit: &negative},
+	}
+	for _, ucr := range invalid {
+		assert.Error(t, UpdateContainerResources(ucr), ucr)
+	}
+}
+
+func TestOrderValidator_Chaos(t *testing.T) {
+	valid := entity.Chaos{Faults: []string{"kill", "pause", "restart"}, Fraction: 0.5}
+	valid.Interval.Duration = time.Minute
+	assert.NoError(t, Chaos(valid))
+
+	partition := valid
+	partition.Faults = []string{"partition"}
+	assert.Equal(t, ErrMissingNetwork, Chaos(partition))
+	partition.Network = "net"
+	assert.NoError(t, Chaos(partition))
+
+	invalid := []func(c *entity.Chaos){
+		func(c *entity.Chaos) { c.Name = "a b" },
+		func(c *entity.Chaos) { c.Faults = nil },
+		func(c *entity.Chaos) { c.Faults = []string{"explode"} },
+		func(c *entity.Chaos) { c.Fraction = 0 },
+		func(c *entity.Chaos) { c.Fraction = 1.5 },
+		func(c *entity.Chaos) { c.Interval.Duration = 0 },
+		func(c *entity.Chaos) { c.Duration.Duration = 2 * time.Minute },
+		func(c *entity.Chaos) { c.Rounds = -1 },
+	}
+	for i, change := range invalid {
+		c := valid
+		change(&c)
+		assert.Error(t, Chaos(c), i)
+	}
+}
+
+func TestOrderValidator_CollectStats(t *testing.T) {
+	assert.NoError(t, CollectStats(entity.CollectStats{}))
+
+	cs := entity.CollectStats{Selector: map[string]string{"role": "validator"}}
+	cs.Interval.Duration = time.Second
+	assert.NoError(t, CollectStats(cs))
+
+	cs.Interval.Duration = -time.Second
+	assert.Error(t, CollectStats(cs))
+	assert.Error(t, CollectStats(entity.CollectStats{Interval: command.InfiniteDuration}))
+}
+
+func TestOrderValidator_CopyFromContainer(t *testing.T) {
+	assert.NoError(t, CopyFromContainer(entity.CopyFromContainer{Path: "/data"}))
+	assert.NoError(t, CopyFromContainer(entity.CopyFromContainer{
+		Containers: []string{"node0"}, Path: "/data/keys/", Name: "keys"}))
+
+	invalid := []entity.CopyFromContainer{
+		{},
+		{Path: "data"},
+		{Path: "/data", Name: "a/b"},
+		{Path: "/data", Containers: []string{""}},
+	}
+	for _, cfc := range invalid {
+		assert.Error(t, CopyFromContainer(cfc), cfc)
+	}
 }
@@ -186,6 +186,8 @@ func dockerTest(clean bool) {
 	}
 	log.SetLevel(lvl)
 
+	events := service.NewEventService(conf.GetLogger())
+	stats := getStatsService(conf)
 	dockerUseCase := usecase.NewDockerUseCase(
 		service.NewDockerService(
 			repository.NewDockerRepository(conf.GetLogger()),
@@ -194,6 +196,9 @@ func dockerTest(clean bool) {
 				conf,
 				conf.GetLogger()),
 			conf.GetLogger()),
+		getScheduleService(conf, events),
+		getChaosService(conf, events),
+		stats,
 		conf.GetLogger())
 
 	if clean {